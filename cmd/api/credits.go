@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+func (app *application) listCreditsHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	_, err := app.models.Movies.Get(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	credits, err := app.models.Credits.GetForMovie(id)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"credits": credits}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) createCreditHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	_, err := app.models.Movies.Get(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		PersonID  string `json:"person_id"`
+		Role      string `json:"role"`
+		Character string `json:"character"`
+		Billing   int32  `json:"billing"`
+	}
+
+	err = app.readJSON(rw, r, &input)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	credit := &data.Credit{
+		MovieID:   id,
+		PersonID:  input.PersonID,
+		Role:      input.Role,
+		Character: input.Character,
+		Billing:   input.Billing,
+	}
+
+	v := validator.New()
+
+	if data.ValidateCredit(v, credit); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	person, err := app.models.People.Get(credit.PersonID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.failedValidationResponse(rw, r, map[string]string{"person_id": "must be an existing person"})
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	_, err = app.models.Credits.Insert(credit)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+	credit.Person = person
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/movies/%s/credits", id))
+
+	err = app.writeResponse(rw, r, http.StatusCreated, envelope{"credit": credit}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) deleteCreditHandler(rw http.ResponseWriter, r *http.Request) {
+	err := app.models.Credits.Delete(app.readIDParam(r), app.readCreditIDParam(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"message": "credit successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 // Generic helper method for logging error messages
@@ -13,11 +15,11 @@ func (app *application) logError(r *http.Request, err error) {
 	})
 }
 
-// Generic helper method for sending JSON-formatted error messages to client
+// Generic helper method for sending error messages to client in the media type it accepts
 func (app *application) errorResponse(rw http.ResponseWriter, r *http.Request, status int, message interface{}) {
 	env := envelope{"error": message}
 
-	err := app.writeJSON(rw, status, env, nil)
+	err := app.writeResponse(rw, r, status, env, nil)
 	if err != nil {
 		app.logError(r, err)
 		rw.WriteHeader(500)
@@ -46,6 +48,12 @@ func (app *application) methodNotAllowedResponse(w http.ResponseWriter, r *http.
 
 // 400 Bad Request
 func (app *application) badRequestResponse(rw http.ResponseWriter, r *http.Request, err error) {
+	// Bodies readJSON can't decode in the first place are an unsupported media type rather than malformed
+	if errors.Is(err, errUnsupportedBody) {
+		app.unsupportedMediaTypeResponse(rw, r, jsonType, xmlType, msgpackType)
+		return
+	}
+
 	app.errorResponse(rw, r, http.StatusBadRequest, err.Error())
 }
 
@@ -66,6 +74,35 @@ func (app *application) editConflictResponse(rw http.ResponseWriter, r *http.Req
 	app.errorResponse(rw, r, http.StatusConflict, message)
 }
 
+// 412 Precondition Failed
+func (app *application) preconditionFailedResponse(rw http.ResponseWriter, r *http.Request) {
+	message := "the record has been modified since it was retrieved, fetch it again and retry"
+	app.errorResponse(rw, r, http.StatusPreconditionFailed, message)
+}
+
+// 415 Unsupported Media Type
+func (app *application) unsupportedMediaTypeResponse(rw http.ResponseWriter, r *http.Request, supported ...string) {
+	message := fmt.Sprintf("the request body must be one of the media types: %s", strings.Join(supported, ", "))
+	app.errorResponse(rw, r, http.StatusUnsupportedMediaType, message)
+}
+
+// 406 Not Acceptable
+func (app *application) notAcceptableResponse(rw http.ResponseWriter, r *http.Request) {
+	message := fmt.Sprintf("the response can only be sent as one of the media types: %s, %s, %s or %s for lists", jsonType, xmlType, msgpackType, csvType)
+	app.errorResponse(rw, r, http.StatusNotAcceptable, message)
+}
+
+// 409 Conflict, the new movie is most likely a duplicate of the candidates
+func (app *application) duplicateMovieResponse(rw http.ResponseWriter, r *http.Request, candidates []string) {
+	message := "a movie with a similar title and year already exists, pass force=true to create it anyway"
+
+	err := app.writeResponse(rw, r, http.StatusConflict, envelope{"error": message, "candidates": candidates}, nil)
+	if err != nil {
+		app.logError(r, err)
+		rw.WriteHeader(500)
+	}
+}
+
 // 401 Unauthorized
 func (app *application) invalidCredentialsResponse(rw http.ResponseWriter, r *http.Request) {
 	message := "invalid authentication credentials"
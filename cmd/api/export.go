@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+// Number of movies written between two flushes of an export response
+const exportFlushRows = 100
+
+// Supported export formats & their media types, ndjson & csv exports can be imported again
+var exportFormats = map[string]string{
+	"ndjson": ndjsonType,
+	"csv":    csvType,
+	"json":   "application/json",
+}
+
+// movieEncoder writes movies to an export response in a specific format
+type movieEncoder interface {
+	encode(movie *data.Movie) error
+	flush() error
+	close() error
+}
+
+func (app *application) exportMoviesHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	query := app.readMovieQuery(qs, v)
+	sort := app.readString(qs, "sort", "id")
+	format := app.readString(qs, "format", "ndjson")
+	runtimeFormat := app.readRuntimeFormat(qs, v)
+
+	data.ValidateMovieQuery(v, query)
+	v.Check(validator.In(sort, movieSortSafelist...), "sort", "invalid sort value")
+	v.Check(exportFormats[format] != "", "format", "must be one of ndjson, csv or json")
+
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	buf := bufio.NewWriter(rw)
+	flusher, _ := rw.(http.Flusher)
+
+	var enc movieEncoder
+
+	// Headers are only sent with the first movie, errors before that still get a proper error response
+	start := func() error {
+		filename := fmt.Sprintf("movies-%s.%s", time.Now().Format("20060102"), format)
+
+		rw.Header().Set("Content-Type", exportFormats[format])
+		rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		rw.WriteHeader(http.StatusOK)
+
+		var err error
+		enc, err = newMovieEncoder(format, buf)
+		return err
+	}
+
+	rows := 0
+	err := app.models.Movies.Export(query, sort, func(movie *data.Movie) error {
+		if enc == nil {
+			err := start()
+			if err != nil {
+				return err
+			}
+		}
+
+		movie.RuntimeFormat = runtimeFormat
+
+		err := enc.encode(movie)
+		if err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushRows != 0 {
+			return nil
+		}
+
+		err = enc.flush()
+		if err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	if enc == nil {
+		if err != nil {
+			app.serverErrorResponse(rw, r, err)
+			return
+		}
+
+		err = start()
+	}
+
+	if err != nil {
+		// The status has already been sent, the truncated body is all the client gets
+		app.logError(r, err)
+		return
+	}
+
+	err = enc.close()
+	if err != nil {
+		app.logError(r, err)
+	}
+}
+
+// newMovieEncoder returns the encoder for format & writes its leading part, e.g. the CSV header
+func newMovieEncoder(format string, w *bufio.Writer) (movieEncoder, error) {
+	switch format {
+	case "csv":
+		enc := &csvMovieEncoder{w: w, csv: csv.NewWriter(w)}
+		return enc, enc.csv.Write(movieCSVHeader)
+	case "json":
+		enc := &jsonMovieEncoder{w: w}
+		_, err := io.WriteString(w, `{"movies":[`)
+		return enc, err
+	default:
+		return &ndjsonMovieEncoder{w: w, enc: json.NewEncoder(w)}, nil
+	}
+}
+
+// ndjsonMovieEncoder writes one JSON movie per line
+type ndjsonMovieEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (e *ndjsonMovieEncoder) encode(movie *data.Movie) error {
+	return e.enc.Encode(movie)
+}
+
+func (e *ndjsonMovieEncoder) flush() error {
+	return e.w.Flush()
+}
+
+func (e *ndjsonMovieEncoder) close() error {
+	return e.flush()
+}
+
+// csvMovieEncoder writes movies with the columns read by the import endpoint
+type csvMovieEncoder struct {
+	w   *bufio.Writer
+	csv *csv.Writer
+}
+
+func (e *csvMovieEncoder) encode(movie *data.Movie) error {
+	return e.csv.Write([]string{
+		movie.Title,
+		fmt.Sprint(movie.Year),
+		movie.Runtime.Format(movie.RuntimeFormat),
+		strings.Join(movie.Genres, ","),
+	})
+}
+
+func (e *csvMovieEncoder) flush() error {
+	e.csv.Flush()
+	if err := e.csv.Error(); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+func (e *csvMovieEncoder) close() error {
+	return e.flush()
+}
+
+// jsonMovieEncoder writes a single JSON document enveloped like the list endpoint
+type jsonMovieEncoder struct {
+	w    *bufio.Writer
+	rows int
+}
+
+func (e *jsonMovieEncoder) encode(movie *data.Movie) error {
+	js, err := json.Marshal(movie)
+	if err != nil {
+		return err
+	}
+
+	if e.rows > 0 {
+		err = e.w.WriteByte(',')
+		if err != nil {
+			return err
+		}
+	}
+	e.rows++
+
+	_, err = e.w.Write(js)
+	return err
+}
+
+func (e *jsonMovieEncoder) flush() error {
+	return e.w.Flush()
+}
+
+func (e *jsonMovieEncoder) close() error {
+	_, err := io.WriteString(e.w, "]}\n")
+	if err != nil {
+		return err
+	}
+	return e.flush()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMovieExport(t *testing.T) {
+	ts := newTestServer(t)
+
+	ts.createMovie(t, `{"title":"Collateral","year":2004,"runtime":"120 mins","genres":["crime"]}`)
+	ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	ts.createMovie(t, `{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action"]}`)
+
+	rr := ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/export?format=csv&sort=year"}, http.StatusOK, nil)
+	want := "title,year,runtime,genres\nHeat,1995,170 mins,crime\nRonin,1998,122 mins,action\nCollateral,2004,120 mins,crime\n"
+	if rr.Body.String() != want {
+		t.Errorf("CSV export = %q, want %q", rr.Body, want)
+	}
+
+	// NDJSON is the default, one movie per line
+	rr = ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/export?genres_any=crime&sort=-year"}, http.StatusOK, nil)
+	if got := rr.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", got)
+	}
+
+	var titles []string
+	for _, line := range strings.Split(strings.TrimSpace(rr.Body.String()), "\n") {
+		var movie testMovie
+		if err := json.Unmarshal([]byte(line), &movie); err != nil {
+			t.Fatalf("NDJSON line %q: %v", line, err)
+		}
+		titles = append(titles, movie.Title)
+	}
+	if strings.Join(titles, ",") != "Collateral,Heat" {
+		t.Errorf("NDJSON export = %v, want Collateral,Heat", titles)
+	}
+
+	// An export can be imported again, every movie is unchanged
+	var again struct {
+		Import struct {
+			Unchanged int `json:"unchanged"`
+		} `json:"import"`
+	}
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/import?upsert=true", body: want, headers: map[string]string{"Content-Type": "text/csv"}}, http.StatusOK, &again)
+	if again.Import.Unchanged != 3 {
+		t.Errorf("re-import unchanged = %d, want 3", again.Import.Unchanged)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/export?format=xlsx"}, http.StatusUnprocessableEntity, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/export?sort=budget"}, http.StatusUnprocessableEntity, nil)
+}
@@ -13,7 +13,7 @@ func (app *application) healthcheckHandler(rw http.ResponseWriter, r *http.Reque
 		},
 	}
 
-	err := app.writeJSON(rw, http.StatusOK, env, nil)
+	err := app.writeResponse(rw, r, http.StatusOK, env, nil)
 	if err != nil {
 		app.serverErrorResponse(rw, r, err)
 	}
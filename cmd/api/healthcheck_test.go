@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHealthcheck(t *testing.T) {
+	ts := newTestServer(t)
+
+	var res struct {
+		Status     string            `json:"status"`
+		SystemInfo map[string]string `json:"system_info"`
+	}
+	rr := ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/healthcheck", token: "-"}, http.StatusOK, &res)
+
+	if res.Status != "available" || res.SystemInfo["version"] != version {
+		t.Errorf("healthcheck = %+v, want available & version %s", res, version)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"application/xml", "application/xml"},
+		{"application/msgpack", "application/msgpack"},
+		{"image/png, application/json;q=0.5", "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			rr := ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/healthcheck", token: "-", headers: map[string]string{"Accept": tt.accept}}, http.StatusOK, nil)
+			if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+		})
+	}
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/healthcheck", token: "-", headers: map[string]string{"Accept": "image/png"}}, http.StatusNotAcceptable, nil)
+}
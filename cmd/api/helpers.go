@@ -1,15 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 
+	"github.com/BunnyTheLifeguard/greenlight/internal/codec"
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/jsonpatch"
 	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
 	"github.com/julienschmidt/httprouter"
 )
@@ -21,79 +26,200 @@ func (app *application) readIDParam(r *http.Request) string {
 	return id
 }
 
+// readVersionParam returns the :version parameter of the current URL, versions start at 1
+func (app *application) readVersionParam(r *http.Request) (int32, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	version, err := strconv.ParseInt(params.ByName("version"), 10, 32)
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid version parameter")
+	}
+
+	return int32(version), nil
+}
+
+// readCreditIDParam returns the :credit_id parameter of the current URL
+func (app *application) readCreditIDParam(r *http.Request) string {
+	params := httprouter.ParamsFromContext(r.Context())
+	return params.ByName("credit_id")
+}
+
 type envelope map[string]interface{}
 
-func (app *application) writeJSON(rw http.ResponseWriter, status int, data envelope, headers http.Header) error {
+// writeResponse sends data in the media type the Accept header of r prefers, see acceptedTypes. Successful responses no accepted
+// media type can represent are answered with 406 Not Acceptable instead, error responses fall back to JSON
+func (app *application) writeResponse(rw http.ResponseWriter, r *http.Request, status int, data envelope, headers http.Header) error {
 	js, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
 
-	js = append(js, '\n')
+	mediaType, body, err := encodeResponse(js, acceptedTypes(r))
+	if err != nil {
+		return err
+	}
+
+	if mediaType == "" {
+		if status < http.StatusBadRequest {
+			app.notAcceptableResponse(rw, r)
+			return nil
+		}
+		mediaType, body = jsonType, append(js, '\n')
+	}
 
 	for key, value := range headers {
 		rw.Header()[key] = value
 	}
 
-	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Add("Vary", "Accept")
+	rw.Header().Set("Content-Type", mediaType)
 	rw.WriteHeader(status)
-	rw.Write(js)
+	rw.Write(body)
 
 	return nil
 }
 
+// readJSON decodes the request body into dst, bodies in XML & MessagePack are converted to JSON first, see requestFormat
 func (app *application) readJSON(rw http.ResponseWriter, r *http.Request, dst interface{}) error {
 	// Limit size of req body to 1MB
 	maxBytes := 1_048_576
 	r.Body = http.MaxBytesReader(rw, r.Body, int64(maxBytes))
 
+	format, err := requestFormat(r)
+	if err != nil {
+		return err
+	}
+
+	if format != formatJSON {
+		return app.readConverted(r.Body, format, dst, maxBytes)
+	}
+
 	// Unknown JSON fields throw errors
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
 
-	err := dec.Decode(dst)
+	err = dec.Decode(dst)
 	if err != nil {
-		var syntaxError *json.SyntaxError
-		var unmarshalTypeError *json.UnmarshalTypeError
-		var invalidUnmarshalError *json.InvalidUnmarshalError
+		return app.jsonError(err, maxBytes)
+	}
+
+	// Check for additional JSON values in req body
+	err = dec.Decode(&struct{}{})
+	if err != io.EOF {
+		return errors.New("body must only contain a single JSON value")
+	}
 
-		switch {
-		case errors.As(err, &syntaxError):
-			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+	return nil
+}
 
-		case errors.Is(err, io.ErrUnexpectedEOF):
-			return errors.New("body contains badly-formed JSON")
+// jsonError translates a JSON decoding error into a message safe to send to the client
+func (app *application) jsonError(err error, maxBytes int) error {
+	return app.bodyError(err, formatJSON, maxBytes)
+}
 
-		case errors.As(err, &unmarshalTypeError):
-			if unmarshalTypeError.Field != "" {
-				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
-			}
-			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+// bodyError translates a decoding error of a body in format into a message safe to send to the client. Bodies in other formats
+// than JSON are decoded after converting them to JSON, their errors leave out the offsets into the converted body
+func (app *application) bodyError(err error, format string, maxBytes int) error {
+	var syntaxError *json.SyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	var invalidUnmarshalError *json.InvalidUnmarshalError
 
-		case errors.Is(err, io.EOF):
-			return errors.New("body must not be empty")
+	switch {
+	case errors.As(err, &syntaxError):
+		return fmt.Errorf("body contains badly-formed %s (at character %d)", format, syntaxError.Offset)
 
-		case strings.HasPrefix(err.Error(), "json: unknown field"):
-			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field")
-			return fmt.Errorf("body contains unknown key %s", fieldName)
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, codec.ErrSyntax):
+		return fmt.Errorf("body contains badly-formed %s", format)
 
-		case err.Error() == "http: request body too large":
-			return fmt.Errorf("body must not be larger than %d bytes", maxBytes)
+	case errors.Is(err, codec.ErrMultipleValues):
+		return fmt.Errorf("body must only contain a single %s value", format)
 
-		case errors.As(err, &invalidUnmarshalError):
-			panic(err)
+	case errors.As(err, &unmarshalTypeError):
+		if unmarshalTypeError.Field != "" {
+			return fmt.Errorf("body contains incorrect %s type for field %q", format, unmarshalTypeError.Field)
+		}
+		if format != formatJSON {
+			return fmt.Errorf("body contains incorrect %s type", format)
+		}
+		return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+
+	case errors.Is(err, io.EOF):
+		return errors.New("body must not be empty")
+
+	case strings.HasPrefix(err.Error(), "json: unknown field"):
+		fieldName := strings.TrimPrefix(err.Error(), "json: unknown field")
+		return fmt.Errorf("body contains unknown key %s", fieldName)
+
+	case err.Error() == "http: request body too large":
+		return fmt.Errorf("body must not be larger than %d bytes", maxBytes)
+
+	case errors.As(err, &invalidUnmarshalError):
+		panic(err)
+
+	default:
+		return err
+	}
+}
+
+// errUnprocessablePatch wraps errors of patches which are well-formed but can't be applied to the record
+var errUnprocessablePatch = errors.New("patch can't be applied")
+
+// readPatch reads a JSON Merge Patch or JSON Patch body, applies it to the JSON representation of doc & decodes the result back into doc
+func (app *application) readPatch(rw http.ResponseWriter, r *http.Request, mediaType string, doc interface{}) error {
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var target interface{}
+	err = json.Unmarshal(js, &target)
+	if err != nil {
+		return err
+	}
+
+	var patched interface{}
 
-		default:
+	switch mediaType {
+	case jsonpatch.MergePatchType:
+		var patch interface{}
+		err = app.readJSON(rw, r, &patch)
+		if err != nil {
 			return err
 		}
+		patched = jsonpatch.MergePatch(target, patch)
+
+	case jsonpatch.JSONPatchType:
+		var ops []jsonpatch.Operation
+		err = app.readJSON(rw, r, &ops)
+		if err != nil {
+			return err
+		}
+		patched, err = jsonpatch.Apply(target, ops)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errUnprocessablePatch, err)
+		}
+
+	default:
+		return fmt.Errorf("unsupported patch media type %q", mediaType)
 	}
 
-	// Check for additional JSON values in req body
-	err = dec.Decode(&struct{}{})
-	if err != io.EOF {
-		return errors.New("body must only contain a single JSON value")
+	js, err = json.Marshal(patched)
+	if err != nil {
+		return err
 	}
 
+	// Decode into a fresh value so removed members end up as zero values
+	fresh := reflect.New(reflect.TypeOf(doc).Elem())
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+
+	err = dec.Decode(fresh.Interface())
+	if err != nil {
+		return fmt.Errorf("%w: %s", errUnprocessablePatch, app.jsonError(err, len(js)))
+	}
+
+	reflect.ValueOf(doc).Elem().Set(fresh.Elem())
 	return nil
 }
 
@@ -117,6 +243,24 @@ func (app *application) readCSV(qs url.Values, key string, defaultValue []string
 	return strings.Split(csv, ",")
 }
 
+// readFields extracts the comma separated fields query string value, the returned error names all fields missing from the safelist
+func (app *application) readFields(qs url.Values, safelist []string) ([]string, error) {
+	fields := app.readCSV(qs, "fields", []string{})
+
+	var unknown []string
+	for _, field := range fields {
+		if !validator.In(field, safelist...) {
+			unknown = append(unknown, strconv.Quote(field))
+		}
+	}
+
+	if len(unknown) != 0 {
+		return nil, fmt.Errorf("unknown fields %s, must be one of %s", strings.Join(unknown, ", "), strings.Join(safelist, ", "))
+	}
+
+	return fields, nil
+}
+
 func (app *application) readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
 	s := qs.Get(key)
 
@@ -133,6 +277,103 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	return i
 }
 
+func (app *application) readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+
+	return b
+}
+
+// readRuntime reads a runtime in any format accepted by data.ParseRuntime, e.g. 112, 1h 52m or PT1H52M
+func (app *application) readRuntime(qs url.Values, key string, defaultValue data.Runtime, v *validator.Validator) data.Runtime {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	runtime, err := data.ParseRuntime(s)
+	if err != nil {
+		v.AddError(key, "must be a runtime like 112, 112 mins, 1h 52m or PT1H52M")
+		return defaultValue
+	}
+
+	return runtime
+}
+
+// etag returns the strong entity tag for a record version
+func (app *application) etag(version int32) string {
+	return strconv.Quote(strconv.Itoa(int(version)))
+}
+
+// ifMatch reports if the request has no If-Match header or one of its entity tags matches etag
+func (app *application) ifMatch(r *http.Request, etag string) bool {
+	values := r.Header.Values("If-Match")
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, candidate := range strings.Split(strings.Join(values, ","), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// paginationLinks builds an RFC 8288 Link header value for a list response, keeping all other query parameters of the request
+func (app *application) paginationLinks(r *http.Request, metadata data.Metadata) string {
+	link := func(rel string, set map[string]string) string {
+		qs := r.URL.Query()
+		for key, value := range set {
+			if value == "" {
+				qs.Del(key)
+			} else {
+				qs.Set(key, value)
+			}
+		}
+
+		u := url.URL{Path: r.URL.Path, RawQuery: qs.Encode()}
+		return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+	}
+
+	var links []string
+
+	switch {
+	// Keyset pages can only link forward & back to the start
+	case metadata.CurrentPage == 0:
+		if metadata.NextCursor != "" {
+			links = append(links, link("next", map[string]string{"cursor": metadata.NextCursor}))
+		}
+		if metadata.TotalRecords != 0 {
+			links = append(links, link("first", map[string]string{"cursor": ""}))
+		}
+
+	default:
+		if metadata.HasNext {
+			links = append(links, link("next", map[string]string{"page": strconv.Itoa(metadata.CurrentPage + 1)}))
+		}
+		if metadata.CurrentPage > metadata.FirstPage {
+			links = append(links, link("prev", map[string]string{"page": strconv.Itoa(metadata.CurrentPage - 1)}))
+		}
+		links = append(links, link("first", map[string]string{"page": strconv.Itoa(metadata.FirstPage)}))
+		links = append(links, link("last", map[string]string{"page": strconv.Itoa(metadata.LastPage)}))
+	}
+
+	return strings.Join(links, ", ")
+}
+
 func (app *application) background(fn func()) {
 	// Increment WaitGroup counter
 	app.wg.Add(1)
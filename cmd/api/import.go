@@ -0,0 +1,614 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+// Media types of bulk movie import & export bodies
+const (
+	ndjsonType = "application/x-ndjson"
+	csvType    = "text/csv"
+)
+
+const (
+	// Limit size of import bodies to 64MB, single lines are limited like readJSON bodies
+	maxImportBytes     = 64 << 20
+	maxImportLineBytes = 1_048_576
+
+	// Number of movies inserted per InsertMany call
+	importBatchSize = 500
+)
+
+// movieCSVHeader lists the columns of movie CSV files, genres are comma separated within their cell
+var movieCSVHeader = []string{"title", "year", "runtime", "genres"}
+
+// importRow is a single movie read from an import body, errors holds the problems if the row couldn't be parsed
+type importRow struct {
+	line   int
+	movie  *data.Movie
+	errors map[string]string
+}
+
+// importResult reports the outcome of a single import row, candidates lists the stored movies a duplicate is similar to
+type importResult struct {
+	Line       int               `json:"line"`
+	Status     string            `json:"status"`
+	ID         string            `json:"id,omitempty"`
+	Candidates []string          `json:"candidates,omitempty"`
+	Errors     map[string]string `json:"errors,omitempty"`
+}
+
+// movieImport validates & stores import rows, new movies are buffered & inserted in batches
+type movieImport struct {
+	app    *application
+	userID string
+	dryRun bool
+	upsert bool
+	force  bool
+
+	pending        []*data.Movie
+	pendingResults []*importResult
+	seen           map[string]bool
+
+	// Rows to be created so far, by normalized title & by external ID, to find duplicates within the import
+	titles      map[string][]importedTitle
+	externalIDs map[string]int
+
+	report importReport
+}
+
+// importedTitle is the year & line of a row to be created
+type importedTitle struct {
+	year int32
+	line int
+}
+
+// importReport is sent to the client once the import body has been processed, failed counts the rows which weren't stored because
+// the import stopped with an error
+type importReport struct {
+	DryRun     bool            `json:"dry_run"`
+	Created    int             `json:"created"`
+	Updated    int             `json:"updated"`
+	Unchanged  int             `json:"unchanged"`
+	Duplicates int             `json:"duplicates"`
+	Invalid    int             `json:"invalid"`
+	Failed     int             `json:"failed"`
+	Results    []*importResult `json:"results"`
+}
+
+func (app *application) importMoviesHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	imp := &movieImport{
+		app:    app,
+		userID: app.contextGetUser(r).ID,
+		dryRun: app.readBool(qs, "dry_run", false, v),
+		upsert: app.readBool(qs, "upsert", false, v),
+		force:  app.readBool(qs, "force", false, v),
+		seen:   make(map[string]bool),
+
+		titles:      make(map[string][]importedTitle),
+		externalIDs: make(map[string]int),
+	}
+	imp.report = importReport{DryRun: imp.dryRun, Results: []*importResult{}}
+
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	// Import bodies are read row by row, only their total size is limited
+	r.Body = http.MaxBytesReader(rw, r.Body, maxImportBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var err error
+
+	switch mediaType {
+	case ndjsonType:
+		err = app.readMovieNDJSON(r.Body, imp.add)
+	case csvType:
+		err = app.readMovieCSV(r.Body, imp.add)
+	default:
+		app.unsupportedMediaTypeResponse(rw, r, ndjsonType, csvType)
+		return
+	}
+
+	// Rows read before an unreadable part of the body are stored as well
+	if err == nil || errors.As(err, new(*importError)) {
+		flushErr := imp.flush()
+		if flushErr != nil {
+			err = flushErr
+		}
+	}
+
+	if err != nil {
+		// Rows which were read but not stored are reported as failed
+		imp.fail()
+
+		// Rows stored before the import stopped are reported along with the error
+		status, message := http.StatusInternalServerError, "The server encountered a problem and could not process your request"
+
+		var badRequest *importError
+		switch {
+		case errors.As(err, &badRequest):
+			status, message = http.StatusBadRequest, badRequest.Error()
+		default:
+			app.logError(r, err)
+		}
+
+		err = app.writeResponse(rw, r, status, envelope{"error": message, "import": imp.report}, nil)
+		if err != nil {
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"import": imp.report}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+// importError is returned if an import body can't be read any further
+type importError struct {
+	message string
+}
+
+func (e *importError) Error() string {
+	return e.message
+}
+
+// bodyError turns errors reading an import body into an importError
+func bodyError(err error) error {
+	if err.Error() == "http: request body too large" {
+		return &importError{fmt.Sprintf("body must not be larger than %d bytes", maxImportBytes)}
+	}
+
+	return &importError{err.Error()}
+}
+
+// add validates a row & either stores it or buffers it for the next batch insert
+func (imp *movieImport) add(row importRow) error {
+	result := &importResult{Line: row.line}
+	imp.report.Results = append(imp.report.Results, result)
+
+	if row.errors == nil {
+		v := validator.New()
+
+		if data.ValidateMovie(v, row.movie); !v.Valid() {
+			row.errors = v.Errors
+		}
+	}
+
+	if row.errors != nil {
+		result.Status = "invalid"
+		result.Errors = row.errors
+		imp.report.Invalid++
+		return nil
+	}
+
+	row.movie.CreatedBy = imp.userID
+
+	if imp.upsert {
+		updated, err := imp.update(result, row.movie)
+		if err != nil || updated {
+			return err
+		}
+	}
+
+	// Like creates, near duplicates of stored movies & earlier rows are only imported with force=true
+	if !imp.force {
+		duplicate, err := imp.duplicate(result, row)
+		if err != nil || duplicate {
+			return err
+		}
+	}
+
+	invalid, err := imp.usesExternalIDs(result, row)
+	if err != nil || invalid {
+		return err
+	}
+
+	key := data.NormalizeTitle(row.movie.Title)
+	imp.titles[key] = append(imp.titles[key], importedTitle{year: row.movie.Year, line: row.line})
+	for _, id := range externalIDKeys(row.movie.ExternalIDs) {
+		imp.externalIDs[id] = row.line
+	}
+
+	// Dry runs report the rows as created right away, otherwise they are once their batch is stored
+	if imp.dryRun {
+		result.Status = "created"
+		imp.report.Created++
+		return nil
+	}
+
+	imp.pending = append(imp.pending, row.movie)
+	imp.pendingResults = append(imp.pendingResults, result)
+
+	if len(imp.pending) >= importBatchSize {
+		return imp.flush()
+	}
+
+	return nil
+}
+
+// duplicate reports a row as a duplicate if its normalized title & year at most one apart match a stored movie or an earlier row to be
+// created, reports false if there is no such movie
+func (imp *movieImport) duplicate(result *importResult, row importRow) (bool, error) {
+	for _, earlier := range imp.titles[data.NormalizeTitle(row.movie.Title)] {
+		if earlier.year >= row.movie.Year-1 && earlier.year <= row.movie.Year+1 {
+			result.Status = "duplicate"
+			result.Errors = map[string]string{"title": fmt.Sprintf("is too similar to the movie on line %d, pass force=true to import it anyway", earlier.line)}
+			imp.report.Duplicates++
+			return true, nil
+		}
+	}
+
+	duplicates, err := imp.app.models.Movies.FindDuplicates(row.movie.Title, row.movie.Year)
+	if err != nil {
+		return false, err
+	}
+
+	if len(duplicates) == 0 {
+		return false, nil
+	}
+
+	result.Status = "duplicate"
+	for _, duplicate := range duplicates {
+		result.Candidates = append(result.Candidates, duplicate.ID)
+	}
+	result.Errors = map[string]string{"title": "is too similar to an existing movie, pass force=true to import it anyway"}
+	imp.report.Duplicates++
+	return true, nil
+}
+
+// usesExternalIDs reports a row as invalid if a stored movie or an earlier row to be created has any of its external IDs
+func (imp *movieImport) usesExternalIDs(result *importResult, row importRow) (bool, error) {
+	ids := row.movie.ExternalIDs
+	if ids.Empty() {
+		return false, nil
+	}
+
+	used := false
+	for _, id := range externalIDKeys(ids) {
+		if _, ok := imp.externalIDs[id]; ok {
+			used = true
+		}
+	}
+
+	if !used {
+		_, err := imp.app.models.Movies.GetByExternalID(*ids)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return false, nil
+		case err != nil:
+			return false, err
+		}
+	}
+
+	imp.invalidExternalIDs(result)
+	return true, nil
+}
+
+// invalidExternalIDs reports a row as invalid as its external IDs are used by another movie
+func (imp *movieImport) invalidExternalIDs(result *importResult) {
+	result.Status = "invalid"
+	result.Errors = map[string]string{"external_ids": "must not be used by another movie"}
+	imp.report.Invalid++
+}
+
+// externalIDKeys returns a key for every external ID which is set, keys of different kinds of IDs never collide
+func externalIDKeys(ids *data.ExternalIDs) []string {
+	if ids.Empty() {
+		return nil
+	}
+
+	var keys []string
+	if ids.IMDb != "" {
+		keys = append(keys, "imdb:"+ids.IMDb)
+	}
+	if ids.TMDb != 0 {
+		keys = append(keys, "tmdb:"+strconv.FormatInt(ids.TMDb, 10))
+	}
+	if ids.Wikidata != "" {
+		keys = append(keys, "wikidata:"+ids.Wikidata)
+	}
+	return keys
+}
+
+// update applies a row to the existing movie with the same title & year, reports false if there is none
+func (imp *movieImport) update(result *importResult, movie *data.Movie) (bool, error) {
+	key := movie.Title + "\x00" + strconv.Itoa(int(movie.Year))
+
+	// Earlier rows with the same title & year have to be stored before they can be found
+	if imp.seen[key] {
+		if imp.dryRun {
+			result.Status = "updated"
+			imp.report.Updated++
+			return true, nil
+		}
+
+		err := imp.flush()
+		if err != nil {
+			return false, err
+		}
+	}
+	imp.seen[key] = true
+
+	existing, err := imp.app.models.Movies.GetByTitle(movie.Title, movie.Year)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	result.ID = existing.ID
+
+	// Rows without external IDs keep the existing ones
+	if movie.ExternalIDs.Empty() {
+		movie.ExternalIDs = existing.ExternalIDs
+	}
+
+	update := data.NewMovieUpdate(existing, movie)
+	if update.Empty() {
+		result.Status = "unchanged"
+		imp.report.Unchanged++
+		return true, nil
+	}
+
+	if !imp.dryRun {
+		err = imp.app.models.Movies.Patch(existing, update, data.Change{Action: data.RevisionUpdate, UserID: imp.userID})
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrEditConflict):
+				result.Status = "invalid"
+				result.Errors = map[string]string{"movie": "was modified during the import, please try again"}
+				imp.report.Invalid++
+				return true, nil
+			case errors.Is(err, data.ErrDuplicateExternalID):
+				result.ID = ""
+				imp.invalidExternalIDs(result)
+				return true, nil
+			default:
+				return false, err
+			}
+		}
+	}
+
+	result.Status = "updated"
+	imp.report.Updated++
+	return true, nil
+}
+
+// flush inserts all buffered movies, their revisions are written along with them. The rows are reported as created once they are
+// stored, if the batch fails on an external ID used by a movie in the trash the rows are inserted one by one
+func (imp *movieImport) flush() error {
+	if len(imp.pending) == 0 {
+		return nil
+	}
+
+	err := imp.app.models.Movies.InsertMany(imp.pending)
+	switch {
+	case errors.Is(err, data.ErrDuplicateExternalID):
+		for len(imp.pending) > 0 {
+			movie, result := imp.pending[0], imp.pendingResults[0]
+
+			id, err := imp.app.models.Movies.Insert(movie)
+			switch {
+			case errors.Is(err, data.ErrDuplicateExternalID):
+				imp.invalidExternalIDs(result)
+			case err != nil:
+				return err
+			default:
+				imp.created(result, id)
+			}
+
+			imp.pending, imp.pendingResults = imp.pending[1:], imp.pendingResults[1:]
+		}
+
+	case err != nil:
+		return err
+
+	default:
+		for i, movie := range imp.pending {
+			imp.created(imp.pendingResults[i], movie.ID)
+		}
+	}
+
+	imp.pending = imp.pending[:0]
+	imp.pendingResults = imp.pendingResults[:0]
+	return nil
+}
+
+// created reports a row as stored as the movie with id
+func (imp *movieImport) created(result *importResult, id string) {
+	result.Status = "created"
+	result.ID = id
+	imp.report.Created++
+}
+
+// fail reports the buffered rows as failed, they weren't stored as the import stopped with an error
+func (imp *movieImport) fail() {
+	for _, result := range imp.pendingResults {
+		result.Status = "failed"
+		imp.report.Failed++
+	}
+
+	imp.pending = imp.pending[:0]
+	imp.pendingResults = imp.pendingResults[:0]
+}
+
+// readMovieNDJSON reads one JSON movie per line & passes each to fn, blank lines are skipped
+func (app *application) readMovieNDJSON(body io.Reader, fn func(row importRow) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineBytes)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		js := bytes.TrimSpace(scanner.Bytes())
+		if len(js) == 0 {
+			continue
+		}
+
+		var input struct {
+			Title       string            `json:"title"`
+			Year        int32             `json:"year"`
+			Runtime     data.Runtime      `json:"runtime"`
+			Genres      []string          `json:"genres"`
+			ExternalIDs *data.ExternalIDs `json:"external_ids"`
+		}
+
+		row := importRow{line: line}
+
+		dec := json.NewDecoder(bytes.NewReader(js))
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&input)
+		if err == nil && dec.More() {
+			err = errors.New("body must only contain a single JSON value")
+		}
+
+		if err != nil {
+			row.errors = map[string]string{"json": app.jsonError(err, maxImportLineBytes).Error()}
+		} else {
+			row.movie = &data.Movie{
+				Title:       input.Title,
+				Year:        input.Year,
+				Runtime:     input.Runtime,
+				Genres:      input.Genres,
+				ExternalIDs: input.ExternalIDs,
+			}
+		}
+
+		err = fn(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return &importError{fmt.Sprintf("line %d must not be larger than %d bytes", line+1, maxImportLineBytes)}
+		}
+		return bodyError(err)
+	}
+
+	return nil
+}
+
+// readMovieCSV reads movies from a CSV body starting with a header row & passes each to fn
+func (app *application) readMovieCSV(body io.Reader, fn func(row importRow) error) error {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return &importError{"body must not be empty"}
+		}
+		return bodyError(err)
+	}
+
+	// Columns may be given in any order, but all of them are required
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !app.contains(movieCSVHeader, name) {
+			return &importError{fmt.Sprintf("header contains unknown column %q", name)}
+		}
+		columns[name] = i
+	}
+
+	for _, name := range movieCSVHeader {
+		if _, ok := columns[name]; !ok {
+			return &importError{fmt.Sprintf("header is missing column %q", name)}
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var parseError *csv.ParseError
+		switch {
+		case errors.As(err, &parseError):
+			// Malformed rows are reported, the reader continues with the next one
+			row := importRow{line: parseError.StartLine, errors: map[string]string{"csv": parseError.Err.Error()}}
+			err = fn(row)
+			if err != nil {
+				return err
+			}
+			continue
+		case err != nil:
+			return bodyError(err)
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		err = fn(parseMovieRecord(line, record, columns))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseMovieRecord converts a CSV record to an import row
+func parseMovieRecord(line int, record []string, columns map[string]int) importRow {
+	row := importRow{line: line, movie: &data.Movie{}}
+	errs := make(map[string]string)
+
+	row.movie.Title = strings.TrimSpace(record[columns["title"]])
+
+	if year := strings.TrimSpace(record[columns["year"]]); year != "" {
+		y, err := strconv.ParseInt(year, 10, 32)
+		if err != nil {
+			errs["year"] = "must be an integer value"
+		}
+		row.movie.Year = int32(y)
+	}
+
+	if runtime := strings.TrimSpace(record[columns["runtime"]]); runtime != "" {
+		rt, err := data.ParseRuntime(runtime)
+		if err != nil {
+			errs["runtime"] = err.Error()
+		}
+		row.movie.Runtime = rt
+	}
+
+	if genres := strings.TrimSpace(record[columns["genres"]]); genres != "" {
+		row.movie.Genres = strings.Split(genres, ",")
+		for i := range row.movie.Genres {
+			row.movie.Genres[i] = strings.TrimSpace(row.movie.Genres[i])
+		}
+	}
+
+	if len(errs) > 0 {
+		row.errors = errs
+	}
+
+	return row
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMovieImport(t *testing.T) {
+	ts := newTestServer(t)
+	ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+
+	body := strings.Join([]string{
+		`{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action"]}`,
+		`{"title":"heat","year":1996,"runtime":"170 mins","genres":["crime"]}`,
+		`{"title":"Collateral","year":2004,"runtime":"120 mins","genres":["crime"],"external_ids":{"imdb":"tt0369339"}}`,
+		`{"title":"Copy","year":2004,"runtime":"120 mins","genres":["crime"],"external_ids":{"imdb":"tt0369339"}}`,
+		`{"title":"","year":2004}`,
+	}, "\n")
+
+	var res struct {
+		Import struct {
+			Created    int `json:"created"`
+			Duplicates int `json:"duplicates"`
+			Invalid    int `json:"invalid"`
+			Results    []struct {
+				Line   int    `json:"line"`
+				Status string `json:"status"`
+				ID     string `json:"id"`
+			} `json:"results"`
+		} `json:"import"`
+	}
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/import", body: body, headers: map[string]string{"Content-Type": "application/x-ndjson"}}, http.StatusOK, &res)
+
+	wantStatus := []string{"created", "duplicate", "created", "invalid", "invalid"}
+	for i, result := range res.Import.Results {
+		if result.Status != wantStatus[i] {
+			t.Errorf("line %d status = %q, want %q", result.Line, result.Status, wantStatus[i])
+		}
+		if (result.Status == "created") != (result.ID != "") {
+			t.Errorf("line %d id = %q, want one only if created", result.Line, result.ID)
+		}
+	}
+	if res.Import.Created != 2 || res.Import.Duplicates != 1 || res.Import.Invalid != 2 {
+		t.Errorf("import report = %+v, want 2 created, 1 duplicate & 2 invalid", res.Import)
+	}
+
+	// Dry runs report the same without storing anything, forced rows skip the duplicate check
+	var dry struct {
+		Import struct {
+			Created int `json:"created"`
+		} `json:"import"`
+	}
+	ndjson := map[string]string{"Content-Type": "application/x-ndjson"}
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/import?dry_run=true&force=true", body: `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`, headers: ndjson}, http.StatusOK, &dry)
+	if dry.Import.Created != 1 {
+		t.Errorf("dry run created = %d, want 1", dry.Import.Created)
+	}
+
+	var list struct{ Movies []testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies"}, http.StatusOK, &list)
+	if len(list.Movies) != 3 {
+		t.Errorf("movies = %d, want 3", len(list.Movies))
+	}
+
+	// CSV rows are imported like NDJSON lines, other bodies are refused
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/import", body: "title,year,runtime,genres\nThief,1981,122 mins,crime\n", headers: map[string]string{"Content-Type": "text/csv"}}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/import", body: `[]`}, http.StatusUnsupportedMediaType, nil)
+
+	// Malformed lines are reported like invalid rows
+	var malformed struct {
+		Import struct {
+			Invalid int `json:"invalid"`
+		} `json:"import"`
+	}
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/import", body: `{"title":`, headers: ndjson}, http.StatusOK, &malformed)
+	if malformed.Import.Invalid != 1 {
+		t.Errorf("malformed invalid = %d, want 1", malformed.Import.Invalid)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// purgeTrash permanently deletes movies that have been in the trash longer than the retention period, runs until done is closed
+func (app *application) purgeTrash(done <-chan struct{}) {
+	if app.config.trash.retention == 0 || app.config.trash.purgeInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(app.config.trash.purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			purged, err := app.models.Purges.Purge(time.Now().Add(-app.config.trash.retention))
+			if err != nil {
+				app.logger.PrintError(err, map[string]string{"job": "purge trash"})
+				continue
+			}
+
+			if purged > 0 {
+				app.logger.PrintInfo("purged deleted movies", map[string]string{
+					"count": strconv.FormatInt(purged, 10),
+				})
+			}
+		}
+	}
+}
+
+// rebuildRecommendations builds the recommendation index right away & rebuilds it every interval, runs until done is closed
+func (app *application) rebuildRecommendations(done <-chan struct{}) {
+	rebuild := func() {
+		err := app.recommender.Rebuild(app.models.Movies)
+		if err != nil {
+			app.logger.PrintError(err, map[string]string{"job": "rebuild recommendations"})
+			return
+		}
+
+		app.logger.PrintInfo("rebuilt recommendation index", map[string]string{
+			"movies": strconv.Itoa(app.recommender.Len()),
+		})
+	}
+
+	rebuild()
+
+	if app.config.recommend.rebuildInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(app.config.recommend.rebuildInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			rebuild()
+		}
+	}
+}
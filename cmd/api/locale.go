@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+	"golang.org/x/text/language"
+)
+
+// readLanguages returns the languages titles are localized to in order of preference, ?lang= takes precedence over the
+// Accept-Language header. A malformed header is ignored & leaves the original titles
+func (app *application) readLanguages(r *http.Request, v *validator.Validator) []language.Tag {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		tag, err := language.Parse(lang)
+		v.Check(err == nil && tag != language.Und, "lang", "must be a language tag like de or pt-BR")
+		return []language.Tag{tag}
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil {
+		return nil
+	}
+
+	return tags
+}
+
+// localeFields adds the fields a title is localized from to a field selection, localize removes them again unless they were selected
+func localeFields(fields []string) []string {
+	if len(fields) == 0 || !(validator.In("title", fields...) || validator.In("highlights", fields...)) {
+		return fields
+	}
+
+	return append(append([]string{}, fields...), "titles", "original_language")
+}
+
+// localize sets the title of every movie to the one best matching prefs & returns the language of the first one.
+// Search results are highlighted again if their title changed, query is nil for anything but search results.
+// The response depends on the Accept-Language header, so caches are told to vary on it
+func (app *application) localize(rw http.ResponseWriter, prefs []language.Tag, fields []string, query *data.MovieQuery, movies ...*data.Movie) string {
+	rw.Header().Add("Vary", "Accept-Language")
+
+	selected := func(field string) bool {
+		return len(fields) == 0 || validator.In(field, fields...)
+	}
+
+	if !selected("title") && !selected("highlights") {
+		return ""
+	}
+
+	var lang string
+	for i, movie := range movies {
+		tag := movie.Localize(prefs)
+		if i == 0 {
+			lang = tag
+		}
+
+		// Highlights are nil if they weren't selected or there are no search terms
+		if movie.OriginalTitle != "" && query != nil && movie.Highlights != nil {
+			query.Highlight(movie)
+		}
+
+		if !selected("title") {
+			movie.Title, movie.OriginalTitle = "", ""
+		}
+		if !selected("titles") {
+			movie.Titles = nil
+		}
+		if !selected("original_language") {
+			movie.OriginalLanguage = ""
+		}
+	}
+
+	return lang
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+func TestMovieLocalization(t *testing.T) {
+	ts := newTestServer(t)
+
+	id := ts.createMovie(t, `{"title":"Das Leben der Anderen","original_language":"de","titles":{"en":"The Lives of Others","pt-BR":"A Vida dos Outros"},"year":2006,"runtime":"137 mins","genres":["drama"]}`)
+	url := "/v1/movies/" + id
+
+	tests := []struct {
+		name     string
+		query    string
+		accept   string
+		title    string
+		language string
+	}{
+		{"lang parameter", "?lang=en", "", "The Lives of Others", "en"},
+		{"lang parameter wins", "?lang=en", "pt-BR", "The Lives of Others", "en"},
+		{"parent language", "", "en-GB,de;q=0.5", "The Lives of Others", "en"},
+		{"same base language", "", "pt", "A Vida dos Outros", "pt-BR"},
+		{"original language", "", "de-AT", "Das Leben der Anderen", "de"},
+		{"fallback to the original", "", "fr", "Das Leben der Anderen", "de"},
+		{"no preference", "", "", "Das Leben der Anderen", "de"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res struct {
+				Movie struct {
+					Title         string `json:"title"`
+					OriginalTitle string `json:"original_title"`
+				} `json:"movie"`
+			}
+			rr := ts.expect(t, testRequest{method: http.MethodGet, url: url + tt.query, headers: map[string]string{"Accept-Language": tt.accept}}, http.StatusOK, &res)
+
+			if res.Movie.Title != tt.title {
+				t.Errorf("title = %q, want %q", res.Movie.Title, tt.title)
+			}
+			if tt.title != "Das Leben der Anderen" && res.Movie.OriginalTitle != "Das Leben der Anderen" {
+				t.Errorf("original title = %q, want Das Leben der Anderen", res.Movie.OriginalTitle)
+			}
+			if got := rr.Header().Get("Content-Language"); got != tt.language {
+				t.Errorf("Content-Language = %q, want %q", got, tt.language)
+			}
+			if vary := rr.Header().Values("Vary"); !validator.In("Accept-Language", vary...) {
+				t.Errorf("Vary = %v, want Accept-Language", vary)
+			}
+		})
+	}
+
+	// Titles in every language are searched
+	var list struct{ Movies []testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?title=lives&lang=en"}, http.StatusOK, &list)
+	if len(list.Movies) != 1 || list.Movies[0].Title != "The Lives of Others" {
+		t.Errorf("search = %+v, want The Lives of Others", list.Movies)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: url + "?lang=-"}, http.StatusUnprocessableEntity, nil)
+	ts.expect(t, testRequest{method: http.MethodPatch, url: url, body: `{"titles":{"xx-invalid-":"Title"}}`}, http.StatusUnprocessableEntity, nil)
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"expvar"
 	"flag"
 	"fmt"
@@ -9,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +20,8 @@ import (
 	"github.com/BunnyTheLifeguard/greenlight/internal/data"
 	"github.com/BunnyTheLifeguard/greenlight/internal/jsonlog"
 	"github.com/BunnyTheLifeguard/greenlight/internal/mailer"
+	"github.com/BunnyTheLifeguard/greenlight/internal/migrations"
+	"github.com/BunnyTheLifeguard/greenlight/internal/recommend"
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -34,6 +40,7 @@ type config struct {
 	port int
 	env  string
 	db   struct {
+		driver       string
 		uri          string
 		maxOpenConns int
 		maxIdleTime  string
@@ -41,11 +48,20 @@ type config struct {
 		data         string
 		user         string
 		token        string
+		revision     string
+		people       string
+		credit       string
+		review       string
+		watchlist    string
+		watched      string
+		poster       string
 	}
 	limiter struct {
-		rps     float64
-		burst   int
-		enabled bool
+		rps          float64
+		burst        int
+		suggestRPS   float64
+		suggestBurst int
+		enabled      bool
 	}
 	smtp struct {
 		host     string
@@ -57,6 +73,20 @@ type config struct {
 	cors struct {
 		trustedOrigins []string
 	}
+	cursor struct {
+		secret string
+	}
+	trash struct {
+		retention     time.Duration
+		purgeInterval time.Duration
+	}
+	recommend struct {
+		weights         recommend.Weights
+		rebuildInterval time.Duration
+	}
+	popularity struct {
+		flushInterval time.Duration
+	}
 }
 
 // Application struct to hold dependencies for HTTP handlers, helpers & middleware
@@ -66,11 +96,15 @@ type application struct {
 	models data.Models
 	mailer mailer.Mailer
 	wg     sync.WaitGroup
+
+	recommender *recommend.Index
+	views       viewCounter
 }
 
 func init() {
+	// A missing .env file is fine, e.g. when running with -db-driver=memory
 	err := godotenv.Load(filepath.Join(".env"))
-	if err != nil {
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		log.Fatal("Error loading .env file")
 	}
 }
@@ -82,11 +116,19 @@ func main() {
 	flag.IntVar(&cfg.port, "port", 4000, "API server port")
 	flag.StringVar(&cfg.env, "env", "development", "Environment (development|staging|production)")
 
-	flag.StringVar(&cfg.db.uri, "db-uri", os.Getenv("MONGODB_URI"), "MongoDB URI")
+	flag.StringVar(&cfg.db.driver, "db-driver", "mongo", "Database driver (mongo|memory)")
+	flag.StringVar(&cfg.db.uri, "db-uri", os.Getenv("MONGODB_URI"), "MongoDB URI of a replica set or sharded cluster, writes use transactions")
 	flag.StringVar(&cfg.db.name, "db-name", os.Getenv("DB"), "DB Name")
 	flag.StringVar(&cfg.db.data, "db-data", os.Getenv("DATA"), "Collection Data")
 	flag.StringVar(&cfg.db.user, "db-user", os.Getenv("USER"), "Collection User")
 	flag.StringVar(&cfg.db.token, "db-token", os.Getenv("TOKEN"), "Collection Token")
+	flag.StringVar(&cfg.db.revision, "db-revision", os.Getenv("REVISION"), "Collection Revision")
+	flag.StringVar(&cfg.db.people, "db-people", os.Getenv("PEOPLE"), "Collection People")
+	flag.StringVar(&cfg.db.credit, "db-credit", os.Getenv("CREDIT"), "Collection Credit")
+	flag.StringVar(&cfg.db.review, "db-review", os.Getenv("REVIEW"), "Collection Review")
+	flag.StringVar(&cfg.db.watchlist, "db-watchlist", os.Getenv("WATCHLIST"), "Collection Watchlist")
+	flag.StringVar(&cfg.db.watched, "db-watched", os.Getenv("WATCHED"), "Collection Watched")
+	flag.StringVar(&cfg.db.poster, "db-poster", os.Getenv("POSTER"), "GridFS Bucket Poster")
 
 	// Connection pool cli flags
 	flag.IntVar(&cfg.db.maxOpenConns, "db-max-open-conns", 25, "MongoDB max open connections")
@@ -95,6 +137,8 @@ func main() {
 	// Rate limiter cli flags
 	flag.Float64Var(&cfg.limiter.rps, "limiter-rps", 2, "Rate limiter maximum requests per second")
 	flag.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst")
+	flag.Float64Var(&cfg.limiter.suggestRPS, "limiter-suggest-rps", 10, "Rate limiter maximum title suggest requests per second")
+	flag.IntVar(&cfg.limiter.suggestBurst, "limiter-suggest-burst", 20, "Rate limiter maximum title suggest burst")
 	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enabled rate limiter")
 
 	// SMTP config settings
@@ -110,6 +154,30 @@ func main() {
 		return nil
 	})
 
+	// Pagination cursor signing key
+	flag.StringVar(&cfg.cursor.secret, "cursor-secret", os.Getenv("CURSOR_SECRET"), "Secret key for signing pagination cursors")
+
+	// Trash purge job, a retention of 0 keeps deleted movies forever
+	flag.Func("trash-retention-days", "Days deleted movies are kept in the trash before they are purged, 0 disables purging (default 30)", func(val string) error {
+		days, err := strconv.Atoi(val)
+		if err != nil || days < 0 {
+			return errors.New("must be a non-negative integer")
+		}
+		cfg.trash.retention = time.Duration(days) * 24 * time.Hour
+		return nil
+	})
+	cfg.trash.retention = 30 * 24 * time.Hour
+	flag.DurationVar(&cfg.trash.purgeInterval, "trash-purge-interval", time.Hour, "Interval between trash purge runs")
+
+	// Recommendation scoring weights & index rebuild interval
+	flag.Float64Var(&cfg.recommend.weights.Genre, "recommend-genre-weight", recommend.DefaultWeights.Genre, "Recommendation weight of genre overlap")
+	flag.Float64Var(&cfg.recommend.weights.Year, "recommend-year-weight", recommend.DefaultWeights.Year, "Recommendation weight of year proximity")
+	flag.Float64Var(&cfg.recommend.weights.Runtime, "recommend-runtime-weight", recommend.DefaultWeights.Runtime, "Recommendation weight of runtime similarity")
+	flag.DurationVar(&cfg.recommend.rebuildInterval, "recommend-rebuild-interval", 10*time.Minute, "Interval between recommendation index rebuilds")
+
+	// Movie views are buffered & added to the popularity in batches, an interval of 0 stops counting views
+	flag.DurationVar(&cfg.popularity.flushInterval, "popularity-flush-interval", time.Minute, "Interval between movie popularity updates")
+
 	// Version
 	displayVersion := flag.Bool("version", false, "Display version and exit")
 
@@ -124,52 +192,57 @@ func main() {
 	// Initialize a new jsonlog.Logger for messages above INFO severity level
 	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	weights := cfg.recommend.weights
+	if weights.Genre < 0 || weights.Year < 0 || weights.Runtime < 0 || weights.Genre+weights.Year+weights.Runtime == 0 {
+		logger.PrintFatal(errors.New("recommendation weights must not be negative & not all be zero"), nil)
+		os.Exit(1)
+	}
 
-	db, err := openDB(ctx, cfg)
-	if err != nil {
-		logger.PrintFatal(err, nil)
+	// Without a configured key cursors are signed with a random one & become invalid on restart
+	if cfg.cursor.secret == "" {
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		if err != nil {
+			logger.PrintFatal(err, nil)
+			os.Exit(1)
+		}
+		cfg.cursor.secret = hex.EncodeToString(key)
+		logger.PrintInfo("no cursor secret configured, using a random key", nil)
 	}
-	defer db.Disconnect(ctx)
 
-	logger.PrintInfo("database connection pool established", nil)
+	var (
+		db     *mongo.Client
+		models data.Models
+	)
 
-	dataColl := openCollection(db, cfg, cfg.db.data)
-	userColl := openCollection(db, cfg, cfg.db.user)
-	tokenColl := openCollection(db, cfg, cfg.db.token)
+	switch cfg.db.driver {
+	case "memory":
+		models = data.NewMemoryModels()
+		logger.PrintInfo("using in-memory storage, data is lost on shutdown", nil)
 
-	// Add text indexes for search functionality
-	_, err = dataColl.Indexes().CreateOne(context.Background(), mongo.IndexModel{Keys: bson.D{{Key: "title", Value: "text"}, {Key: "genres", Value: "text"}}})
-	if err != nil {
-		logger.PrintFatal(err, nil)
-	}
+	case "mongo":
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
 
-	// Flag username & email as unique
-	_, err = userColl.Indexes().CreateMany(
-		context.Background(),
-		[]mongo.IndexModel{
-			{
-				Keys:    bson.M{"name": 1},
-				Options: options.Index().SetUnique(true).SetCollation(&options.Collation{Locale: "en", Strength: 2}),
-			},
-			{
-				Keys:    bson.M{"email": 1},
-				Options: options.Index().SetUnique(true).SetCollation(&options.Collation{Locale: "en", Strength: 2}),
-			},
-		},
-	)
-	if err != nil {
-		logger.PrintFatal(err, nil)
-	}
+		var err error
+		db, err = openDB(ctx, cfg)
+		if err != nil {
+			logger.PrintFatal(err, nil)
+			os.Exit(1)
+		}
+		defer db.Disconnect(ctx)
 
-	// Add TimeToLive for expiry field to autodelete expired tokens
-	_, err = tokenColl.Indexes().CreateOne(context.Background(), mongo.IndexModel{
-		Keys:    bson.D{{Key: "expiry", Value: 1}},
-		Options: options.Index().SetExpireAfterSeconds(1),
-	})
-	if err != nil {
-		logger.PrintFatal(err, nil)
+		logger.PrintInfo("database connection pool established", nil)
+
+		models, err = openModels(ctx, db, cfg)
+		if err != nil {
+			logger.PrintFatal(err, nil)
+			os.Exit(1)
+		}
+
+	default:
+		logger.PrintFatal(fmt.Errorf("unsupported db driver %q", cfg.db.driver), nil)
+		os.Exit(1)
 	}
 
 	// Metrics
@@ -178,6 +251,31 @@ func main() {
 		return runtime.NumGoroutine()
 	}))
 
+	if db != nil {
+		publishDatabaseMetrics(db, cfg, logger)
+	}
+	expvar.Publish("timestamp", expvar.Func(func() interface{} {
+		return time.Now().Unix()
+	}))
+
+	// Declare an instance of the application struct containing config struct & logger
+	app := &application{
+		config: cfg,
+		logger: logger,
+		models: models,
+		mailer: mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+
+		recommender: recommend.New(cfg.recommend.weights),
+	}
+
+	err := app.serve()
+	if err != nil {
+		logger.PrintFatal(err, nil)
+		os.Exit(1)
+	}
+}
+
+func publishDatabaseMetrics(db *mongo.Client, cfg config, logger *jsonlog.Logger) {
 	expvar.Publish("database", expvar.Func(func() interface{} {
 		type Metrics struct {
 			Connections bson.M `json:"connections"`
@@ -193,29 +291,15 @@ func main() {
 		}
 		opts := options.RunCmd().SetReadPreference(readpref.Primary())
 
+		// A failed status query must not take the API down, the metrics are left empty until the next request
 		err := db.Database(cfg.db.name).RunCommand(context.TODO(), cmd, opts).Decode(&result)
 		if err != nil {
-			logger.PrintFatal(err, nil)
+			logger.PrintError(err, nil)
+			return nil
 		}
 
 		return result
 	}))
-	expvar.Publish("timestamp", expvar.Func(func() interface{} {
-		return time.Now().Unix()
-	}))
-
-	// Declare an instance of the application struct containing config struct & logger
-	app := &application{
-		config: cfg,
-		logger: logger,
-		models: data.NewModels(dataColl, userColl, tokenColl),
-		mailer: mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
-	}
-
-	err = app.serve()
-	if err != nil {
-		logger.PrintFatal(err, nil)
-	}
 }
 
 func openDB(ctx context.Context, cfg config) (*mongo.Client, error) {
@@ -248,3 +332,64 @@ func openCollection(client *mongo.Client, cfg config, coll string) *mongo.Collec
 	collection := client.Database(cfg.db.name).Collection(coll)
 	return collection
 }
+
+// openModels checks the server supports transactions & the schema version is current and returns MongoDB backed Models
+func openModels(ctx context.Context, db *mongo.Client, cfg config) (data.Models, error) {
+	err := checkTransactions(ctx, db)
+	if err != nil {
+		return data.Models{}, err
+	}
+
+	// Indexes & data changes are applied by cmd/migrate, refuse to start on an outdated schema
+	migrator := migrations.New(db.Database(cfg.db.name), migrations.Collections{
+		Movies:    cfg.db.data,
+		Users:     cfg.db.user,
+		Tokens:    cfg.db.token,
+		Revisions: cfg.db.revision,
+		People:    cfg.db.people,
+		Credits:   cfg.db.credit,
+		Reviews:   cfg.db.review,
+		Watchlist: cfg.db.watchlist,
+		Watched:   cfg.db.watched,
+		Posters:   cfg.db.poster,
+	})
+
+	err = migrator.Check(ctx)
+	if err != nil {
+		return data.Models{}, err
+	}
+
+	dataColl := openCollection(db, cfg, cfg.db.data)
+	userColl := openCollection(db, cfg, cfg.db.user)
+	tokenColl := openCollection(db, cfg, cfg.db.token)
+	revisionColl := openCollection(db, cfg, cfg.db.revision)
+	peopleColl := openCollection(db, cfg, cfg.db.people)
+	creditColl := openCollection(db, cfg, cfg.db.credit)
+	reviewColl := openCollection(db, cfg, cfg.db.review)
+	watchlistColl := openCollection(db, cfg, cfg.db.watchlist)
+	watchedColl := openCollection(db, cfg, cfg.db.watched)
+	posterBucket := data.PosterModel{Database: db.Database(cfg.db.name), Bucket: cfg.db.poster}
+
+	return data.NewModels(dataColl, userColl, tokenColl, revisionColl, peopleColl, creditColl, reviewColl, watchlistColl, watchedColl, posterBucket), nil
+}
+
+// checkTransactions fails unless the server is a replica set member or a mongos. Every movie write runs in a transaction together with
+// its revision, which a standalone mongod doesn't support, so the API refuses to start instead of failing on every write
+func checkTransactions(ctx context.Context, db *mongo.Client) error {
+	var result struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+
+	err := db.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&result)
+	if err != nil {
+		return err
+	}
+
+	if result.SetName == "" && result.Msg != "isdbgrid" {
+		return errors.New("MongoDB transactions require a replica set or sharded cluster, the server is a standalone mongod: " +
+			"start it with --replSet & run rs.initiate() to turn it into a single-node replica set")
+	}
+
+	return nil
+}
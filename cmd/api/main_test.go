@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/jsonlog"
+	"github.com/BunnyTheLifeguard/greenlight/internal/recommend"
+)
+
+// testServer serves the API routes from the memory store, token authenticates a user with all movie permissions
+type testServer struct {
+	app     *application
+	handler http.Handler
+	token   string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	app := &application{
+		logger:      jsonlog.New(io.Discard, jsonlog.LevelError),
+		models:      data.NewMemoryModels(),
+		recommender: recommend.New(recommend.DefaultWeights),
+	}
+	app.config.cursor.secret = "test-cursor-secret"
+
+	// Mails aren't sent, background jobs are waited for so they don't outlive the test
+	t.Cleanup(app.wg.Wait)
+
+	ts := &testServer{app: app, handler: app.routes()}
+	ts.token = ts.newUser(t, "admin@example.com", "movies:read", "movies:write", "movies:admin")
+	return ts
+}
+
+// newUser inserts an activated user with permissions & returns an authentication token for it
+func (ts *testServer) newUser(t *testing.T, email string, permissions ...string) string {
+	t.Helper()
+
+	user := &data.User{Name: strings.Split(email, "@")[0], Email: email, Activated: true, Permissions: permissions}
+	if err := user.Password.Set("pa55word1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := ts.app.models.User.Insert(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := ts.app.models.Token.New(id, time.Hour, data.ScopeAuthentication)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token.Plaintext
+}
+
+// testRequest is a request to the test server, it's sent with the token of the server unless token is set
+type testRequest struct {
+	method  string
+	url     string
+	body    string
+	headers map[string]string
+	token   string
+}
+
+// send serves req & returns the recorded response
+func (ts *testServer) send(t *testing.T, req testRequest) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := httptest.NewRequest(req.method, req.url, strings.NewReader(req.body))
+
+	token := req.token
+	if token == "" {
+		token = ts.token
+	}
+	if token != "-" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	if req.body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range req.headers {
+		r.Header.Set(key, value)
+	}
+
+	rr := httptest.NewRecorder()
+	ts.handler.ServeHTTP(rr, r)
+	return rr
+}
+
+// expect sends req, fails the test unless the response has status & decodes a JSON response body into dst if it isn't nil
+func (ts *testServer) expect(t *testing.T, req testRequest, status int, dst interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rr := ts.send(t, req)
+	if rr.Code != status {
+		t.Fatalf("%s %s = %d, want %d: %s", req.method, req.url, rr.Code, status, rr.Body)
+	}
+
+	if dst != nil {
+		if err := json.Unmarshal(rr.Body.Bytes(), dst); err != nil {
+			t.Fatalf("%s %s: invalid JSON response %s: %v", req.method, req.url, rr.Body, err)
+		}
+	}
+
+	return rr
+}
+
+// testMovie is the JSON of a movie in responses
+type testMovie struct {
+	ID          string            `json:"id"`
+	Title       string            `json:"title"`
+	Titles      map[string]string `json:"titles"`
+	Year        int32             `json:"year"`
+	Runtime     json.RawMessage   `json:"runtime"`
+	Genres      []string          `json:"genres"`
+	Rating      float64           `json:"rating"`
+	RatingCount int64             `json:"rating_count"`
+	Posters     map[string]string `json:"posters"`
+}
+
+// createMovie creates a movie from its JSON & returns its ID
+func (ts *testServer) createMovie(t *testing.T, body string) string {
+	t.Helper()
+
+	var res struct{ Movie testMovie }
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies", body: body}, http.StatusCreated, &res)
+	return res.Movie.ID
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+// maxMergeSources is the largest number of movies merged in one request
+const maxMergeSources = 10
+
+// Fields which take a merge rule, the rules pick the movie a value is taken from.
+// "survivor" keeps the value of the movie merged into, a movie ID takes the value of that movie & "union" combines all values
+var (
+	mergeRuleFields  = []string{"title", "titles", "original_language", "year", "runtime", "genres", "external_ids"}
+	mergeUnionFields = []string{"titles", "genres", "external_ids"}
+)
+
+// mergeMovies returns survivor with the fields resolved by rules, localized titles, genres & external IDs are combined unless a rule
+// says otherwise
+func mergeMovies(survivor *data.Movie, sources []*data.Movie, rules map[string]string) *data.Movie {
+	byID := map[string]*data.Movie{survivor.ID: survivor}
+	for _, source := range sources {
+		byID[source.ID] = source
+	}
+
+	pick := func(field string) *data.Movie {
+		if movie, ok := byID[rules[field]]; ok {
+			return movie
+		}
+		return survivor
+	}
+
+	merged := *survivor
+	merged.Title = pick("title").Title
+	merged.OriginalLanguage = pick("original_language").OriginalLanguage
+	merged.Year = pick("year").Year
+	merged.Runtime = pick("runtime").Runtime
+
+	// The survivor's titles win, the sources only add languages it's missing
+	merged.Titles = make(map[string]string)
+	switch rule := rules["titles"]; rule {
+	case "", "union":
+		for _, movie := range append([]*data.Movie{survivor}, sources...) {
+			for tag, title := range movie.Titles {
+				if _, ok := merged.Titles[tag]; !ok {
+					merged.Titles[tag] = title
+				}
+			}
+		}
+	default:
+		for tag, title := range pick("titles").Titles {
+			merged.Titles[tag] = title
+		}
+	}
+
+	switch rule := rules["genres"]; rule {
+	case "", "union":
+		merged.Genres = append([]string{}, survivor.Genres...)
+		for _, source := range sources {
+			for _, genre := range source.Genres {
+				if !validator.In(genre, merged.Genres...) {
+					merged.Genres = append(merged.Genres, genre)
+				}
+			}
+		}
+	default:
+		merged.Genres = append([]string{}, pick("genres").Genres...)
+	}
+
+	// The survivor's IDs win, the sources only fill in the ones it's missing
+	switch rule := rules["external_ids"]; rule {
+	case "", "union":
+		ids := data.ExternalIDs{}
+		for _, movie := range append([]*data.Movie{survivor}, sources...) {
+			if movie.ExternalIDs == nil {
+				continue
+			}
+			if ids.IMDb == "" {
+				ids.IMDb = movie.ExternalIDs.IMDb
+			}
+			if ids.TMDb == 0 {
+				ids.TMDb = movie.ExternalIDs.TMDb
+			}
+			if ids.Wikidata == "" {
+				ids.Wikidata = movie.ExternalIDs.Wikidata
+			}
+		}
+		merged.ExternalIDs = &ids
+	default:
+		merged.ExternalIDs = nil
+		if ids := pick("external_ids").ExternalIDs; ids != nil {
+			c := *ids
+			merged.ExternalIDs = &c
+		}
+	}
+
+	return &merged
+}
+
+func (app *application) mergeMoviesHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	var input struct {
+		Sources []string          `json:"sources"`
+		Rules   map[string]string `json:"rules"`
+	}
+
+	err := app.readJSON(rw, r, &input)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	output := app.readMovieOutput(r, v)
+
+	v.Check(len(input.Sources) >= 1, "sources", "must contain at least 1 movie")
+	v.Check(len(input.Sources) <= maxMergeSources, "sources", fmt.Sprintf("must not contain more than %d movies", maxMergeSources))
+	v.Check(validator.Unique(input.Sources), "sources", "must not contain duplicate values")
+	v.Check(!validator.In(id, input.Sources...), "sources", "must not contain the movie merged into")
+
+	for field, rule := range input.Rules {
+		if !validator.In(field, mergeRuleFields...) {
+			v.AddError("rules."+field, "unknown field")
+			continue
+		}
+
+		valid := rule == "survivor" || rule == id || validator.In(rule, input.Sources...) ||
+			(rule == "union" && validator.In(field, mergeUnionFields...))
+		v.Check(valid, "rules."+field, "must be survivor, union or the ID of a merged movie")
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	survivor, err := app.models.Movies.Get(id, nil)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if !app.ifMatch(r, app.etag(survivor.Version)) {
+		app.preconditionFailedResponse(rw, r)
+		return
+	}
+
+	sources := make([]*data.Movie, 0, len(input.Sources))
+	for _, sourceID := range input.Sources {
+		source, err := app.models.Movies.Get(sourceID, nil)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				v.AddError("sources", fmt.Sprintf("movie %s not found", sourceID))
+				continue
+			default:
+				app.serverErrorResponse(rw, r, err)
+				return
+			}
+		}
+		sources = append(sources, source)
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	merged := mergeMovies(survivor, sources, input.Rules)
+
+	if data.ValidateMovie(v, merged); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	// Everything is written in one transaction, either all sources end up merged or none
+	err = app.models.Merges.Merge(survivor, data.NewMovieUpdate(survivor, merged), sources, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(rw, r)
+		case errors.Is(err, data.ErrDuplicateExternalID):
+			v.AddError("external_ids", "must not be used by another movie")
+			app.failedValidationResponse(rw, r, v.Errors)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", app.etag(survivor.Version))
+
+	app.formatMovie(rw, headers, output, nil, survivor)
+	app.setPosterURLs(survivor)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"movie": survivor, "merged": input.Sources}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+// redirectMerged answers a request for a movie which isn't found with a redirect to the movie it was merged into, if there is one
+func (app *application) redirectMerged(rw http.ResponseWriter, r *http.Request, id string) {
+	survivorID, err := app.models.Movies.GetMergedInto(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	location := fmt.Sprintf("/v1/movies/%s", survivorID)
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", location)
+
+	err = app.writeResponse(rw, r, http.StatusMovedPermanently, envelope{"merged_into": survivorID}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
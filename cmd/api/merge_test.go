@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMovieMerge(t *testing.T) {
+	ts := newTestServer(t)
+
+	survivor := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	source := ts.createMovie(t, `{"title":"Heat (1995)","year":1995,"runtime":"171 mins","genres":["drama"],"external_ids":{"imdb":"tt0113277"}}`)
+
+	var merged struct{ Movie testMovie }
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/" + survivor + "/merge", body: `{"sources":["` + source + `"],"rules":{"runtime":"` + source + `"}}`}, http.StatusOK, &merged)
+	if string(merged.Movie.Runtime) != `"171 mins"` || strings.Join(merged.Movie.Genres, ",") != "crime,drama" {
+		t.Errorf("merged movie = %+v, want runtime 171 mins & genres crime,drama", merged.Movie)
+	}
+
+	// Merged movies redirect to the one they were merged into
+	rr := ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + source}, http.StatusMovedPermanently, nil)
+	if got := rr.Header().Get("Location"); got != "/v1/movies/"+survivor {
+		t.Errorf("Location = %q, want /v1/movies/%s", got, survivor)
+	}
+
+	var lookup struct{ Movie testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/lookup?imdb=tt0113277"}, http.StatusOK, &lookup)
+	if lookup.Movie.ID != survivor {
+		t.Errorf("lookup = %s, want %s", lookup.Movie.ID, survivor)
+	}
+
+	// Only the survivor is listed
+	var list struct{ Movies []testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies"}, http.StatusOK, &list)
+	if len(list.Movies) != 1 || list.Movies[0].ID != survivor {
+		t.Errorf("listed movies = %+v, want only %s", list.Movies, survivor)
+	}
+
+	other := ts.createMovie(t, `{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action"]}`)
+	url := "/v1/movies/" + survivor + "/merge"
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no sources", `{"sources":[]}`},
+		{"merged into itself", `{"sources":["` + survivor + `"]}`},
+		{"duplicate sources", `{"sources":["` + other + `","` + other + `"]}`},
+		{"already merged source", `{"sources":["` + source + `"]}`},
+		{"unknown rule", `{"sources":["` + other + `"],"rules":{"runtime":"newest"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.expect(t, testRequest{method: http.MethodPost, url: url, body: tt.body}, http.StatusUnprocessableEntity, nil)
+		})
+	}
+}
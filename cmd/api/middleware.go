@@ -14,7 +14,6 @@ import (
 	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
 	"github.com/felixge/httpsnoop"
 	"github.com/tomasen/realip"
-	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/time/rate"
 )
 
@@ -75,16 +74,22 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 			// Lock mutex preventing concurrent execution
 			mu.Lock()
 
+			// Suggest requests get a separate, more generous bucket so typeahead doesn't starve the other endpoints
+			key, limit, burst := ip, rate.Limit(app.config.limiter.rps), app.config.limiter.burst
+			if r.URL.Path == suggestPath {
+				key, limit, burst = "suggest "+ip, rate.Limit(app.config.limiter.suggestRPS), app.config.limiter.suggestBurst
+			}
+
 			// Check if IP address already in map, if not add to map w/ new rate limiter
-			if _, found := clients[ip]; !found {
-				clients[ip] = &client{limiter: rate.NewLimiter(2, 4)}
+			if _, found := clients[key]; !found {
+				clients[key] = &client{limiter: rate.NewLimiter(limit, burst)}
 			}
 
 			// Update last seen time for current client
-			clients[ip].lastSeen = time.Now()
+			clients[key].lastSeen = time.Now()
 
 			// Call Allow() method on rate limiter for current IP address, unlock mutex & send 429 if request not allowed
-			if !clients[ip].limiter.Allow() {
+			if !clients[key].limiter.Allow() {
 				mu.Unlock()
 				app.rateLimitExceededResponse(rw, r)
 				return
@@ -135,7 +140,7 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 		userID, err := app.models.Token.Get(data.ScopeAuthentication, token)
 		if err != nil {
 			switch {
-			case errors.Is(err, mongo.ErrNoDocuments):
+			case errors.Is(err, data.ErrRecordNotFound):
 				app.invalidAuthenticationTokenResponse(rw, r)
 			default:
 				app.serverErrorResponse(rw, r, err)
@@ -146,7 +151,7 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 		user, err := app.models.User.GetForToken(userID)
 		if err != nil {
 			switch {
-			case errors.Is(err, mongo.ErrNoDocuments):
+			case errors.Is(err, data.ErrRecordNotFound):
 				app.invalidAuthenticationTokenResponse(rw, r)
 			default:
 				app.serverErrorResponse(rw, r, err)
@@ -226,10 +231,11 @@ func (app *application) enableCORS(next http.Handler) http.Handler {
 			for i := range app.config.cors.trustedOrigins {
 				if origin == app.config.cors.trustedOrigins[i] {
 					rw.Header().Set("Access-Control-Allow-Origin", origin)
+					rw.Header().Set("Access-Control-Expose-Headers", "ETag, Link")
 
 					if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 						rw.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
-						rw.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+						rw.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match")
 
 						rw.WriteHeader(http.StatusOK)
 						return
@@ -242,12 +248,15 @@ func (app *application) enableCORS(next http.Handler) http.Handler {
 	})
 }
 
-func (app *application) metrics(next http.Handler) http.Handler {
-	totalRequestsReceived := expvar.NewInt("total_requests_received")
-	totalResponsesSent := expvar.NewInt("total_responses_sent")
-	totalProcessingTimeMicroseconds := expvar.NewInt("total_processing_time")
-	totalResponsesSentByStatus := expvar.NewMap("total_responses_sent_by_status")
+// Metric variables are published once per process, expvar panics on reuse of a name so routes() can be built more than once
+var (
+	totalRequestsReceived           = expvar.NewInt("total_requests_received")
+	totalResponsesSent              = expvar.NewInt("total_responses_sent")
+	totalProcessingTimeMicroseconds = expvar.NewInt("total_processing_time")
+	totalResponsesSentByStatus      = expvar.NewMap("total_responses_sent_by_status")
+)
 
+func (app *application) metrics(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		totalRequestsReceived.Add(1)
 		metrics := httpsnoop.CaptureMetrics(next, rw, r)
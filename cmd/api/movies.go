@@ -3,20 +3,38 @@ package main
 import (
 	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
 
 	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/jsonpatch"
 	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
-	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/text/language"
+)
+
+// Media types accepted by PATCH /v1/movies/:id
+const acceptPatch = "application/json, " + jsonpatch.MergePatchType + ", " + jsonpatch.JSONPatchType
+
+// Supported sort values of the movie list endpoints
+var movieSortSafelist = []string{"id", "title", "year", "runtime", "rating", "-id", "-title", "-year", "-runtime", "-rating"}
+
+// Fields which can be selected with ?fields= on the show & list endpoints, search results also have a score & highlights
+var (
+	movieFieldSafelist     = []string{"id", "title", "titles", "original_language", "year", "runtime", "genres", "external_ids", "rating", "rating_count", "posters"}
+	movieListFieldSafelist = []string{"id", "title", "titles", "original_language", "year", "runtime", "genres", "external_ids", "rating", "rating_count", "posters", "score", "highlights"}
 )
 
 // Add createMovieHandler for "POST /v1/movies" endpoint
 func (app *application) createMovieHandler(rw http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Title   string       `json:"title"`
-		Year    int32        `json:"year"`
-		Runtime data.Runtime `json:"runtime"`
-		Genres  []string     `json:"genres"`
+		Title            string            `json:"title"`
+		Titles           map[string]string `json:"titles"`
+		OriginalLanguage string            `json:"original_language"`
+		Year             int32             `json:"year"`
+		Runtime          data.Runtime      `json:"runtime"`
+		Genres           []string          `json:"genres"`
+		ExternalIDs      *data.ExternalIDs `json:"external_ids"`
 	}
 
 	err := app.readJSON(rw, r, &input)
@@ -26,30 +44,67 @@ func (app *application) createMovieHandler(rw http.ResponseWriter, r *http.Reque
 	}
 
 	movie := &data.Movie{
-		Title:   input.Title,
-		Year:    input.Year,
-		Runtime: input.Runtime,
-		Genres:  input.Genres,
+		Title:            input.Title,
+		Titles:           input.Titles,
+		OriginalLanguage: input.OriginalLanguage,
+		Year:             input.Year,
+		Runtime:          input.Runtime,
+		Genres:           input.Genres,
+		ExternalIDs:      input.ExternalIDs,
 	}
 
+	// Record which user created the movie
+	movie.CreatedBy = app.contextGetUser(r).ID
+
 	v := validator.New()
 
+	force := app.readBool(r.URL.Query(), "force", false, v)
+	output := app.readMovieOutput(r, v)
+
 	if data.ValidateMovie(v, movie); !v.Valid() {
 		app.failedValidationResponse(rw, r, v.Errors)
 		return
 	}
 
+	// A movie with the same normalized title & a year at most one apart is most likely created twice, force creates it anyway
+	if !force {
+		duplicates, err := app.models.Movies.FindDuplicates(movie.Title, movie.Year)
+		if err != nil {
+			app.serverErrorResponse(rw, r, err)
+			return
+		}
+
+		if len(duplicates) != 0 {
+			ids := make([]string, 0, len(duplicates))
+			for _, duplicate := range duplicates {
+				ids = append(ids, duplicate.ID)
+			}
+
+			app.duplicateMovieResponse(rw, r, ids)
+			return
+		}
+	}
+
 	id, err := app.models.Movies.Insert(movie)
 	if err != nil {
-		app.serverErrorResponse(rw, r, err)
+		switch {
+		case errors.Is(err, data.ErrDuplicateExternalID):
+			v.AddError("external_ids", "must not be used by another movie")
+			app.failedValidationResponse(rw, r, v.Errors)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
 		return
 	}
 	movie.ID = id
 
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/movies/%s", id))
+	headers.Set("ETag", app.etag(movie.Version))
+
+	app.formatMovie(rw, headers, output, nil, movie)
 
-	err = app.writeJSON(rw, http.StatusCreated, envelope{"movie": movie}, headers)
+	err = app.writeResponse(rw, r, http.StatusCreated, envelope{"movie": movie}, headers)
 	if err != nil {
 		app.serverErrorResponse(rw, r, err)
 	}
@@ -59,11 +114,26 @@ func (app *application) createMovieHandler(rw http.ResponseWriter, r *http.Reque
 func (app *application) showMovieHandler(rw http.ResponseWriter, r *http.Request) {
 	id := app.readIDParam(r)
 
-	movie, err := app.models.Movies.Get(id)
+	fields, err := app.readFields(r.URL.Query(), movieFieldSafelist)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	output := app.readMovieOutput(r, v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	movie, err := app.models.Movies.Get(id, localeFields(fields))
 	if err != nil {
 		switch {
-		case errors.Is(err, mongo.ErrNoDocuments):
-			app.notFoundResponse(rw, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			// Movies which were merged into another one redirect to it
+			app.redirectMerged(rw, r, id)
 		default:
 			app.serverErrorResponse(rw, r, err)
 		}
@@ -71,7 +141,62 @@ func (app *application) showMovieHandler(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = app.writeJSON(rw, http.StatusOK, envelope{"movie": movie}, nil)
+	// Recently viewed movies make up the profile of a user's recommendations
+	if user := app.contextGetUser(r); !user.IsAnonymous() {
+		app.recommender.Viewed(user.ID, id)
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", app.etag(movie.Version))
+	headers.Set("Accept-Patch", acceptPatch)
+
+	// The display title is picked from ?lang= or Accept-Language, the original title is the fallback
+	app.formatMovie(rw, headers, output, fields, movie)
+	app.setPosterURLs(movie)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"movie": movie}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) lookupMovieHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	ids := data.ExternalIDs{
+		IMDb:     app.readString(qs, "imdb", ""),
+		TMDb:     int64(app.readInt(qs, "tmdb", 0, v)),
+		Wikidata: app.readString(qs, "wikidata", ""),
+	}
+	output := app.readMovieOutput(r, v)
+
+	v.Check(!ids.Empty(), "imdb", "an imdb, tmdb or wikidata ID must be provided")
+	if data.ValidateExternalIDs(v, &ids); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	movie, err := app.models.Movies.GetByExternalID(ids)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Content-Location", fmt.Sprintf("/v1/movies/%s", movie.ID))
+	headers.Set("ETag", app.etag(movie.Version))
+
+	app.formatMovie(rw, headers, output, nil, movie)
+	app.setPosterURLs(movie)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"movie": movie}, headers)
 	if err != nil {
 		app.serverErrorResponse(rw, r, err)
 	}
@@ -81,11 +206,20 @@ func (app *application) updateMovieHandler(rw http.ResponseWriter, r *http.Reque
 	// Extract ID from URL
 	id := app.readIDParam(r)
 
+	// Validator for the output options & the updated movie record
+	v := validator.New()
+
+	output := app.readMovieOutput(r, v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
 	// Get existing movie record from db
-	movie, err := app.models.Movies.Get(id)
+	movie, err := app.models.Movies.Get(id, nil)
 	if err != nil {
 		switch {
-		case errors.Is(err, mongo.ErrNoDocuments):
+		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(rw, r)
 		default:
 			app.serverErrorResponse(rw, r, err)
@@ -94,51 +228,141 @@ func (app *application) updateMovieHandler(rw http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Input struct to hold expected data from client
-	var input struct {
-		Title   *string       `json:"title"`
-		Year    *int32        `json:"year"`
-		Runtime *data.Runtime `json:"runtime"`
-		Genres  []string      `json:"genres"`
-	}
-
-	// Read JSON request body into input struct
-	err = app.readJSON(rw, r, &input)
-	if err != nil {
-		app.badRequestResponse(rw, r, err)
+	// Reject the update if the client's copy is outdated
+	if !app.ifMatch(r, app.etag(movie.Version)) {
+		app.preconditionFailedResponse(rw, r)
 		return
 	}
 
-	// Check if values are provided in JSON request body
-	if input.Title != nil {
-		movie.Title = *input.Title
-	}
+	// Copy of the record the changes are applied to, the original is needed to work out what changed
+	updated := *movie
+	updated.Genres = append([]string{}, movie.Genres...)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	// RFC 7396 & RFC 6902 patches are applied to the JSON representation of the editable fields
+	case jsonpatch.MergePatchType, jsonpatch.JSONPatchType:
+		doc := struct {
+			Title            string            `json:"title"`
+			Titles           map[string]string `json:"titles,omitempty"`
+			OriginalLanguage string            `json:"original_language,omitempty"`
+			Year             int32             `json:"year"`
+			Runtime          data.Runtime      `json:"runtime"`
+			Genres           []string          `json:"genres"`
+			ExternalIDs      *data.ExternalIDs `json:"external_ids,omitempty"`
+		}{
+			Title:            updated.Title,
+			Titles:           updated.Titles,
+			OriginalLanguage: updated.OriginalLanguage,
+			Year:             updated.Year,
+			Runtime:          updated.Runtime,
+			Genres:           updated.Genres,
+			ExternalIDs:      updated.ExternalIDs,
+		}
 
-	if input.Year != nil {
-		movie.Year = *input.Year
-	}
+		err = app.readPatch(rw, r, mediaType, &doc)
+		if err != nil {
+			switch {
+			case errors.Is(err, errUnprocessablePatch):
+				app.failedValidationResponse(rw, r, map[string]string{"patch": err.Error()})
+			default:
+				app.badRequestResponse(rw, r, err)
+			}
+			return
+		}
+
+		updated.Title = doc.Title
+		updated.Titles = doc.Titles
+		updated.OriginalLanguage = doc.OriginalLanguage
+		updated.Year = doc.Year
+		updated.Runtime = doc.Runtime
+		updated.Genres = doc.Genres
+		updated.ExternalIDs = doc.ExternalIDs
+
+	default:
+		// Input struct to hold expected data from client
+		// Localized titles & external IDs are replaced as a whole, an empty object removes them
+		var input struct {
+			Title            *string           `json:"title"`
+			Titles           map[string]string `json:"titles"`
+			OriginalLanguage *string           `json:"original_language"`
+			Year             *int32            `json:"year"`
+			Runtime          *data.Runtime     `json:"runtime"`
+			Genres           []string          `json:"genres"`
+			ExternalIDs      *data.ExternalIDs `json:"external_ids"`
+		}
+
+		// Read JSON request body into input struct
+		err = app.readJSON(rw, r, &input)
+		if err != nil {
+			app.badRequestResponse(rw, r, err)
+			return
+		}
+
+		// Check if values are provided in JSON request body
+		if input.Title != nil {
+			updated.Title = *input.Title
+		}
+
+		if input.Titles != nil {
+			updated.Titles = input.Titles
+		}
+
+		if input.OriginalLanguage != nil {
+			updated.OriginalLanguage = *input.OriginalLanguage
+		}
+
+		if input.Year != nil {
+			updated.Year = *input.Year
+		}
 
-	if input.Runtime != nil {
-		movie.Runtime = *input.Runtime
+		if input.Runtime != nil {
+			updated.Runtime = *input.Runtime
+		}
+
+		if input.Genres != nil {
+			updated.Genres = input.Genres
+		}
+
+		if input.ExternalIDs != nil {
+			updated.ExternalIDs = input.ExternalIDs
+		}
 	}
 
 	// Validate updated movie record
-	v := validator.New()
-
-	if data.ValidateMovie(v, movie); !v.Valid() {
+	if data.ValidateMovie(v, &updated); !v.Valid() {
 		app.failedValidationResponse(rw, r, v.Errors)
 		return
 	}
 
-	// Send update request with new record
-	err = app.models.Movies.Update(movie, id)
-	if err != nil {
-		app.serverErrorResponse(rw, r, err)
-		return
+	// Persist only the changed fields along with a revision, fails if the record was changed since it was read
+	update := data.NewMovieUpdate(movie, &updated)
+	if !update.Empty() {
+		err = app.models.Movies.Patch(movie, update, data.Change{Action: data.RevisionUpdate, UserID: app.contextGetUser(r).ID})
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrEditConflict):
+				app.editConflictResponse(rw, r)
+			case errors.Is(err, data.ErrDuplicateExternalID):
+				v.AddError("external_ids", "must not be used by another movie")
+				app.failedValidationResponse(rw, r, v.Errors)
+			default:
+				app.serverErrorResponse(rw, r, err)
+			}
+			return
+		}
 	}
 
+	headers := make(http.Header)
+	headers.Set("ETag", app.etag(movie.Version))
+	headers.Set("Accept-Patch", acceptPatch)
+
+	app.formatMovie(rw, headers, output, nil, movie)
+	app.setPosterURLs(movie)
+
 	// Write updated data in JSON response
-	err = app.writeJSON(rw, http.StatusOK, envelope{"movie": movie}, nil)
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"movie": movie}, headers)
 	if err != nil {
 		app.serverErrorResponse(rw, r, err)
 	}
@@ -147,10 +371,11 @@ func (app *application) updateMovieHandler(rw http.ResponseWriter, r *http.Reque
 func (app *application) deleteMovieHandler(rw http.ResponseWriter, r *http.Request) {
 	id := app.readIDParam(r)
 
-	err := app.models.Movies.Delete(id)
+	// Movies are moved to the trash & can be restored until they are purged
+	_, err := app.models.Movies.Delete(id, app.contextGetUser(r).ID)
 	if err != nil {
 		switch {
-		case errors.Is(err, mongo.ErrNoDocuments):
+		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(rw, r)
 		default:
 			app.serverErrorResponse(rw, r, err)
@@ -158,55 +383,235 @@ func (app *application) deleteMovieHandler(rw http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = app.writeJSON(rw, http.StatusOK, envelope{"message": "movie successfully deleted"}, nil)
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"message": "movie successfully deleted"}, nil)
 	if err != nil {
 		app.serverErrorResponse(rw, r, err)
 	}
 
 }
 
-func (app *application) listMoviesHandler(rw http.ResponseWriter, r *http.Request) {
-	// Struct to hold expected values from req query string
-	var input struct {
-		Title  string
-		Genres []string
-		data.Filters
+func (app *application) restoreMovieHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	v := validator.New()
+
+	output := app.readMovieOutput(r, v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
 	}
-	// Initialize new validator
+
+	movie, err := app.models.Movies.Restore(id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", app.etag(movie.Version))
+
+	app.formatMovie(rw, headers, output, nil, movie)
+	app.setPosterURLs(movie)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"movie": movie}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) listTrashHandler(rw http.ResponseWriter, r *http.Request) {
 	v := validator.New()
 
-	// Get url.Values map containing query string data
 	qs := r.URL.Query()
 
-	// Extract title & genre string values
-	input.Title = app.readString(qs, "title", "")
-	input.Genres = app.readCSV(qs, "genres", []string{})
+	filters := app.readMovieFilters(qs, v)
+	output := app.readMovieOutput(r, v)
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	movies, metadata, err := app.models.Movies.GetTrash(filters)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	app.formatMovies(rw, output, nil, nil, movies...)
+
+	headers := make(http.Header)
+	if links := app.paginationLinks(r, metadata); links != "" {
+		headers.Set("Link", links)
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"movies": movies, "metadata": metadata}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+// readMovieQuery extracts the search & filter query string values shared by the list & export endpoints
+func (app *application) readMovieQuery(qs url.Values, v *validator.Validator) data.MovieQuery {
+	var query data.MovieQuery
+
+	// Title is either searched like the genres or matched as a prefix
+	query.Title = app.readString(qs, "title", "")
+	query.TitleMatch = app.readString(qs, "title_match", data.TitleMatchText)
+
+	// Genres are full text search terms, genres_all & genres_any match exactly
+	query.Genres = app.readCSV(qs, "genres", []string{})
+	query.GenresAll = app.readCSV(qs, "genres_all", []string{})
+	query.GenresAny = app.readCSV(qs, "genres_any", []string{})
+
+	// Inclusive ranges, 0 leaves the bound open
+	query.YearMin = int32(app.readInt(qs, "year_min", 0, v))
+	query.YearMax = int32(app.readInt(qs, "year_max", 0, v))
+	query.RuntimeMin = int32(app.readRuntime(qs, "runtime_min", 0, v))
+	query.RuntimeMax = int32(app.readRuntime(qs, "runtime_max", 0, v))
+
+	return query
+}
+
+// readMovieFilters extracts the pagination & sort query string values shared by the movie list endpoints
+func (app *application) readMovieFilters(qs url.Values, v *validator.Validator) data.Filters {
+	var filters data.Filters
 
 	// Extract page & pagesize string values as integers
-	input.Filters.Page = app.readInt(qs, "page", 0, v)
-	input.Filters.PageSize = app.readInt(qs, "page_size", 0, v)
+	filters.Page = app.readInt(qs, "page", 0, v)
+	filters.PageSize = app.readInt(qs, "page_size", 0, v)
 
 	// Extract sort query string value
-	input.Filters.Sort = app.readString(qs, "sort", "id")
+	filters.Sort = app.readString(qs, "sort", "id")
+
+	// Extract keyset pagination cursor, only used if no page number is requested
+	filters.Cursor = app.readString(qs, "cursor", "")
+	filters.CursorSecret = []byte(app.config.cursor.secret)
 
 	// Supported sort values
-	input.Filters.SortSafelist = []string{"id", "title", "year", "runtime", "-id", "-title", "-year", "-runtime"}
+	filters.SortSafelist = movieSortSafelist
+
+	return filters
+}
+
+// readRuntimeFormat extracts the format runtimes are written in, one of data.RuntimeFormats
+func (app *application) readRuntimeFormat(qs url.Values, v *validator.Validator) string {
+	format := app.readString(qs, "runtime_format", data.RuntimeFormatMins)
+
+	v.Check(validator.In(format, data.RuntimeFormats...), "runtime_format", "must be one of mins, minutes, iso8601 or human")
+
+	return format
+}
+
+// setRuntimeFormat sets the format the runtime of every movie is written in
+func setRuntimeFormat(format string, movies ...*data.Movie) {
+	for _, movie := range movies {
+		movie.RuntimeFormat = format
+	}
+}
+
+// movieOutput holds how movies are written in a response, the languages titles are localized to & the format of runtimes
+type movieOutput struct {
+	prefs         []language.Tag
+	runtimeFormat string
+}
+
+// readMovieOutput extracts the output options every endpoint responding with movies supports, endpoints changing data read them
+// before any change is made
+func (app *application) readMovieOutput(r *http.Request, v *validator.Validator) movieOutput {
+	return movieOutput{
+		prefs:         app.readLanguages(r, v),
+		runtimeFormat: app.readRuntimeFormat(r.URL.Query(), v),
+	}
+}
+
+// formatMovies localizes the titles & sets the runtime format of every movie, returns the language of the first one like localize
+func (app *application) formatMovies(rw http.ResponseWriter, output movieOutput, fields []string, query *data.MovieQuery, movies ...*data.Movie) string {
+	lang := app.localize(rw, output.prefs, fields, query, movies...)
+	setRuntimeFormat(output.runtimeFormat, movies...)
+	return lang
+}
+
+// formatMovie formats a single movie with formatMovies & sends the language of its title as Content-Language
+func (app *application) formatMovie(rw http.ResponseWriter, headers http.Header, output movieOutput, fields []string, movie *data.Movie) {
+	if lang := app.formatMovies(rw, output, fields, nil, movie); lang != "" {
+		headers.Set("Content-Language", lang)
+	}
+}
+
+func (app *application) listMoviesHandler(rw http.ResponseWriter, r *http.Request) {
+	// Initialize new validator
+	v := validator.New()
+
+	// Get url.Values map containing query string data
+	qs := r.URL.Query()
+
+	// Extract search, filter, pagination & sort values
+	query := app.readMovieQuery(qs, v)
+	filters := app.readMovieFilters(qs, v)
+
+	// Search results are ranked best matches first unless another sort is requested
+	filters.SortSafelist = append([]string{data.SortRelevance}, movieSortSafelist...)
+	if query.HasSearchTerms() && !qs.Has("sort") {
+		filters.Sort = data.SortRelevance
+	}
+
+	// Extract optional facet names, counted over all matches & not just the current page
+	facetNames := app.readCSV(qs, "facets", []string{})
+
+	// Unknown field names are a malformed request rather than a failed validation
+	fields, err := app.readFields(qs, movieListFieldSafelist)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+	filters.Fields = localeFields(fields)
+
+	output := app.readMovieOutput(r, v)
 
 	// Check validator instance for any errors
-	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+	data.ValidateMovieQuery(v, query)
+	data.ValidateFacets(v, facetNames)
+	v.Check(filters.Sort != data.SortRelevance || query.HasSearchTerms(), "sort", "relevance requires a title or genres search term")
+	if data.ValidateFilters(v, filters); !v.Valid() {
 		app.failedValidationResponse(rw, r, v.Errors)
 		return
 	}
 
 	// Call GetAll() method to retrieve all movies from db passing in filters if provided
-	movies, metadata, err := app.models.Movies.GetAll(input.Title, input.Genres, input.Filters)
+	movies, metadata, err := app.models.Movies.GetAll(query, filters)
 	if err != nil {
 		app.serverErrorResponse(rw, r, err)
 		return
 	}
 
+	app.formatMovies(rw, output, fields, &query, movies...)
+	app.setPosterURLs(movies...)
+
+	env := envelope{"movies": movies, "metadata": metadata}
+
+	if len(facetNames) != 0 {
+		facets, err := app.models.Movies.Facets(query, facetNames)
+		if err != nil {
+			app.serverErrorResponse(rw, r, err)
+			return
+		}
+		env["facets"] = facets
+	}
+
+	// Link header lets clients follow pages without building URLs themselves
+	headers := make(http.Header)
+	if links := app.paginationLinks(r, metadata); links != "" {
+		headers.Set("Link", links)
+	}
+
 	// Send JSON response with movie list data
-	err = app.writeJSON(rw, http.StatusOK, envelope{"movies": movies, "metadata": metadata}, nil)
+	err = app.writeResponse(rw, r, http.StatusOK, env, headers)
 	if err != nil {
 		app.serverErrorResponse(rw, r, err)
 	}
@@ -0,0 +1,551 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+)
+
+func TestMoviesCRUD(t *testing.T) {
+	ts := newTestServer(t)
+
+	id := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime","drama"]}`)
+	url := "/v1/movies/" + id
+
+	var shown struct{ Movie testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: url}, http.StatusOK, &shown)
+	if shown.Movie.ID != id || shown.Movie.Title != "Heat" || shown.Movie.Year != 1995 || string(shown.Movie.Runtime) != `"170 mins"` {
+		t.Errorf("shown movie = %+v, want Heat 1995 170 mins", shown.Movie)
+	}
+
+	var updated struct{ Movie testMovie }
+	ts.expect(t, testRequest{method: http.MethodPatch, url: url, body: `{"year":1996}`}, http.StatusOK, &updated)
+	if updated.Movie.Year != 1996 || strings.Join(updated.Movie.Genres, ",") != "crime,drama" {
+		t.Errorf("updated movie = %+v, want year 1996 & the genres unchanged", updated.Movie)
+	}
+
+	var list struct{ Movies []testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies"}, http.StatusOK, &list)
+	if len(list.Movies) != 1 || list.Movies[0].ID != id {
+		t.Errorf("listed movies = %+v, want %s", list.Movies, id)
+	}
+
+	tests := []struct {
+		name   string
+		req    testRequest
+		status int
+	}{
+		{"missing title", testRequest{method: http.MethodPost, url: "/v1/movies", body: `{"title":"","year":1995}`}, http.StatusUnprocessableEntity},
+		{"unknown field", testRequest{method: http.MethodPost, url: "/v1/movies", body: `{"title":"Heat","unknown":1}`}, http.StatusBadRequest},
+		{"malformed JSON", testRequest{method: http.MethodPost, url: "/v1/movies", body: `{"title":`}, http.StatusBadRequest},
+		{"invalid update", testRequest{method: http.MethodPatch, url: url, body: `{"year":1800}`}, http.StatusUnprocessableEntity},
+		{"invalid id", testRequest{method: http.MethodGet, url: "/v1/movies/123"}, http.StatusNotFound},
+		{"unknown id", testRequest{method: http.MethodGet, url: "/v1/movies/0123456789abcdef01234567"}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.expect(t, tt.req, tt.status, nil)
+		})
+	}
+
+	ts.expect(t, testRequest{method: http.MethodDelete, url: url}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: url}, http.StatusNotFound, nil)
+	ts.expect(t, testRequest{method: http.MethodDelete, url: url}, http.StatusNotFound, nil)
+}
+
+func TestMovieListCursor(t *testing.T) {
+	ts := newTestServer(t)
+
+	want := []string{
+		ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`),
+		ts.createMovie(t, `{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action"]}`),
+		ts.createMovie(t, `{"title":"Collateral","year":2004,"runtime":"120 mins","genres":["crime"]}`),
+	}
+
+	// Pages are followed through the next cursor until there is none
+	var got []string
+	url := "/v1/movies?page_size=1"
+	for i := 0; i < len(want)+1 && url != ""; i++ {
+		var page struct {
+			Movies   []testMovie   `json:"movies"`
+			Metadata data.Metadata `json:"metadata"`
+		}
+		rr := ts.expect(t, testRequest{method: http.MethodGet, url: url}, http.StatusOK, &page)
+
+		for _, movie := range page.Movies {
+			got = append(got, movie.ID)
+		}
+
+		url = ""
+		if page.Metadata.NextCursor != "" {
+			url = "/v1/movies?page_size=1&cursor=" + page.Metadata.NextCursor
+			if link := rr.Header().Get("Link"); !strings.Contains(link, `rel="next"`) {
+				t.Errorf("Link = %q, want a next link", link)
+			}
+		}
+	}
+
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("cursor pages = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"tampered cursor", "/v1/movies?page_size=1&cursor=eyJpZCI6IjEifQ.AAAA"},
+		{"cursor of another sort", "/v1/movies?page_size=1&sort=-year&cursor=" + nextCursor(t, ts, "/v1/movies?page_size=1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.expect(t, testRequest{method: http.MethodGet, url: tt.url}, http.StatusUnprocessableEntity, nil)
+		})
+	}
+}
+
+// nextCursor returns the next page cursor of the movie list at url
+func nextCursor(t *testing.T, ts *testServer, url string) string {
+	t.Helper()
+
+	var page struct {
+		Metadata data.Metadata `json:"metadata"`
+	}
+	ts.expect(t, testRequest{method: http.MethodGet, url: url}, http.StatusOK, &page)
+	return page.Metadata.NextCursor
+}
+
+func TestMovieListPagination(t *testing.T) {
+	ts := newTestServer(t)
+
+	ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	ts.createMovie(t, `{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action"]}`)
+	ts.createMovie(t, `{"title":"Collateral","year":2004,"runtime":"120 mins","genres":["crime"]}`)
+
+	tests := []struct {
+		name     string
+		url      string
+		movies   int
+		metadata data.Metadata
+		links    []string
+	}{
+		{"first page", "/v1/movies?page=1&page_size=2", 2, data.Metadata{CurrentPage: 1, PageSize: 2, FirstPage: 1, LastPage: 2, TotalRecords: 3, HasNext: true}, []string{"next", "first", "last"}},
+		{"middle page", "/v1/movies?page=2&page_size=1", 1, data.Metadata{CurrentPage: 2, PageSize: 1, FirstPage: 1, LastPage: 3, TotalRecords: 3, HasNext: true}, []string{"next", "prev", "first", "last"}},
+		{"last page", "/v1/movies?page=2&page_size=2", 1, data.Metadata{CurrentPage: 2, PageSize: 2, FirstPage: 1, LastPage: 2, TotalRecords: 3}, []string{"prev", "first", "last"}},
+		{"past the last page", "/v1/movies?page=5&page_size=2", 0, data.Metadata{CurrentPage: 5, PageSize: 2, FirstPage: 1, LastPage: 2, TotalRecords: 3}, []string{"prev", "first", "last"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var page struct {
+				Movies   []testMovie   `json:"movies"`
+				Metadata data.Metadata `json:"metadata"`
+			}
+			rr := ts.expect(t, testRequest{method: http.MethodGet, url: tt.url}, http.StatusOK, &page)
+
+			if len(page.Movies) != tt.movies {
+				t.Errorf("movies = %d, want %d", len(page.Movies), tt.movies)
+			}
+			if page.Metadata != tt.metadata {
+				t.Errorf("metadata = %+v, want %+v", page.Metadata, tt.metadata)
+			}
+
+			var rels []string
+			for _, link := range strings.Split(rr.Header().Get("Link"), ", ") {
+				if i := strings.Index(link, `rel="`); i >= 0 {
+					rels = append(rels, strings.TrimSuffix(link[i+len(`rel="`):], `"`))
+				}
+			}
+			if strings.Join(rels, ",") != strings.Join(tt.links, ",") {
+				t.Errorf("Link rels = %v, want %v", rels, tt.links)
+			}
+		})
+	}
+}
+
+func TestMovieIfMatch(t *testing.T) {
+	ts := newTestServer(t)
+
+	id := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	url := "/v1/movies/" + id
+
+	rr := ts.expect(t, testRequest{method: http.MethodGet, url: url}, http.StatusOK, nil)
+	if got := rr.Header().Get("ETag"); got != `"1"` {
+		t.Fatalf("ETag = %s, want \"1\"", got)
+	}
+
+	// Every change bumps the version, so each step changes the year & expects the tag of the step before plus one
+	tests := []struct {
+		name    string
+		ifMatch string
+		year    int
+		status  int
+		etag    string
+	}{
+		{"no header", "", 1996, http.StatusOK, `"2"`},
+		{"current version", `"2"`, 1997, http.StatusOK, `"3"`},
+		{"outdated version", `"2"`, 1998, http.StatusPreconditionFailed, ""},
+		{"any version", "*", 1999, http.StatusOK, `"4"`},
+		{"list containing the version", `"1", "4"`, 2000, http.StatusOK, `"5"`},
+		{"weak comparison isn't used", `W/"5"`, 2001, http.StatusPreconditionFailed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.ifMatch != "" {
+				headers["If-Match"] = tt.ifMatch
+			}
+
+			body := `{"year":` + strconv.Itoa(tt.year) + `}`
+			rr := ts.expect(t, testRequest{method: http.MethodPatch, url: url, body: body, headers: headers}, tt.status, nil)
+			if got := rr.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("ETag = %s, want %s", got, tt.etag)
+			}
+		})
+	}
+}
+
+func TestMoviePatchFormats(t *testing.T) {
+	ts := newTestServer(t)
+
+	id := ts.createMovie(t, `{"title":"Heat","titles":{"de":"Heat – Die Stadt"},"year":1995,"runtime":"170 mins","genres":["crime","drama"]}`)
+	url := "/v1/movies/" + id
+
+	// Each step patches the result of the one before
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		genres      string
+		titles      int
+	}{
+		{"merge patch", "application/merge-patch+json", `{"year":1996,"genres":["crime"]}`, http.StatusOK, "crime", 1},
+		{"merge patch removes members", "application/merge-patch+json", `{"titles":null}`, http.StatusOK, "crime", 0},
+		{"JSON patch", "application/json-patch+json", `[{"op":"add","path":"/genres/-","value":"thriller"},{"op":"add","path":"/genres/0","value":"drama"}]`, http.StatusOK, "drama,crime,thriller", 0},
+		{"JSON patch test", "application/json-patch+json", `[{"op":"test","path":"/year","value":1996},{"op":"remove","path":"/genres/0"}]`, http.StatusOK, "crime,thriller", 0},
+		{"failed test", "application/json-patch+json", `[{"op":"test","path":"/year","value":1995},{"op":"remove","path":"/genres/0"}]`, http.StatusUnprocessableEntity, "", 0},
+		{"unknown path", "application/json-patch+json", `[{"op":"remove","path":"/genres/9"}]`, http.StatusUnprocessableEntity, "", 0},
+		{"unknown member", "application/merge-patch+json", `{"rating":10}`, http.StatusUnprocessableEntity, "", 0},
+		{"patch isn't a list", "application/json-patch+json", `{"op":"remove","path":"/year"}`, http.StatusBadRequest, "", 0},
+		{"invalid result", "application/json-patch+json", `[{"op":"replace","path":"/title","value":""}]`, http.StatusUnprocessableEntity, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res struct{ Movie testMovie }
+			ts.expect(t, testRequest{method: http.MethodPatch, url: url, body: tt.body, headers: map[string]string{"Content-Type": tt.contentType}}, tt.status, &res)
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			if got := strings.Join(res.Movie.Genres, ","); got != tt.genres {
+				t.Errorf("genres = %s, want %s", got, tt.genres)
+			}
+			if len(res.Movie.Titles) != tt.titles {
+				t.Errorf("titles = %v, want %d", res.Movie.Titles, tt.titles)
+			}
+		})
+	}
+}
+
+func TestMovieTrash(t *testing.T) {
+	ts := newTestServer(t)
+	writer := ts.newUser(t, "writer@example.com", "movies:read", "movies:write")
+
+	id := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	kept := ts.createMovie(t, `{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action"]}`)
+	url := "/v1/movies/" + id
+
+	ts.expect(t, testRequest{method: http.MethodPost, url: url + "/restore"}, http.StatusNotFound, nil)
+
+	// Deleted movies are moved to the trash & left out of the list
+	ts.expect(t, testRequest{method: http.MethodDelete, url: url, token: writer}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: url}, http.StatusNotFound, nil)
+
+	var list struct{ Movies []testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies"}, http.StatusOK, &list)
+	if len(list.Movies) != 1 || list.Movies[0].ID != kept {
+		t.Errorf("listed movies = %+v, want only %s", list.Movies, kept)
+	}
+
+	var trash struct{ Movies []testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/trash"}, http.StatusOK, &trash)
+	if len(trash.Movies) != 1 || trash.Movies[0].ID != id {
+		t.Errorf("trash = %+v, want %s", trash.Movies, id)
+	}
+
+	// Only admins see the trash & restore from it
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/trash", token: writer}, http.StatusForbidden, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: url + "/restore", token: writer}, http.StatusForbidden, nil)
+
+	ts.expect(t, testRequest{method: http.MethodPost, url: url + "/restore"}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: url}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/trash"}, http.StatusOK, &trash)
+	if len(trash.Movies) != 0 {
+		t.Errorf("trash = %+v, want it empty", trash.Movies)
+	}
+}
+
+func TestMovieListFilters(t *testing.T) {
+	ts := newTestServer(t)
+
+	ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime","drama"]}`)
+	ts.createMovie(t, `{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action","crime"]}`)
+	ts.createMovie(t, `{"title":"Heathers","year":1989,"runtime":"103 mins","genres":["comedy"]}`)
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"year range", "year_min=1990&year_max=1998", "Heat,Ronin"},
+		{"open year range", "year_min=1996", "Ronin"},
+		{"runtime range", "runtime_min=110&runtime_max=130", "Ronin"},
+		{"runtime range in another format", "runtime_max=PT2H", "Heathers"},
+		{"all genres", "genres_all=crime,drama", "Heat"},
+		{"any genre", "genres_any=drama,comedy", "Heathers,Heat"},
+		{"genres match exactly", "genres_any=dram", ""},
+		{"title prefix", "title=hea&title_match=prefix", "Heathers,Heat"},
+		{"combined", "title=hea&title_match=prefix&genres_any=crime", "Heat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list struct{ Movies []testMovie }
+			ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?sort=year&" + tt.query}, http.StatusOK, &list)
+
+			titles := make([]string, len(list.Movies))
+			for i, movie := range list.Movies {
+				titles[i] = movie.Title
+			}
+			if got := strings.Join(titles, ","); got != tt.want {
+				t.Errorf("movies = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	invalid := []string{
+		"title_match=suffix",
+		"year_min=1800",
+		"year_min=2000&year_max=1990",
+		"runtime_min=-5",
+		"runtime_min=130&runtime_max=110",
+		"genres_all=a,b,c,d,e,f",
+		"genres_any=crime,,drama",
+	}
+
+	for _, query := range invalid {
+		t.Run(query, func(t *testing.T) {
+			ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?" + query}, http.StatusUnprocessableEntity, nil)
+		})
+	}
+}
+
+func TestMovieListFacets(t *testing.T) {
+	ts := newTestServer(t)
+
+	ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime","drama"]}`)
+	ts.createMovie(t, `{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action","crime"]}`)
+	ts.createMovie(t, `{"title":"Collateral","year":2004,"runtime":"120 mins","genres":["crime"]}`)
+	ts.createMovie(t, `{"title":"Heathers","year":1989,"runtime":"103 mins","genres":["comedy"]}`)
+
+	// Counts are over every match of the query, not just the single movie on the page
+	var page struct {
+		Movies []testMovie
+		Facets map[string][]struct {
+			Value json.RawMessage `json:"value"`
+			Count int64           `json:"count"`
+		}
+	}
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?genres_any=crime&page_size=1&facets=genres,decade,runtime"}, http.StatusOK, &page)
+
+	if len(page.Movies) != 1 {
+		t.Errorf("movies = %d, want 1", len(page.Movies))
+	}
+
+	want := map[string]string{
+		"genres":  `"crime":3,"action":1,"drama":1`,
+		"decade":  `1990:2,2000:1`,
+		"runtime": `"120-149 mins":2,"150-179 mins":1`,
+	}
+
+	for name, counts := range want {
+		var got []string
+		for _, count := range page.Facets[name] {
+			got = append(got, fmt.Sprintf("%s:%d", count.Value, count.Count))
+		}
+		if strings.Join(got, ",") != counts {
+			t.Errorf("facet %s = %s, want %s", name, strings.Join(got, ","), counts)
+		}
+	}
+
+	// Without facets requested the envelope has none
+	rr := ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies"}, http.StatusOK, nil)
+	if strings.Contains(rr.Body.String(), `"facets"`) {
+		t.Errorf("body = %s, want no facets", rr.Body)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?facets=rating"}, http.StatusUnprocessableEntity, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?facets=genres,genres"}, http.StatusUnprocessableEntity, nil)
+}
+
+func TestMovieFields(t *testing.T) {
+	ts := newTestServer(t)
+
+	id := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+
+	keys := func(movie map[string]interface{}) string {
+		var names []string
+		for name := range movie {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+
+	// The id is always selected
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"show", "/v1/movies/" + id + "?fields=title,year", "id,title,year"},
+		{"explicit id", "/v1/movies/" + id + "?fields=id,title", "id,title"},
+		{"list", "/v1/movies?fields=id,title", "id,title"},
+		{"list search score", "/v1/movies?title=heat&fields=title,score", "id,score,title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res struct {
+				Movie  map[string]interface{}   `json:"movie"`
+				Movies []map[string]interface{} `json:"movies"`
+			}
+			ts.expect(t, testRequest{method: http.MethodGet, url: tt.url}, http.StatusOK, &res)
+
+			movie := res.Movie
+			if len(res.Movies) == 1 {
+				movie = res.Movies[0]
+			}
+			if got := keys(movie); got != tt.want {
+				t.Errorf("fields = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Unknown fields are named in the error
+	var res struct{ Error string }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + id + "?fields=title,budget"}, http.StatusBadRequest, &res)
+	if !strings.Contains(res.Error, `"budget"`) {
+		t.Errorf("error = %q, want it to name budget", res.Error)
+	}
+
+	// Scores only exist on search results
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + id + "?fields=score"}, http.StatusBadRequest, nil)
+}
+
+func TestMovieDuplicates(t *testing.T) {
+	ts := newTestServer(t)
+
+	heat := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"],"external_ids":{"imdb":"tt0113277","tmdb":949}}`)
+
+	// Lookups by any external ID
+	for _, query := range []string{"imdb=tt0113277", "tmdb=949"} {
+		var res struct{ Movie testMovie }
+		ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/lookup?" + query}, http.StatusOK, &res)
+		if res.Movie.ID != heat {
+			t.Errorf("lookup %s = %s, want %s", query, res.Movie.ID, heat)
+		}
+	}
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/lookup?wikidata=Q23"}, http.StatusNotFound, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/lookup"}, http.StatusUnprocessableEntity, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/lookup?imdb=0113277"}, http.StatusUnprocessableEntity, nil)
+
+	// A near duplicate of the normalized title within a year is refused along with the candidates
+	var conflict struct {
+		Candidates []string `json:"candidates"`
+	}
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies", body: `{"title":"HEAT","year":1996,"runtime":"170 mins","genres":["crime"]}`}, http.StatusConflict, &conflict)
+	if len(conflict.Candidates) != 1 || conflict.Candidates[0] != heat {
+		t.Errorf("candidates = %v, want %s", conflict.Candidates, heat)
+	}
+
+	ts.createMovie(t, `{"title":"Heat","year":1986,"runtime":"101 mins","genres":["action"]}`)
+
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies?force=true", body: `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`}, http.StatusCreated, nil)
+
+	// External IDs stay unique even when forced
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies?force=true", body: `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"],"external_ids":{"imdb":"tt0113277"}}`}, http.StatusUnprocessableEntity, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies", body: `{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action"],"external_ids":{"wikidata":"23"}}`}, http.StatusUnprocessableEntity, nil)
+}
+
+func TestMovieRuntimeFormats(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Every accepted input format is stored as the same number of minutes
+	inputs := []string{`170`, `"170 mins"`, `"2h 50m"`, `"PT2H50M"`}
+	ids := make([]string, len(inputs))
+	for i, input := range inputs {
+		ids[i] = ts.createMovie(t, `{"title":"Heat `+strconv.Itoa(i+2)+`","year":1995,"runtime":`+input+`,"genres":["crime"]}`)
+	}
+
+	var person struct {
+		Person struct {
+			ID string `json:"id"`
+		} `json:"person"`
+	}
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/people", body: `{"name":"Michael Mann"}`}, http.StatusCreated, &person)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/" + ids[0] + "/credits", body: `{"person_id":"` + person.Person.ID + `","role":"director"}`}, http.StatusCreated, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/user/watchlist", body: `{"movie_id":"` + ids[0] + `"}`}, http.StatusCreated, nil)
+
+	tests := []struct {
+		name   string
+		req    testRequest
+		format string
+		want   string
+	}{
+		{"show", testRequest{method: http.MethodGet, url: "/v1/movies/" + ids[1]}, "", `"170 mins"`},
+		{"show human", testRequest{method: http.MethodGet, url: "/v1/movies/" + ids[2]}, "human", `"2h 50m"`},
+		{"update minutes", testRequest{method: http.MethodPatch, url: "/v1/movies/" + ids[3], body: `{"year":1996}`}, "minutes", `170`},
+		{"list iso8601", testRequest{method: http.MethodGet, url: "/v1/movies?page_size=1"}, "iso8601", `"PT2H50M"`},
+		{"watchlist human", testRequest{method: http.MethodGet, url: "/v1/user/watchlist"}, "human", `"2h 50m"`},
+		{"filmography iso8601", testRequest{method: http.MethodGet, url: "/v1/people/" + person.Person.ID + "/filmography"}, "iso8601", `"PT2H50M"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.format != "" {
+				separator := "?"
+				if strings.Contains(tt.req.url, "?") {
+					separator = "&"
+				}
+				tt.req.url += separator + "runtime_format=" + tt.format
+			}
+
+			rr := ts.expect(t, tt.req, http.StatusOK, nil)
+			if !strings.Contains(rr.Body.String(), `"runtime":`+tt.want) {
+				t.Errorf("body = %s, want runtime %s", rr.Body, tt.want)
+			}
+		})
+	}
+
+	// Invalid runtimes are rejected with the formats which are accepted
+	var res struct{ Error string }
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies", body: `{"title":"Ronin","year":1998,"runtime":"two hours","genres":["action"]}`}, http.StatusBadRequest, &res)
+	if !strings.Contains(res.Error, "1h 52m") {
+		t.Errorf("error = %q, want it to name the accepted formats", res.Error)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + ids[0] + "?runtime_format=seconds"}, http.StatusUnprocessableEntity, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?runtime_min=2h"}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?runtime_min=long"}, http.StatusUnprocessableEntity, nil)
+}
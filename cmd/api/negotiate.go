@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/codec"
+)
+
+// Media types of request & response bodies besides the import & export ones, JSON is the default
+const (
+	jsonType    = "application/json"
+	xmlType     = "application/xml"
+	msgpackType = "application/msgpack"
+)
+
+// Formats request & response bodies are encoded in, the names are used in error messages
+const (
+	formatJSON    = "JSON"
+	formatXML     = "XML"
+	formatCSV     = "CSV"
+	formatMsgpack = "MessagePack"
+)
+
+// bodyFormats maps the media types of request & response bodies to their format, CSV is only used for responses holding a list
+var bodyFormats = map[string]string{
+	jsonType:                  formatJSON,
+	xmlType:                   formatXML,
+	"text/xml":                formatXML,
+	msgpackType:               formatMsgpack,
+	"application/x-msgpack":   formatMsgpack,
+	"application/vnd.msgpack": formatMsgpack,
+	csvType:                   formatCSV,
+}
+
+// responseTypes lists the media types responses can be sent as, the first one wins if the Accept header likes several equally
+var responseTypes = []string{jsonType, xmlType, "text/xml", msgpackType, "application/x-msgpack", "application/vnd.msgpack", csvType}
+
+// xmlRoot is the name of the root element of XML responses
+const xmlRoot = "response"
+
+// errUnsupportedBody error if a request body is in a format no request can be decoded from, answered with 415 Unsupported Media Type
+var errUnsupportedBody = errors.New("unsupported request body media type")
+
+// acceptedTypes returns the media types of responseTypes the Accept header of r allows, most preferred first. Of the media ranges
+// matching a type the most specific one sets its quality, types with a quality of 0 aren't acceptable. Requests without an Accept
+// header get JSON
+func acceptedTypes(r *http.Request) []string {
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		return []string{jsonType}
+	}
+
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{mediaType, quality})
+	}
+
+	type candidate struct {
+		mediaType   string
+		quality     float64
+		specificity int
+	}
+
+	var candidates []candidate
+	for _, offer := range responseTypes {
+		match := candidate{mediaType: offer, specificity: -1}
+
+		for _, rng := range ranges {
+			specificity := -1
+			switch {
+			case rng.mediaType == offer:
+				specificity = 2
+			case strings.HasSuffix(rng.mediaType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(rng.mediaType, "*")):
+				specificity = 1
+			case rng.mediaType == "*/*":
+				specificity = 0
+			}
+
+			if specificity > match.specificity {
+				match.quality, match.specificity = rng.quality, specificity
+			}
+		}
+
+		if match.quality > 0 {
+			candidates = append(candidates, match)
+		}
+	}
+
+	// Types named in the header go before types only matched by a wildcard of the same quality
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].quality != candidates[j].quality {
+			return candidates[i].quality > candidates[j].quality
+		}
+		return candidates[i].specificity > candidates[j].specificity
+	})
+
+	types := make([]string, len(candidates))
+	for i, c := range candidates {
+		types[i] = c.mediaType
+	}
+
+	return types
+}
+
+// encodeResponse encodes the JSON encoding js of an envelope in the first of types able to represent it & returns the media type
+// used, which is empty if none of them can
+func encodeResponse(js []byte, types []string) (string, []byte, error) {
+	var value interface{}
+	decoded := false
+
+	for _, mediaType := range types {
+		format := bodyFormats[mediaType]
+
+		if format == formatJSON {
+			return mediaType, append(js, '\n'), nil
+		}
+
+		// The other formats are encoded from the generic value, which keeps the order of the JSON fields
+		if !decoded {
+			var err error
+			value, err = codec.FromJSON(js)
+			if err != nil {
+				return "", nil, err
+			}
+			decoded = true
+		}
+
+		switch format {
+		case formatXML:
+			body, err := codec.MarshalXML(value, xmlRoot)
+			return mediaType, append(body, '\n'), err
+
+		case formatMsgpack:
+			body, err := codec.MarshalMsgpack(value)
+			return mediaType, body, err
+
+		case formatCSV:
+			list, ok := envelopeList(value)
+			if !ok {
+				continue
+			}
+
+			body, err := codec.MarshalCSV(list)
+			if errors.Is(err, codec.ErrNotTabular) {
+				continue
+			}
+			return mediaType, body, err
+		}
+	}
+
+	return "", nil, nil
+}
+
+// envelopeList returns the only array of an envelope, list endpoints send a single list along with metadata like pagination
+func envelopeList(value interface{}) (interface{}, bool) {
+	object, ok := value.(codec.Object)
+	if !ok {
+		return nil, false
+	}
+
+	var list interface{}
+	for _, member := range object {
+		if _, ok := member.Value.([]interface{}); ok {
+			if list != nil {
+				return nil, false
+			}
+			list = member.Value
+		}
+	}
+
+	return list, list != nil
+}
+
+// requestFormat returns the format of the body of r by its Content-Type. Anything but XML & MessagePack is read as JSON, like
+// before other formats were supported, except CSV which only lists are sent as
+func requestFormat(r *http.Request) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch format := bodyFormats[mediaType]; format {
+	case formatXML, formatMsgpack:
+		return format, nil
+	case formatCSV:
+		return "", errUnsupportedBody
+	default:
+		return formatJSON, nil
+	}
+}
+
+// readConverted decodes a request body in format into dst by converting it to JSON first, so it's decoded with the same rules &
+// errors as JSON bodies
+func (app *application) readConverted(body io.Reader, format string, dst interface{}, maxBytes int) error {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return app.bodyError(err, format, maxBytes)
+	}
+
+	var value interface{}
+	switch format {
+	case formatXML:
+		value, err = codec.UnmarshalXML(b, reflect.TypeOf(dst))
+	case formatMsgpack:
+		value, err = codec.UnmarshalMsgpack(b)
+	}
+	if err != nil {
+		return app.bodyError(err, format, maxBytes)
+	}
+
+	js, err := codec.ToJSON(value)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+
+	err = dec.Decode(dst)
+	if err != nil {
+		return app.bodyError(err, format, maxBytes)
+	}
+
+	return nil
+}
+
+// negotiate answers requests changing data with 406 Not Acceptable before any change is made if no accepted media type can represent
+// their response, CSV can't as it's only used for lists. Safe requests are checked once their response is written, as some of
+// them respond in media types of their own like exports & posters
+func (app *application) negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		for _, mediaType := range acceptedTypes(r) {
+			if bodyFormats[mediaType] != formatCSV {
+				next.ServeHTTP(rw, r)
+				return
+			}
+		}
+
+		app.notAcceptableResponse(rw, r)
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAcceptedTypes(t *testing.T) {
+	all := []string{jsonType, xmlType, "text/xml", msgpackType, "application/x-msgpack", "application/vnd.msgpack", csvType}
+
+	tests := []struct {
+		name   string
+		accept []string
+		want   []string
+	}{
+		{"no header", nil, []string{jsonType}},
+		{"blank header", []string{" "}, []string{jsonType}},
+		{"exact type", []string{"application/xml"}, []string{xmlType}},
+		{"any type", []string{"*/*"}, all},
+		{"subtype wildcard", []string{"text/*"}, []string{"text/xml", csvType}},
+		{"quality order", []string{"application/json;q=0.5, application/xml"}, []string{xmlType, jsonType}},
+		{"equal quality keeps server order", []string{"text/csv, application/json"}, []string{jsonType, csvType}},
+		{"named before wildcard", []string{"*/*, text/csv"}, []string{csvType, jsonType, xmlType, "text/xml", msgpackType, "application/x-msgpack", "application/vnd.msgpack"}},
+		{"specific range sets quality", []string{"application/*;q=0.2, application/msgpack;q=0.9"}, []string{msgpackType, jsonType, xmlType, "application/x-msgpack", "application/vnd.msgpack"}},
+		{"specific q=0 excludes type", []string{"*/*, application/json;q=0"}, []string{xmlType, "text/xml", msgpackType, "application/x-msgpack", "application/vnd.msgpack", csvType}},
+		{"wildcard q=0 excludes type", []string{"application/xml, */*;q=0"}, []string{xmlType}},
+		{"multiple headers", []string{"application/xml;q=0.1", "text/csv"}, []string{csvType, xmlType}},
+		{"parameters ignored", []string{"application/json; charset=utf-8"}, []string{jsonType}},
+		{"case-insensitive", []string{"Application/XML"}, []string{xmlType}},
+		{"invalid quality skipped", []string{"application/xml;q=2, application/json;q=abc, text/csv;q=0.3"}, []string{csvType}},
+		{"malformed range skipped", []string{"application/, text/csv"}, []string{csvType}},
+		{"nothing acceptable", []string{"image/png"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/movies", nil)
+			for _, accept := range tt.accept {
+				r.Header.Add("Accept", accept)
+			}
+
+			if got := acceptedTypes(r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("acceptedTypes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeResponse(t *testing.T) {
+	list := `{"metadata":{"total_records":1},"movies":[{"id":"a","genres":["crime","drama"]}]}`
+	movie := `{"movie":{"id":"a","year":1995}}`
+
+	tests := []struct {
+		name      string
+		js        string
+		types     []string
+		wantType  string
+		wantStart string
+	}{
+		{"json", movie, []string{jsonType}, jsonType, movie + "\n"},
+		{"xml", movie, []string{xmlType}, xmlType, `<?xml version="1.0" encoding="UTF-8"?>` + "\n<response><movie><id>a</id><year>1995</year></movie></response>\n"},
+		{"msgpack", movie, []string{msgpackType}, msgpackType, "\x81\xa5movie\x82\xa2id\xa1a\xa4year\xcd\x07\xcb"},
+		{"csv list", list, []string{csvType}, csvType, "id,genres\na,\"crime,drama\"\n"},
+		{"csv falls back", movie, []string{csvType, xmlType}, xmlType, `<?xml`},
+		{"csv only", movie, []string{csvType}, "", ""},
+		{"no types", movie, nil, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mediaType, body, err := encodeResponse([]byte(tt.js), tt.types)
+			if err != nil {
+				t.Fatalf("encodeResponse() error = %v", err)
+			}
+
+			if mediaType != tt.wantType {
+				t.Errorf("encodeResponse() media type = %q, want %q", mediaType, tt.wantType)
+			}
+			if !strings.HasPrefix(string(body), tt.wantStart) {
+				t.Errorf("encodeResponse() body = %q, want it to start with %q", body, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestBodyFormats(t *testing.T) {
+	ts := newTestServer(t)
+
+	xmlBody := `<movie><title>Ronin</title><year>1998</year><runtime>2h 2m</runtime><genres><item>action</item></genres></movie>`
+
+	var created struct{ Movie testMovie }
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies", body: xmlBody, headers: map[string]string{"Content-Type": "application/xml"}}, http.StatusCreated, &created)
+	if created.Movie.Title != "Ronin" || created.Movie.Year != 1998 || string(created.Movie.Runtime) != `"122 mins"` {
+		t.Errorf("created movie = %+v, want Ronin 1998 122 mins", created.Movie)
+	}
+
+	rr := ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + created.Movie.ID, headers: map[string]string{"Accept": "application/xml"}}, http.StatusOK, nil)
+	if !strings.Contains(rr.Body.String(), "<title>Ronin</title>") {
+		t.Errorf("XML response = %s, want the title element", rr.Body)
+	}
+
+	rr = ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies", headers: map[string]string{"Accept": "text/csv"}}, http.StatusOK, nil)
+	if !strings.HasPrefix(rr.Body.String(), "id,title,year,runtime,genres") {
+		t.Errorf("CSV response = %s, want a header row", rr.Body)
+	}
+
+	// Single movies can't be sent as CSV, changes are refused before they are made
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies", body: `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`, headers: map[string]string{"Accept": "text/csv"}}, http.StatusNotAcceptable, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies", body: "title\nHeat", headers: map[string]string{"Content-Type": "text/csv"}}, http.StatusUnsupportedMediaType, nil)
+
+	// Malformed bodies are reported like malformed JSON
+	var res struct{ Error string }
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies", body: `<movie><title>Heat</movie>`, headers: map[string]string{"Content-Type": "application/xml"}}, http.StatusBadRequest, &res)
+	if !strings.Contains(res.Error, "badly-formed") {
+		t.Errorf("error = %q, want a badly-formed body", res.Error)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+var personSortSafelist = []string{"id", "name", "-id", "-name"}
+
+func (app *application) createPersonHandler(rw http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name      string `json:"name"`
+		BirthYear int32  `json:"birth_year"`
+		Bio       string `json:"bio"`
+	}
+
+	err := app.readJSON(rw, r, &input)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	person := &data.Person{
+		Name:      input.Name,
+		BirthYear: input.BirthYear,
+		Bio:       input.Bio,
+	}
+
+	v := validator.New()
+
+	if data.ValidatePerson(v, person); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	id, err := app.models.People.Insert(person)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/people/%s", id))
+	headers.Set("ETag", app.etag(person.Version))
+
+	err = app.writeResponse(rw, r, http.StatusCreated, envelope{"person": person}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) showPersonHandler(rw http.ResponseWriter, r *http.Request) {
+	person, err := app.models.People.Get(app.readIDParam(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", app.etag(person.Version))
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"person": person}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) updatePersonHandler(rw http.ResponseWriter, r *http.Request) {
+	person, err := app.models.People.Get(app.readIDParam(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	// Reject the update if the client's copy is outdated
+	if !app.ifMatch(r, app.etag(person.Version)) {
+		app.preconditionFailedResponse(rw, r)
+		return
+	}
+
+	var input struct {
+		Name      *string `json:"name"`
+		BirthYear *int32  `json:"birth_year"`
+		Bio       *string `json:"bio"`
+	}
+
+	err = app.readJSON(rw, r, &input)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	if input.Name != nil {
+		person.Name = *input.Name
+	}
+
+	if input.BirthYear != nil {
+		person.BirthYear = *input.BirthYear
+	}
+
+	if input.Bio != nil {
+		person.Bio = *input.Bio
+	}
+
+	v := validator.New()
+
+	if data.ValidatePerson(v, person); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	err = app.models.People.Update(person)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", app.etag(person.Version))
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"person": person}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) deletePersonHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	err := app.models.People.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	// Credits without their person would only show up as gaps in the movie credits
+	err = app.models.Credits.DeleteAllForPerson(id)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"message": "person successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) listPeopleHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	name := app.readString(qs, "name", "")
+
+	var filters data.Filters
+	filters.Page = app.readInt(qs, "page", 1, v)
+	filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	filters.Sort = app.readString(qs, "sort", "name")
+	filters.SortSafelist = personSortSafelist
+
+	// People are only paged by page number
+	v.Check(filters.Page > 0, "page", "must be greater than zero")
+	v.Check(filters.PageSize > 0, "page_size", "must be greater than zero")
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	people, metadata, err := app.models.People.GetAll(name, filters)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	if links := app.paginationLinks(r, metadata); links != "" {
+		headers.Set("Link", links)
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"people": people, "metadata": metadata}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) showFilmographyHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	v := validator.New()
+
+	output := app.readMovieOutput(r, v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	person, err := app.models.People.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	credits, err := app.models.Credits.GetForPerson(id)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	movies := make([]*data.Movie, 0, len(credits))
+	for _, credit := range credits {
+		movies = append(movies, credit.Movie)
+	}
+	app.formatMovies(rw, output, nil, nil, movies...)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"person": person, "filmography": credits}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPeopleAndCredits(t *testing.T) {
+	ts := newTestServer(t)
+
+	movie := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+
+	var person struct {
+		Person struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"person"`
+	}
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/people", body: `{"name":"Michael Mann","birth_year":1943}`}, http.StatusCreated, &person)
+	url := "/v1/people/" + person.Person.ID
+
+	ts.expect(t, testRequest{method: http.MethodPatch, url: url, body: `{"name":"Michael K. Mann"}`}, http.StatusOK, &person)
+	if person.Person.Name != "Michael K. Mann" {
+		t.Errorf("updated name = %q, want Michael K. Mann", person.Person.Name)
+	}
+
+	var credit struct {
+		Credit struct {
+			ID string `json:"id"`
+		} `json:"credit"`
+	}
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/" + movie + "/credits", body: `{"person_id":"` + person.Person.ID + `","role":"director"}`}, http.StatusCreated, &credit)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/" + movie + "/credits", body: `{"person_id":"` + person.Person.ID + `","role":"catering"}`}, http.StatusUnprocessableEntity, nil)
+
+	var credits struct {
+		Credits []struct {
+			Role string `json:"role"`
+		} `json:"credits"`
+	}
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + movie + "/credits"}, http.StatusOK, &credits)
+	if len(credits.Credits) != 1 || credits.Credits[0].Role != "director" {
+		t.Errorf("credits = %+v, want a director", credits.Credits)
+	}
+
+	var filmography struct {
+		Filmography []struct {
+			Role  string    `json:"role"`
+			Movie testMovie `json:"movie"`
+		} `json:"filmography"`
+	}
+	ts.expect(t, testRequest{method: http.MethodGet, url: url + "/filmography"}, http.StatusOK, &filmography)
+	if len(filmography.Filmography) != 1 || filmography.Filmography[0].Movie.Title != "Heat" || filmography.Filmography[0].Role != "director" {
+		t.Errorf("filmography = %+v, want director of Heat", filmography.Filmography)
+	}
+
+	// People share the movie permissions
+	reader := ts.newUser(t, "reader@example.com", "movies:read")
+	ts.expect(t, testRequest{method: http.MethodGet, url: url, token: reader}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/people", body: `{"name":"Al Pacino"}`, token: reader}, http.StatusForbidden, nil)
+
+	ts.expect(t, testRequest{method: http.MethodDelete, url: "/v1/movies/" + movie + "/credits/" + credit.Credit.ID}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + movie + "/credits"}, http.StatusOK, &credits)
+	if len(credits.Credits) != 0 {
+		t.Errorf("credits after delete = %+v, want none", credits.Credits)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodDelete, url: url}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: url}, http.StatusNotFound, nil)
+}
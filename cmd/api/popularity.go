@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/felixge/httpsnoop"
+)
+
+// viewCounter buffers movie views in memory, flushPopularity adds them to the popularity of the movies in batches
+type viewCounter struct {
+	mu    sync.Mutex
+	views map[string]int64
+}
+
+// add counts n views of the movie with id
+func (c *viewCounter) add(id string, n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.views == nil {
+		c.views = make(map[string]int64)
+	}
+	c.views[id] += n
+}
+
+// take returns the views counted since the last call & starts counting from zero
+func (c *viewCounter) take() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	views := c.views
+	c.views = nil
+	return views
+}
+
+// countView counts a view of the movie with :id if next answers a GET with 200 OK, views feed the popularity used to rank title
+// suggestions. Redirects of merged movies, errors & HEAD requests aren't views
+func (app *application) countView(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || app.config.popularity.flushInterval <= 0 {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		metrics := httpsnoop.CaptureMetrics(next, rw, r)
+		if metrics.Code == http.StatusOK {
+			app.views.add(app.readIDParam(r), 1)
+		}
+	}
+}
+
+// flushPopularity adds the buffered views to the popularity of the movies every interval & a last time once done is closed
+func (app *application) flushPopularity(done <-chan struct{}) {
+	if app.config.popularity.flushInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(app.config.popularity.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			app.addPopularity()
+			return
+		case <-ticker.C:
+			app.addPopularity()
+		}
+	}
+}
+
+// addPopularity adds the buffered views to the popularity of the movies. Views of movies which failed are buffered again for the next
+// flush, views of movies which no longer exist are dropped
+func (app *application) addPopularity() {
+	for id, views := range app.views.take() {
+		err := app.models.Movies.AddPopularity(id, views)
+		if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+			app.logger.PrintError(err, map[string]string{"job": "flush popularity", "movie": id, "views": strconv.FormatInt(views, 10)})
+			app.views.add(id, views)
+		}
+	}
+}
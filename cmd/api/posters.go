@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/thumbnail"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+// maxPosterBytes is the largest accepted poster upload
+const maxPosterBytes = 10 << 20
+
+// posterLimits holds the accepted poster dimensions
+var posterLimits = thumbnail.Limits{MinWidth: 100, MinHeight: 100, MaxWidth: 6000, MaxHeight: 6000}
+
+// posterSizes holds the sizes a poster is stored & served in, the original is kept as uploaded
+var posterSizes = []thumbnail.Size{
+	{Name: "small", Width: 154},
+	{Name: "medium", Width: 342},
+	{Name: "large", Width: 780},
+	{Name: "original"},
+}
+
+// setPosterURLs fills in the poster URLs of movies which have a poster, the upload time busts caches when it's replaced
+func (app *application) setPosterURLs(movies ...*data.Movie) {
+	for _, movie := range movies {
+		if movie.PosterAt.IsZero() {
+			continue
+		}
+
+		movie.Posters = make(map[string]string, len(posterSizes))
+		for _, size := range posterSizes {
+			movie.Posters[size.Name] = fmt.Sprintf("/v1/movies/%s/poster?size=%s&v=%d", movie.ID, size.Name, movie.PosterAt.UnixMilli())
+		}
+	}
+}
+
+// readPoster reads the image of a multipart/form-data "poster" field or the raw request body
+func (app *application) readPoster(rw http.ResponseWriter, r *http.Request) ([]byte, error) {
+	tooLarge := fmt.Errorf("poster must not be larger than %d bytes", maxPosterBytes)
+
+	// Leave room for the multipart headers & boundaries
+	r.Body = http.MaxBytesReader(rw, r.Body, maxPosterBytes+64<<10)
+
+	src := io.Reader(r.Body)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, err
+		}
+
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil, errors.New(`multipart body must contain a "poster" field`)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if part.FormName() == "poster" {
+				src = part
+				break
+			}
+		}
+	}
+
+	image, err := io.ReadAll(io.LimitReader(src, maxPosterBytes+1))
+	switch {
+	case err != nil && err.Error() == "http: request body too large":
+		return nil, tooLarge
+	case err != nil:
+		return nil, err
+	case len(image) > maxPosterBytes:
+		return nil, tooLarge
+	case len(image) == 0:
+		return nil, errors.New("body must not be empty")
+	}
+
+	return image, nil
+}
+
+func (app *application) uploadPosterHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	_, err := app.models.Movies.Get(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	image, err := app.readPoster(rw, r)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	// The content type is sniffed from the image itself, the one the client declared isn't trusted
+	images, err := thumbnail.Generate(image, posterLimits, posterSizes)
+	if err != nil {
+		switch {
+		case errors.Is(err, thumbnail.ErrUnsupportedFormat):
+			app.unsupportedMediaTypeResponse(rw, r, "image/jpeg", "image/png")
+		case errors.Is(err, thumbnail.ErrInvalidDimensions):
+			v := validator.New()
+			v.AddError("poster", err.Error())
+			app.failedValidationResponse(rw, r, v.Errors)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	posters := make([]*data.Poster, 0, len(images))
+	for _, img := range images {
+		posters = append(posters, &data.Poster{
+			Size:        img.Size,
+			ContentType: img.ContentType,
+			Width:       int32(img.Width),
+			Height:      int32(img.Height),
+			Data:        img.Data,
+		})
+	}
+
+	err = app.models.Posters.Put(id, posters)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	movie := &data.Movie{ID: id, PosterAt: posters[0].UploadedAt}
+
+	err = app.models.Movies.SetPoster(id, movie.PosterAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	app.setPosterURLs(movie)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"posters": movie.Posters}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) showPosterHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	qs := r.URL.Query()
+
+	sizes := make([]string, 0, len(posterSizes))
+	for _, size := range posterSizes {
+		sizes = append(sizes, size.Name)
+	}
+
+	size := app.readString(qs, "size", "medium")
+
+	v := validator.New()
+
+	if v.Check(validator.In(size, sizes...), "size", "invalid size value"); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	poster, err := app.models.Posters.Get(id, size)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	// Versioned URLs never change their content, anything else has to be revalidated using the ETag
+	if qs.Get("v") == strconv.FormatInt(poster.UploadedAt.UnixMilli(), 10) {
+		rw.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		rw.Header().Set("Cache-Control", "private, no-cache")
+	}
+	rw.Header().Set("Content-Type", poster.ContentType)
+	rw.Header().Set("ETag", poster.ETag)
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// ServeContent answers conditional & range requests
+	http.ServeContent(rw, r, "", poster.UploadedAt, bytes.NewReader(poster.Data))
+}
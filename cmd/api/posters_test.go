@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMoviePoster(t *testing.T) {
+	ts := newTestServer(t)
+
+	id := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	url := "/v1/movies/" + id + "/poster"
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: url}, http.StatusNotFound, nil)
+
+	encode := func(width, height int) string {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for i := range img.Pix {
+			img.Pix[i] = uint8(i)
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	pngHeaders := map[string]string{"Content-Type": "image/png"}
+	ts.expect(t, testRequest{method: http.MethodPut, url: url, body: encode(600, 900), headers: pngHeaders}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodPut, url: url, body: encode(50, 50), headers: pngHeaders}, http.StatusUnprocessableEntity, nil)
+	ts.expect(t, testRequest{method: http.MethodPut, url: url, body: "not an image", headers: pngHeaders}, http.StatusUnsupportedMediaType, nil)
+
+	var shown struct{ Movie testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + id}, http.StatusOK, &shown)
+	if len(shown.Movie.Posters) == 0 {
+		t.Fatalf("movie posters = %v, want poster URLs", shown.Movie.Posters)
+	}
+
+	for size, posterURL := range shown.Movie.Posters {
+		t.Run(size, func(t *testing.T) {
+			rr := ts.expect(t, testRequest{method: http.MethodGet, url: posterURL}, http.StatusOK, nil)
+			if _, _, err := image.Decode(rr.Body); err != nil {
+				t.Errorf("poster isn't an image: %v", err)
+			}
+
+			// Poster URLs are versioned, so they can be cached for good
+			if got := rr.Header().Get("Cache-Control"); !strings.Contains(got, "immutable") {
+				t.Errorf("Cache-Control = %q, want immutable", got)
+			}
+
+			etag := rr.Header().Get("ETag")
+			ts.expect(t, testRequest{method: http.MethodGet, url: posterURL, headers: map[string]string{"If-None-Match": etag}}, http.StatusNotModified, nil)
+		})
+	}
+
+	// Unversioned URLs have to be revalidated
+	rr := ts.expect(t, testRequest{method: http.MethodGet, url: url + "?size=original"}, http.StatusOK, nil)
+	if got := rr.Header().Get("Cache-Control"); got != "private, no-cache" {
+		t.Errorf("Cache-Control = %q, want private, no-cache", got)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: url + "?size=huge"}, http.StatusUnprocessableEntity, nil)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+// readLimit reads the limit query parameter of the recommendation endpoints
+func (app *application) readLimit(r *http.Request, v *validator.Validator) int {
+	limit := app.readInt(r.URL.Query(), "limit", 10, v)
+
+	v.Check(limit > 0, "limit", "must be greater than zero")
+	v.Check(limit <= 50, "limit", "must be a maximum of 50")
+
+	return limit
+}
+
+func (app *application) listSimilarMoviesHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	v := validator.New()
+
+	limit := app.readLimit(r, v)
+	output := app.readMovieOutput(r, v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	// The movie is read from the store, so movies created since the last index rebuild have similar movies too
+	movie, err := app.models.Movies.Get(id, []string{"year", "runtime", "genres"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	movies := app.recommender.Similar(movie, limit)
+	app.formatMovies(rw, output, nil, nil, movies...)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"movies": movies}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) listRecommendationsHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	limit := app.readLimit(r, v)
+	output := app.readMovieOutput(r, v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	movies := app.recommender.ForUser(app.contextGetUser(r).ID, limit)
+	app.formatMovies(rw, output, nil, nil, movies...)
+
+	err := app.writeResponse(rw, r, http.StatusOK, envelope{"movies": movies}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
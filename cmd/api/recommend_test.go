@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRecommendations(t *testing.T) {
+	ts := newTestServer(t)
+
+	heat := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime","drama"]}`)
+	collateral := ts.createMovie(t, `{"title":"Collateral","year":2004,"runtime":"120 mins","genres":["crime","drama"]}`)
+	ts.createMovie(t, `{"title":"Toy Story","year":1995,"runtime":"81 mins","genres":["animation"]}`)
+
+	if err := ts.app.recommender.Rebuild(ts.app.models.Movies); err != nil {
+		t.Fatal(err)
+	}
+
+	var similar struct{ Movies []testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + heat + "/similar?limit=1"}, http.StatusOK, &similar)
+	if len(similar.Movies) != 1 || similar.Movies[0].ID != collateral {
+		t.Errorf("similar movies = %+v, want Collateral", similar.Movies)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + heat + "/similar?limit=0"}, http.StatusUnprocessableEntity, nil)
+
+	// A user without created or viewed movies has no profile yet
+	viewer := ts.newUser(t, "viewer@example.com", "movies:read")
+
+	var recommended struct{ Movies []testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/user/recommendations", token: viewer}, http.StatusOK, &recommended)
+	if len(recommended.Movies) != 0 {
+		t.Errorf("recommendations = %+v, want none", recommended.Movies)
+	}
+
+	// Viewed movies build the profile & aren't recommended again
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + heat, token: viewer}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/user/recommendations?limit=1", token: viewer}, http.StatusOK, &recommended)
+	if len(recommended.Movies) != 1 || recommended.Movies[0].ID != collateral {
+		t.Errorf("recommendations = %+v, want Collateral", recommended.Movies)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/user/recommendations", token: "-"}, http.StatusUnauthorized, nil)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+var reviewSortSafelist = []string{"created_at", "score", "-created_at", "-score"}
+
+func (app *application) listReviewsHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	var filters data.Filters
+	filters.Page = app.readInt(qs, "page", 1, v)
+	filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	filters.Sort = app.readString(qs, "sort", "-created_at")
+	filters.SortSafelist = reviewSortSafelist
+
+	// Reviews are only paged by page number
+	v.Check(filters.Page > 0, "page", "must be greater than zero")
+	v.Check(filters.PageSize > 0, "page_size", "must be greater than zero")
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	_, err := app.models.Movies.Get(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	reviews, metadata, err := app.models.Reviews.GetAll(id, filters)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	if links := app.paginationLinks(r, metadata); links != "" {
+		headers.Set("Link", links)
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"reviews": reviews, "metadata": metadata}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) createReviewHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	_, err := app.models.Movies.Get(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Score int32  `json:"score"`
+		Text  string `json:"text"`
+	}
+
+	err = app.readJSON(rw, r, &input)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	review := &data.Review{
+		MovieID: id,
+		UserID:  app.contextGetUser(r).ID,
+		Score:   input.Score,
+		Text:    input.Text,
+	}
+
+	v := validator.New()
+
+	if data.ValidateReview(v, review); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	err = app.models.Reviews.Insert(review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateReview):
+			v.AddError("review", "you have already reviewed this movie")
+			app.failedValidationResponse(rw, r, v.Errors)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", app.etag(review.Version))
+
+	err = app.writeResponse(rw, r, http.StatusCreated, envelope{"review": review}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) updateReviewHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	// Users can only ever change their own review
+	review, err := app.models.Reviews.GetForUser(id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if !app.ifMatch(r, app.etag(review.Version)) {
+		app.preconditionFailedResponse(rw, r)
+		return
+	}
+
+	var input struct {
+		Score *int32  `json:"score"`
+		Text  *string `json:"text"`
+	}
+
+	err = app.readJSON(rw, r, &input)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	if input.Score != nil {
+		review.Score = *input.Score
+	}
+
+	if input.Text != nil {
+		review.Text = *input.Text
+	}
+
+	v := validator.New()
+
+	if data.ValidateReview(v, review); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	err = app.models.Reviews.Update(review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(rw, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", app.etag(review.Version))
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"review": review}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) deleteReviewHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	review, err := app.models.Reviews.GetForUser(id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	err = app.models.Reviews.Delete(review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(rw, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"message": "review successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestReviews(t *testing.T) {
+	ts := newTestServer(t)
+	other := ts.newUser(t, "other@example.com", "movies:read")
+
+	movie := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	url := "/v1/movies/" + movie + "/reviews"
+
+	ts.expect(t, testRequest{method: http.MethodPost, url: url, body: `{"score":8,"text":"Great"}`}, http.StatusCreated, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: url, body: `{"score":4,"text":"Fine"}`, token: other}, http.StatusCreated, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: url, body: `{"score":5}`}, http.StatusUnprocessableEntity, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: url, body: `{"score":11}`, token: ts.newUser(t, "third@example.com")}, http.StatusUnprocessableEntity, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/0123456789abcdef01234567/reviews", body: `{"score":5}`}, http.StatusNotFound, nil)
+
+	rating := func(want float64, count int64) {
+		t.Helper()
+
+		var res struct{ Movie testMovie }
+		ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/" + movie}, http.StatusOK, &res)
+		if res.Movie.Rating != want || res.Movie.RatingCount != count {
+			t.Errorf("rating = %v of %d, want %v of %d", res.Movie.Rating, res.Movie.RatingCount, want, count)
+		}
+	}
+
+	rating(6, 2)
+
+	ts.expect(t, testRequest{method: http.MethodPatch, url: url, body: `{"score":10}`, token: other}, http.StatusOK, nil)
+	rating(9, 2)
+
+	ts.expect(t, testRequest{method: http.MethodDelete, url: url}, http.StatusOK, nil)
+	rating(10, 1)
+
+	var list struct {
+		Reviews []struct {
+			Score int32 `json:"score"`
+		} `json:"reviews"`
+	}
+	ts.expect(t, testRequest{method: http.MethodGet, url: url}, http.StatusOK, &list)
+	if len(list.Reviews) != 1 || list.Reviews[0].Score != 10 {
+		t.Errorf("reviews = %+v, want one with score 10", list.Reviews)
+	}
+
+	// The average rating can be sorted on
+	ronin := ts.createMovie(t, `{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action"]}`)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/movies/" + ronin + "/reviews", body: `{"score":7}`}, http.StatusCreated, nil)
+
+	var sorted struct{ Movies []testMovie }
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?sort=-rating"}, http.StatusOK, &sorted)
+	if len(sorted.Movies) != 2 || sorted.Movies[0].ID != movie || sorted.Movies[1].ID != ronin {
+		t.Errorf("movies by rating = %+v, want Heat before Ronin", sorted.Movies)
+	}
+
+	// Only the author can change a review
+	ts.expect(t, testRequest{method: http.MethodPatch, url: "/v1/movies/" + ronin + "/reviews", body: `{"score":1}`, token: other}, http.StatusNotFound, nil)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+func (app *application) listRevisionsHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	v := validator.New()
+
+	output := app.readMovieOutput(r, v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	revisions, err := app.models.Revisions.GetAll(id)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	// Movies created before revisions were recorded have no history, which is only missing if the movie is
+	if len(revisions) == 0 {
+		_, err = app.models.Movies.Get(id, []string{"id"})
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(rw, r)
+			default:
+				app.serverErrorResponse(rw, r, err)
+			}
+			return
+		}
+	}
+
+	for _, revision := range revisions {
+		app.formatMovies(rw, output, nil, nil, &revision.Movie)
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"revisions": revisions}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) showRevisionHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	version, err := app.readVersionParam(r)
+	if err != nil {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	v := validator.New()
+
+	output := app.readMovieOutput(r, v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	revision, err := app.models.Revisions.Get(id, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	app.formatMovie(rw, headers, output, nil, &revision.Movie)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"revision": revision}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+// diffRevisionsHandler lists the fields changed between the ?from version (default: the previous one) & :version
+func (app *application) diffRevisionsHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	version, err := app.readVersionParam(r)
+	if err != nil {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	v := validator.New()
+
+	from := app.readInt(r.URL.Query(), "from", int(version)-1, v)
+	v.Check(from >= 0, "from", "must not be negative")
+
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	to, err := app.models.Revisions.Get(id, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	// Version 0 is the empty record before the movie was inserted
+	base := &data.Movie{}
+	if from > 0 {
+		previous, err := app.models.Revisions.Get(id, int32(from))
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(rw, r)
+			default:
+				app.serverErrorResponse(rw, r, err)
+			}
+			return
+		}
+		base = &previous.Movie
+	}
+
+	changes, err := data.DiffMovies(base, &to.Movie)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	diff := envelope{"movie_id": id, "from": from, "to": version, "changes": changes}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"diff": diff}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+// revertRevisionHandler restores the fields of an old version, the result is stored as a new revision
+func (app *application) revertRevisionHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	version, err := app.readVersionParam(r)
+	if err != nil {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	revision, err := app.models.Revisions.Get(id, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	v := validator.New()
+
+	output := app.readMovieOutput(r, v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	// Movies in the trash have to be restored before they can be reverted
+	movie, err := app.models.Movies.Get(id, nil)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if !app.ifMatch(r, app.etag(movie.Version)) {
+		app.preconditionFailedResponse(rw, r)
+		return
+	}
+
+	updated := *movie
+	updated.Title = revision.Movie.Title
+	updated.Titles = revision.Movie.Titles
+	updated.OriginalLanguage = revision.Movie.OriginalLanguage
+	updated.Year = revision.Movie.Year
+	updated.Runtime = revision.Movie.Runtime
+	updated.Genres = append([]string{}, revision.Movie.Genres...)
+	updated.ExternalIDs = revision.Movie.ExternalIDs
+
+	// Validation rules may have changed since the revision was written
+	if data.ValidateMovie(v, &updated); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	update := data.NewMovieUpdate(movie, &updated)
+	if !update.Empty() {
+		change := data.Change{Action: data.RevisionRevert, UserID: app.contextGetUser(r).ID, RevertedFrom: version}
+
+		err = app.models.Movies.Patch(movie, update, change)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrEditConflict):
+				app.editConflictResponse(rw, r)
+			case errors.Is(err, data.ErrDuplicateExternalID):
+				v.AddError("external_ids", "must not be used by another movie")
+				app.failedValidationResponse(rw, r, v.Errors)
+			default:
+				app.serverErrorResponse(rw, r, err)
+			}
+			return
+		}
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", app.etag(movie.Version))
+
+	app.formatMovie(rw, headers, output, nil, movie)
+	app.setPosterURLs(movie)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"movie": movie}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
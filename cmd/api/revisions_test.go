@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMovieRevisions(t *testing.T) {
+	ts := newTestServer(t)
+
+	id := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	url := "/v1/movies/" + id
+
+	ts.expect(t, testRequest{method: http.MethodPatch, url: url, body: `{"year":1996}`}, http.StatusOK, nil)
+
+	var diff struct {
+		Diff struct {
+			Changes []struct {
+				Field string `json:"field"`
+			} `json:"changes"`
+		} `json:"diff"`
+	}
+	ts.expect(t, testRequest{method: http.MethodGet, url: url + "/revisions/2/diff"}, http.StatusOK, &diff)
+	if len(diff.Diff.Changes) != 1 || diff.Diff.Changes[0].Field != "year" {
+		t.Errorf("diff changes = %+v, want only year", diff.Diff.Changes)
+	}
+
+	var reverted struct{ Movie testMovie }
+	ts.expect(t, testRequest{method: http.MethodPost, url: url + "/revisions/1/revert"}, http.StatusOK, &reverted)
+	if reverted.Movie.Year != 1995 {
+		t.Errorf("reverted year = %d, want 1995", reverted.Movie.Year)
+	}
+
+	var list struct {
+		Revisions []struct {
+			Version      int32  `json:"version"`
+			Action       string `json:"action"`
+			RevertedFrom int32  `json:"reverted_from"`
+		} `json:"revisions"`
+	}
+	ts.expect(t, testRequest{method: http.MethodGet, url: url + "/revisions"}, http.StatusOK, &list)
+
+	wantActions := []string{"insert", "update", "revert"}
+	if len(list.Revisions) != len(wantActions) {
+		t.Fatalf("revisions = %+v, want %v", list.Revisions, wantActions)
+	}
+	for i, revision := range list.Revisions {
+		if revision.Version != int32(i+1) || revision.Action != wantActions[i] {
+			t.Errorf("revision %d = %+v, want version %d %s", i, revision, i+1, wantActions[i])
+		}
+	}
+	if list.Revisions[2].RevertedFrom != 1 {
+		t.Errorf("reverted_from = %d, want 1", list.Revisions[2].RevertedFrom)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: url + "/revisions/9"}, http.StatusNotFound, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/0123456789abcdef01234567/revisions"}, http.StatusNotFound, nil)
+}
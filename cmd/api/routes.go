@@ -3,6 +3,7 @@ package main
 import (
 	"expvar"
 	"net/http"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -19,22 +20,119 @@ func (app *application) routes() http.Handler {
 	// Register relevant methods, URL patterns & handler functions for endpoints using HandlerFunc() method
 	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", app.healthcheckHandler)
 
-	// Movies CRUD endpoints
+	// Movies CRUD endpoints, the single movie routes serve the movie actions first, see movieActions
+	actions := app.movieActions()
 	router.HandlerFunc(http.MethodGet, "/v1/movies", app.requirePermission("movies:read", app.listMoviesHandler))
 	router.HandlerFunc(http.MethodPost, "/v1/movies", app.requirePermission("movies:write", app.createMovieHandler))
-	router.HandlerFunc(http.MethodGet, "/v1/movies/:id", app.requirePermission("movies:read", app.showMovieHandler))
-	router.HandlerFunc(http.MethodPatch, "/v1/movies/:id", app.requirePermission("movies:write", app.updateMovieHandler))
-	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id", app.requirePermission("movies:write", app.deleteMovieHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id", app.dispatchID(actions, http.MethodGet, app.requirePermission("movies:read", app.countView(app.showMovieHandler))))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id", app.dispatchID(actions, http.MethodPost, app.notFoundResponse))
+	router.HandlerFunc(http.MethodPatch, "/v1/movies/:id", app.dispatchID(actions, http.MethodPatch, app.requirePermission("movies:write", app.updateMovieHandler)))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id", app.dispatchID(actions, http.MethodDelete, app.requirePermission("movies:write", app.deleteMovieHandler)))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/restore", app.requirePermission("movies:admin", app.restoreMovieHandler))
+
+	// Merges duplicate movies into the one with :id, the merged movies redirect to it
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/merge", app.requirePermission("movies:write", app.mergeMoviesHandler))
+
+	// Movie poster endpoints, uploads are stored in GridFS along with their thumbnails
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/poster", app.requirePermission("movies:write", app.uploadPosterHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/poster", app.requirePermission("movies:read", app.showPosterHandler))
+
+	// Movie revision history endpoints
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/revisions", app.requirePermission("movies:read", app.listRevisionsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/revisions/:version", app.requirePermission("movies:read", app.showRevisionHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/revisions/:version/diff", app.requirePermission("movies:read", app.diffRevisionsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/revisions/:version/revert", app.requirePermission("movies:write", app.revertRevisionHandler))
+
+	// Content-based recommendation endpoints, scored over an in-memory index rebuilt periodically
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/similar", app.requirePermission("movies:read", app.listSimilarMoviesHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/user/recommendations", app.requireActivatedUser(app.listRecommendationsHandler))
+
+	// Movie credit endpoints
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/credits", app.requirePermission("movies:read", app.listCreditsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/credits", app.requirePermission("movies:write", app.createCreditHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/credits/:credit_id", app.requirePermission("movies:write", app.deleteCreditHandler))
+
+	// Movie review endpoints, a user has at most one review per movie which PATCH & DELETE act on
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/reviews", app.requirePermission("movies:read", app.listReviewsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/reviews", app.requireActivatedUser(app.createReviewHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/movies/:id/reviews", app.requireActivatedUser(app.updateReviewHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/reviews", app.requireActivatedUser(app.deleteReviewHandler))
+
+	// People CRUD endpoints, guarded by the movie permissions
+	router.HandlerFunc(http.MethodGet, "/v1/people", app.requirePermission("movies:read", app.listPeopleHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/people", app.requirePermission("movies:write", app.createPersonHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/people/:id", app.requirePermission("movies:read", app.showPersonHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/people/:id", app.requirePermission("movies:write", app.updatePersonHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/people/:id", app.requirePermission("movies:write", app.deletePersonHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/people/:id/filmography", app.requirePermission("movies:read", app.showFilmographyHandler))
 
 	// User endpoints
 	router.HandlerFunc(http.MethodPost, "/v1/user", app.registerUserHandler)
 	router.HandlerFunc(http.MethodPut, "/v1/user/activate", app.activateUserHandler)
 
+	// Watchlist & watched history endpoints, always scoped to the authenticated user
+	router.HandlerFunc(http.MethodGet, "/v1/user/watchlist", app.requireActivatedUser(app.listWatchlistHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/user/watchlist", app.requireActivatedUser(app.addWatchlistHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/user/watchlist", app.requireActivatedUser(app.reorderWatchlistHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/user/watchlist/:id", app.requireActivatedUser(app.removeWatchlistHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/user/watched", app.requireActivatedUser(app.listWatchedHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/user/watched", app.requireActivatedUser(app.logWatchedHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/user/watched/summary", app.requireActivatedUser(app.showWatchedSummaryHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/user/watched/:id", app.requireActivatedUser(app.removeWatchedHandler))
+
 	// Token endpoints
 	router.HandlerFunc(http.MethodPost, "/v1/token/authentication", app.createAuthenticationTokenHandler)
 
 	// Debug/Metric endpoints
 	router.Handler(http.MethodGet, "/debug/vars", expvar.Handler())
 
-	return app.metrics(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(router)))))
+	return app.metrics(app.recoverPanic(app.enableCORS(app.negotiate(app.rateLimit(app.authenticate(router))))))
+}
+
+// movieAction is an endpoint acting on the movie collection at /v1/movies/<name>, handler is wrapped in the middleware of the endpoint
+type movieAction struct {
+	method  string
+	name    string
+	handler http.HandlerFunc
+}
+
+// movieActions is the dispatch table of the movie actions. httprouter doesn't allow static segments next to the :id wildcard, so
+// the actions are served by the /v1/movies/:id routes through dispatchID. Movie IDs are hex ObjectIDs & never collide with a name
+func (app *application) movieActions() []movieAction {
+	return []movieAction{
+		{http.MethodPost, "import", app.requirePermission("movies:write", app.importMoviesHandler)},
+		{http.MethodGet, "export", app.requirePermission("movies:read", app.exportMoviesHandler)},
+		{http.MethodGet, "trash", app.requirePermission("movies:admin", app.listTrashHandler)},
+		{http.MethodGet, "suggest", app.requirePermission("movies:read", app.suggestMoviesHandler)},
+		{http.MethodGet, "lookup", app.requirePermission("movies:read", app.lookupMovieHandler)},
+	}
+}
+
+// dispatchID serves requests of the :id route for method with the movie action named by :id, anything else with next. Actions
+// which exist for other methods only are answered with 405 Method Not Allowed, like httprouter does for registered routes
+func (app *application) dispatchID(actions []movieAction, method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		name := app.readIDParam(r)
+
+		var allowed []string
+		for _, action := range actions {
+			if action.name != name {
+				continue
+			}
+
+			if action.method == method {
+				action.handler.ServeHTTP(rw, r)
+				return
+			}
+			allowed = append(allowed, action.method)
+		}
+
+		if len(allowed) != 0 {
+			rw.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+			app.methodNotAllowedResponse(rw, r)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	}
 }
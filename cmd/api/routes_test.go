@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMovieActionsRouting(t *testing.T) {
+	ts := newTestServer(t)
+
+	tests := []struct {
+		method string
+		url    string
+		status int
+		allow  string
+	}{
+		{http.MethodGet, "/v1/movies/trash", http.StatusOK, ""},
+		{http.MethodGet, "/v1/movies/export", http.StatusOK, ""},
+		{http.MethodGet, "/v1/movies/suggest?q=a", http.StatusOK, ""},
+		{http.MethodGet, "/v1/movies/lookup?tmdb=949", http.StatusNotFound, ""},
+		{http.MethodPatch, "/v1/movies/import", http.StatusMethodNotAllowed, "POST, OPTIONS"},
+		{http.MethodGet, "/v1/movies/import", http.StatusMethodNotAllowed, "POST, OPTIONS"},
+		{http.MethodDelete, "/v1/movies/trash", http.StatusMethodNotAllowed, "GET, OPTIONS"},
+		{http.MethodGet, "/v1/movies/0123456789abcdef01234567", http.StatusNotFound, ""},
+		{http.MethodPost, "/v1/movies/0123456789abcdef01234567", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			rr := ts.expect(t, testRequest{method: tt.method, url: tt.url}, tt.status, nil)
+			if got := rr.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+)
+
+func TestMovieSearch(t *testing.T) {
+	ts := newTestServer(t)
+
+	ts.createMovie(t, `{"title":"The Heat","year":2013,"runtime":"117 mins","genres":["comedy","crime"]}`)
+	ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	ts.createMovie(t, `{"title":"Crime Story","year":1986,"runtime":"96 mins","genres":["drama"]}`)
+
+	type result struct {
+		Title      string          `json:"title"`
+		Score      float64         `json:"score"`
+		Highlights []data.TextSpan `json:"highlights"`
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		want       string
+		highlights []data.TextSpan
+	}{
+		{"shorter title ranks first", "title=heat", "Heat,The Heat", []data.TextSpan{{Start: 0, End: 4, Text: "Heat"}}},
+		{"title ranks over genres", "genres=crime", "Crime Story,Heat,The Heat", []data.TextSpan{{Start: 0, End: 5, Text: "Crime"}}},
+		{"stemmed match", "title=stories", "Crime Story", []data.TextSpan{{Start: 6, End: 11, Text: "Story"}}},
+		{"explicit sort", "title=heat&sort=-year", "The Heat,Heat", []data.TextSpan{{Start: 4, End: 8, Text: "Heat"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list struct {
+				Movies []result `json:"movies"`
+			}
+			ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?" + tt.query}, http.StatusOK, &list)
+
+			titles := make([]string, len(list.Movies))
+			for i, movie := range list.Movies {
+				if movie.Score <= 0 {
+					t.Errorf("%s score = %v, want it positive", movie.Title, movie.Score)
+				}
+				titles[i] = movie.Title
+			}
+			if got := strings.Join(titles, ","); got != tt.want {
+				t.Fatalf("movies = %q, want %q", got, tt.want)
+			}
+
+			highlights := list.Movies[0].Highlights
+			if len(highlights) != len(tt.highlights) || (len(highlights) != 0 && highlights[0] != tt.highlights[0]) {
+				t.Errorf("highlights = %+v, want %+v", highlights, tt.highlights)
+			}
+		})
+	}
+
+	// Without a search term there's neither a score nor highlights
+	rr := ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?year_min=1990"}, http.StatusOK, nil)
+	if body := rr.Body.String(); strings.Contains(body, `"score"`) || strings.Contains(body, `"highlights"`) {
+		t.Errorf("body = %s, want no score & highlights", body)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?sort=relevance"}, http.StatusUnprocessableEntity, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies?title=heat&title_match=prefix&sort=relevance"}, http.StatusUnprocessableEntity, nil)
+}
@@ -23,6 +23,12 @@ func (app *application) serve() error {
 	// Shutdown error channel receives any errors returned by Shutdown()
 	shutdownError := make(chan error)
 
+	// Periodic jobs run in the background until stopJobs is closed on shutdown
+	stopJobs := make(chan struct{})
+	app.background(func() { app.purgeTrash(stopJobs) })
+	app.background(func() { app.rebuildRecommendations(stopJobs) })
+	app.background(func() { app.flushPopularity(stopJobs) })
+
 	go func() {
 		// Quit channel carries os.Signal values
 		quit := make(chan os.Signal, 1)
@@ -53,6 +59,9 @@ func (app *application) serve() error {
 			"addr": srv.Addr,
 		})
 
+		// Stop periodic jobs
+		close(stopJobs)
+
 		// Block shutdown until WaitGroup counter is zero/all routines finished
 		app.wg.Wait()
 		shutdownError <- nil
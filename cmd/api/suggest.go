@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+// Path of the suggest endpoint, it has its own rate limiter bucket as typeahead clients send a request per keystroke
+const suggestPath = "/v1/movies/suggest"
+
+// suggestion is the trimmed down movie returned by the suggest endpoint
+type suggestion struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Year  int32  `json:"year"`
+}
+
+func (app *application) suggestMoviesHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	q := app.readString(qs, "q", "")
+	limit := app.readInt(qs, "limit", 10, v)
+
+	v.Check(q != "", "q", "must be provided")
+	v.Check(utf8.RuneCountInString(q) <= 100, "q", "must not be more than 100 characters long")
+	v.Check(limit > 0, "limit", "must be greater than zero")
+	v.Check(limit <= 20, "limit", "must be a maximum of 20")
+
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	movies, err := app.models.Movies.Suggest(q, limit)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	suggestions := make([]suggestion, 0, len(movies))
+	for _, movie := range movies {
+		suggestions = append(suggestions, suggestion{ID: movie.ID, Title: movie.Title, Year: movie.Year})
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"suggestions": suggestions}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
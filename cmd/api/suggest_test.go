@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMovieSuggest(t *testing.T) {
+	ts := newTestServer(t)
+
+	heathers := ts.createMovie(t, `{"title":"Heathers","year":1989,"runtime":"103 mins","genres":["comedy"]}`)
+	ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	ts.createMovie(t, `{"title":"The Heat","year":2013,"runtime":"117 mins","genres":["comedy"]}`)
+	ts.createMovie(t, `{"title":"Amélie","year":2001,"runtime":"122 mins","genres":["comedy"]}`)
+
+	// Ties between title prefix matches are broken by popularity
+	if err := ts.app.models.Movies.AddPopularity(heathers, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"title prefix before word prefix", "q=hea", "Heathers,Heat,The Heat"},
+		{"case insensitive", "q=HEATH", "Heathers"},
+		{"diacritic insensitive", "q=ame", "Amélie"},
+		{"multiple words", "q=the%20he", "The Heat"},
+		{"limit", "q=hea&limit=1", "Heathers"},
+		{"no match", "q=ronin", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res struct {
+				Suggestions []struct {
+					ID    string `json:"id"`
+					Title string `json:"title"`
+					Year  int32  `json:"year"`
+				} `json:"suggestions"`
+			}
+			ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/suggest?" + tt.query}, http.StatusOK, &res)
+
+			titles := make([]string, len(res.Suggestions))
+			for i, s := range res.Suggestions {
+				if s.ID == "" || s.Year == 0 {
+					t.Errorf("suggestion %+v, want an id & a year", s)
+				}
+				titles[i] = s.Title
+			}
+			if got := strings.Join(titles, ","); got != tt.want {
+				t.Errorf("suggestions = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, query := range []string{"", "q=hea&limit=0", "q=hea&limit=21", "q=" + strings.Repeat("a", 101)} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/movies/suggest?" + query}, http.StatusUnprocessableEntity, nil)
+		})
+	}
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/BunnyTheLifeguard/greenlight/internal/data"
 	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (app *application) createAuthenticationTokenHandler(rw http.ResponseWriter, r *http.Request) {
@@ -38,7 +37,7 @@ func (app *application) createAuthenticationTokenHandler(rw http.ResponseWriter,
 	user, err := app.models.User.GetByEmail(input.Email)
 	if err != nil {
 		switch {
-		case errors.Is(err, mongo.ErrNoDocuments):
+		case errors.Is(err, data.ErrRecordNotFound):
 			app.invalidCredentialsResponse(rw, r)
 		default:
 			app.serverErrorResponse(rw, r, err)
@@ -66,7 +65,7 @@ func (app *application) createAuthenticationTokenHandler(rw http.ResponseWriter,
 	}
 
 	// Encode token to JSON & send with 201
-	err = app.writeJSON(rw, http.StatusCreated, envelope{"authentication_token": token}, nil)
+	err = app.writeResponse(rw, r, http.StatusCreated, envelope{"authentication_token": token}, nil)
 	if err != nil {
 		app.serverErrorResponse(rw, r, err)
 		return
@@ -7,7 +7,6 @@ import (
 
 	"github.com/BunnyTheLifeguard/greenlight/internal/data"
 	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (app *application) registerUserHandler(rw http.ResponseWriter, r *http.Request) {
@@ -90,7 +89,7 @@ func (app *application) registerUserHandler(rw http.ResponseWriter, r *http.Requ
 	})
 
 	// Write & send 201 JSON response with user data
-	err = app.writeJSON(rw, http.StatusAccepted, envelope{"user": user}, nil)
+	err = app.writeResponse(rw, r, http.StatusAccepted, envelope{"user": user}, nil)
 	if err != nil {
 		app.serverErrorResponse(rw, r, err)
 	}
@@ -120,7 +119,7 @@ func (app *application) activateUserHandler(rw http.ResponseWriter, r *http.Requ
 	token, err := app.models.Token.Get(data.ScopeActivation, input.TokenPlaintext)
 	if err != nil {
 		switch {
-		case errors.Is(err, mongo.ErrNoDocuments):
+		case errors.Is(err, data.ErrRecordNotFound):
 			v.AddError("token", "invalid or expired activation token")
 			app.failedValidationResponse(rw, r, v.Errors)
 		default:
@@ -132,7 +131,7 @@ func (app *application) activateUserHandler(rw http.ResponseWriter, r *http.Requ
 	user, err := app.models.User.GetForToken(token)
 	if err != nil {
 		switch {
-		case errors.Is(err, mongo.ErrNoDocuments):
+		case errors.Is(err, data.ErrRecordNotFound):
 			v.AddError("token", "user for token not found")
 			app.failedValidationResponse(rw, r, v.Errors)
 		default:
@@ -163,7 +162,7 @@ func (app *application) activateUserHandler(rw http.ResponseWriter, r *http.Requ
 	}
 
 	// Send updated user details to client
-	err = app.writeJSON(rw, http.StatusOK, envelope{"user": user}, nil)
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"user": user}, nil)
 	if err != nil {
 		app.serverErrorResponse(rw, r, err)
 	}
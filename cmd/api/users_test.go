@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestUsersAndAuthentication(t *testing.T) {
+	ts := newTestServer(t)
+	reader := ts.newUser(t, "reader@example.com", "movies:read")
+
+	var registered struct {
+		User struct {
+			Email     string `json:"email"`
+			Activated bool   `json:"activated"`
+		} `json:"user"`
+	}
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/user", token: "-", body: `{"name":"New User","email":"new@example.com","password":"pa55word1234"}`}, http.StatusAccepted, &registered)
+	if registered.User.Email != "new@example.com" || registered.User.Activated {
+		t.Errorf("registered user = %+v, want new@example.com & not activated", registered.User)
+	}
+
+	var created struct {
+		AuthenticationToken struct {
+			Token string `json:"token"`
+		} `json:"authentication_token"`
+	}
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/token/authentication", token: "-", body: `{"email":"reader@example.com","password":"pa55word1234"}`}, http.StatusCreated, &created)
+
+	tests := []struct {
+		name   string
+		req    testRequest
+		status int
+	}{
+		{"new token", testRequest{method: http.MethodGet, url: "/v1/movies", token: created.AuthenticationToken.Token}, http.StatusOK},
+		{"duplicate email", testRequest{method: http.MethodPost, url: "/v1/user", token: "-", body: `{"name":"again","email":"reader@example.com","password":"pa55word1234"}`}, http.StatusUnprocessableEntity},
+		{"wrong password", testRequest{method: http.MethodPost, url: "/v1/token/authentication", token: "-", body: `{"email":"reader@example.com","password":"wrong-password"}`}, http.StatusUnauthorized},
+		{"anonymous", testRequest{method: http.MethodGet, url: "/v1/movies", token: "-"}, http.StatusUnauthorized},
+		{"invalid token", testRequest{method: http.MethodGet, url: "/v1/movies", token: "ABCDEFGHIJKLMNOPQRSTUVWXYZ"}, http.StatusUnauthorized},
+		{"missing permission", testRequest{method: http.MethodPost, url: "/v1/movies", token: reader, body: `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`}, http.StatusForbidden},
+		{"read permission", testRequest{method: http.MethodGet, url: "/v1/movies", token: reader}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.expect(t, tt.req, tt.status, nil)
+		})
+	}
+}
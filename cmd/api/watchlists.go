@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+var watchedSortSafelist = []string{"last_watched_at", "watch_count", "-last_watched_at", "-watch_count"}
+
+// movieExists reports whether a movie which isn't in the trash exists & writes the error response if it doesn't
+func (app *application) movieExists(rw http.ResponseWriter, r *http.Request, id string) bool {
+	_, err := app.models.Movies.Get(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v := validator.New()
+			v.AddError("movie_id", "movie not found")
+			app.failedValidationResponse(rw, r, v.Errors)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return false
+	}
+
+	return true
+}
+
+// formatWatchlist formats the movies of a watchlist with formatMovies, entries of movies which were deleted have none
+func (app *application) formatWatchlist(rw http.ResponseWriter, output movieOutput, entries []*data.WatchlistEntry) {
+	movies := make([]*data.Movie, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Movie != nil {
+			movies = append(movies, entry.Movie)
+		}
+	}
+
+	app.formatMovies(rw, output, nil, nil, movies...)
+}
+
+func (app *application) listWatchlistHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	output := app.readMovieOutput(r, v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	entries, err := app.models.Watchlist.GetAll(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	app.formatWatchlist(rw, output, entries)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"watchlist": entries}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) addWatchlistHandler(rw http.ResponseWriter, r *http.Request) {
+	var input struct {
+		MovieID string `json:"movie_id"`
+	}
+
+	err := app.readJSON(rw, r, &input)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if v.Check(input.MovieID != "", "movie_id", "must be provided"); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	if !app.movieExists(rw, r, input.MovieID) {
+		return
+	}
+
+	entry, err := app.models.Watchlist.Add(app.contextGetUser(r).ID, input.MovieID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrAlreadyOnWatchlist):
+			v.AddError("movie_id", "movie is already on your watchlist")
+			app.failedValidationResponse(rw, r, v.Errors)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	err = app.writeResponse(rw, r, http.StatusCreated, envelope{"entry": entry}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) reorderWatchlistHandler(rw http.ResponseWriter, r *http.Request) {
+	var input struct {
+		MovieIDs []string `json:"movie_ids"`
+	}
+
+	err := app.readJSON(rw, r, &input)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	output := app.readMovieOutput(r, v)
+
+	v.Check(input.MovieIDs != nil, "movie_ids", "must be provided")
+	if v.Check(validator.Unique(input.MovieIDs), "movie_ids", "must not contain duplicate values"); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	err = app.models.Watchlist.Reorder(user.ID, input.MovieIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrWatchlistMismatch):
+			v.AddError("movie_ids", "must contain every movie on your watchlist exactly once")
+			app.failedValidationResponse(rw, r, v.Errors)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	entries, err := app.models.Watchlist.GetAll(user.ID)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	app.formatWatchlist(rw, output, entries)
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"watchlist": entries}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) removeWatchlistHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	err := app.models.Watchlist.Remove(app.contextGetUser(r).ID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"message": "movie successfully removed from watchlist"}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) listWatchedHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	var filters data.Filters
+	filters.Page = app.readInt(qs, "page", 1, v)
+	filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	filters.Sort = app.readString(qs, "sort", "-last_watched_at")
+	filters.SortSafelist = watchedSortSafelist
+
+	output := app.readMovieOutput(r, v)
+
+	// The watched history is only paged by page number
+	v.Check(filters.Page > 0, "page", "must be greater than zero")
+	v.Check(filters.PageSize > 0, "page_size", "must be greater than zero")
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	watched, metadata, err := app.models.Watched.GetAll(app.contextGetUser(r).ID, filters)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	movies := make([]*data.Movie, 0, len(watched))
+	for _, entry := range watched {
+		if entry.Movie != nil {
+			movies = append(movies, entry.Movie)
+		}
+	}
+	app.formatMovies(rw, output, nil, nil, movies...)
+
+	headers := make(http.Header)
+	if links := app.paginationLinks(r, metadata); links != "" {
+		headers.Set("Link", links)
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"watched": watched, "metadata": metadata}, headers)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) logWatchedHandler(rw http.ResponseWriter, r *http.Request) {
+	var input struct {
+		MovieID   string     `json:"movie_id"`
+		WatchedAt *time.Time `json:"watched_at"`
+	}
+
+	err := app.readJSON(rw, r, &input)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	// Watches are logged as just happened unless a date is given
+	now := time.Now()
+	watchedAt := now
+	if input.WatchedAt != nil {
+		watchedAt = *input.WatchedAt
+	}
+
+	v := validator.New()
+
+	v.Check(input.MovieID != "", "movie_id", "must be provided")
+	if v.Check(!watchedAt.After(now), "watched_at", "must not be in the future"); !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	if !app.movieExists(rw, r, input.MovieID) {
+		return
+	}
+
+	watched, err := app.models.Watched.Log(app.contextGetUser(r).ID, input.MovieID, watchedAt.UTC())
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	err = app.writeResponse(rw, r, http.StatusCreated, envelope{"watched": watched}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) removeWatchedHandler(rw http.ResponseWriter, r *http.Request) {
+	id := app.readIDParam(r)
+
+	err := app.models.Watched.Remove(app.contextGetUser(r).ID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"message": "movie successfully removed from watched history"}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) showWatchedSummaryHandler(rw http.ResponseWriter, r *http.Request) {
+	summary, err := app.models.Watched.Summary(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	err = app.writeResponse(rw, r, http.StatusOK, envelope{"summary": summary}, nil)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestWatchlistAndWatched(t *testing.T) {
+	ts := newTestServer(t)
+
+	heat := ts.createMovie(t, `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime"]}`)
+	ronin := ts.createMovie(t, `{"title":"Ronin","year":1998,"runtime":"122 mins","genres":["action"]}`)
+
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/user/watchlist", body: `{"movie_id":"` + heat + `"}`}, http.StatusCreated, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/user/watchlist", body: `{"movie_id":"` + ronin + `"}`}, http.StatusCreated, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/user/watchlist", body: `{"movie_id":"` + ronin + `"}`}, http.StatusUnprocessableEntity, nil)
+
+	var watchlist struct {
+		Watchlist []struct {
+			MovieID string    `json:"movie_id"`
+			Movie   testMovie `json:"movie"`
+		} `json:"watchlist"`
+	}
+	ts.expect(t, testRequest{method: http.MethodPut, url: "/v1/user/watchlist", body: `{"movie_ids":["` + ronin + `","` + heat + `"]}`}, http.StatusOK, &watchlist)
+	if len(watchlist.Watchlist) != 2 || watchlist.Watchlist[0].MovieID != ronin || watchlist.Watchlist[0].Movie.Title != "Ronin" {
+		t.Errorf("watchlist = %+v, want Ronin first", watchlist.Watchlist)
+	}
+
+	// A reorder has to name every movie on the watchlist exactly once
+	ts.expect(t, testRequest{method: http.MethodPut, url: "/v1/user/watchlist", body: `{"movie_ids":["` + ronin + `"]}`}, http.StatusUnprocessableEntity, nil)
+
+	ts.expect(t, testRequest{method: http.MethodDelete, url: "/v1/user/watchlist/" + heat}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/user/watchlist"}, http.StatusOK, &watchlist)
+	if len(watchlist.Watchlist) != 1 || watchlist.Watchlist[0].MovieID != ronin {
+		t.Errorf("watchlist after remove = %+v, want only Ronin", watchlist.Watchlist)
+	}
+
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/user/watched", body: `{"movie_id":"` + heat + `","watched_at":"2020-01-01T20:00:00Z"}`}, http.StatusCreated, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/user/watched", body: `{"movie_id":"` + heat + `"}`}, http.StatusCreated, nil)
+	ts.expect(t, testRequest{method: http.MethodPost, url: "/v1/user/watched", body: `{"movie_id":"` + heat + `","watched_at":"2999-01-01T00:00:00Z"}`}, http.StatusUnprocessableEntity, nil)
+
+	var summary struct {
+		Summary struct {
+			Movies  int64 `json:"movies"`
+			Views   int64 `json:"views"`
+			Minutes int64 `json:"minutes"`
+		} `json:"summary"`
+	}
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/user/watched/summary"}, http.StatusOK, &summary)
+	if summary.Summary.Movies != 1 || summary.Summary.Views != 2 || summary.Summary.Minutes != 340 {
+		t.Errorf("summary = %+v, want 1 movie, 2 views & 340 minutes", summary.Summary)
+	}
+
+	var watched struct {
+		Watched []struct {
+			MovieID      string `json:"movie_id"`
+			RewatchCount int32  `json:"rewatch_count"`
+		} `json:"watched"`
+	}
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/user/watched"}, http.StatusOK, &watched)
+	if len(watched.Watched) != 1 || watched.Watched[0].RewatchCount != 1 {
+		t.Errorf("watched = %+v, want Heat rewatched once", watched.Watched)
+	}
+
+	// Every list belongs to an activated user
+	ts.expect(t, testRequest{method: http.MethodGet, url: "/v1/user/watchlist", token: "-"}, http.StatusUnauthorized, nil)
+
+	ts.expect(t, testRequest{method: http.MethodDelete, url: "/v1/user/watched/" + heat}, http.StatusOK, nil)
+	ts.expect(t, testRequest{method: http.MethodDelete, url: "/v1/user/watched/" + heat}, http.StatusNotFound, nil)
+}
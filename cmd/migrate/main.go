@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/jsonlog"
+	"github.com/BunnyTheLifeguard/greenlight/internal/migrations"
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Config struct holds the database settings, uses the same cli-flags & env variables as cmd/api
+type config struct {
+	db struct {
+		uri       string
+		name      string
+		data      string
+		user      string
+		token     string
+		revision  string
+		people    string
+		credit    string
+		review    string
+		watchlist string
+		watched   string
+		poster    string
+	}
+	timeout time.Duration
+}
+
+func init() {
+	err := godotenv.Load(filepath.Join(".env"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatal("Error loading .env file")
+	}
+}
+
+func main() {
+	var cfg config
+
+	flag.StringVar(&cfg.db.uri, "db-uri", os.Getenv("MONGODB_URI"), "MongoDB URI")
+	flag.StringVar(&cfg.db.name, "db-name", os.Getenv("DB"), "DB Name")
+	flag.StringVar(&cfg.db.data, "db-data", os.Getenv("DATA"), "Collection Data")
+	flag.StringVar(&cfg.db.user, "db-user", os.Getenv("USER"), "Collection User")
+	flag.StringVar(&cfg.db.token, "db-token", os.Getenv("TOKEN"), "Collection Token")
+	flag.StringVar(&cfg.db.revision, "db-revision", os.Getenv("REVISION"), "Collection Revision")
+	flag.StringVar(&cfg.db.people, "db-people", os.Getenv("PEOPLE"), "Collection People")
+	flag.StringVar(&cfg.db.credit, "db-credit", os.Getenv("CREDIT"), "Collection Credit")
+	flag.StringVar(&cfg.db.review, "db-review", os.Getenv("REVIEW"), "Collection Review")
+	flag.StringVar(&cfg.db.watchlist, "db-watchlist", os.Getenv("WATCHLIST"), "Collection Watchlist")
+	flag.StringVar(&cfg.db.watched, "db-watched", os.Getenv("WATCHED"), "Collection Watched")
+	flag.StringVar(&cfg.db.poster, "db-poster", os.Getenv("POSTER"), "GridFS Bucket Poster")
+	flag.DurationVar(&cfg.timeout, "timeout", 10*time.Minute, "Maximum duration of the whole migration run")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] up [N] | down [N] | status\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "  up [N]    apply the next N pending migrations, all of them if N is omitted")
+		fmt.Fprintln(flag.CommandLine.Output(), "  down [N]  revert the last N applied migrations, 1 if N is omitted")
+		fmt.Fprintln(flag.CommandLine.Output(), "  status    list all migrations & whether they have been applied")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	command := flag.Arg(0)
+
+	n := 0
+	if flag.NArg() == 2 {
+		var err error
+		n, err = strconv.Atoi(flag.Arg(1))
+		if err != nil || n < 1 {
+			logger.PrintFatal(fmt.Errorf("invalid number of migrations %q", flag.Arg(1)), nil)
+			os.Exit(1)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+
+	client, err := openDB(ctx, cfg)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+		os.Exit(1)
+	}
+	defer client.Disconnect(context.Background())
+
+	migrator := migrations.New(client.Database(cfg.db.name), migrations.Collections{
+		Movies:    cfg.db.data,
+		Users:     cfg.db.user,
+		Tokens:    cfg.db.token,
+		Revisions: cfg.db.revision,
+		People:    cfg.db.people,
+		Credits:   cfg.db.credit,
+		Reviews:   cfg.db.review,
+		Watchlist: cfg.db.watchlist,
+		Watched:   cfg.db.watched,
+		Posters:   cfg.db.poster,
+	})
+
+	switch command {
+	case "up":
+		done, err := migrator.Up(ctx, n)
+		logApplied(logger, "applied migration", done)
+		if err != nil {
+			logger.PrintFatal(err, nil)
+			os.Exit(1)
+		}
+		if len(done) == 0 {
+			logger.PrintInfo("no pending migrations", nil)
+		}
+
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		done, err := migrator.Down(ctx, n)
+		logApplied(logger, "reverted migration", done)
+		if err != nil {
+			logger.PrintFatal(err, nil)
+			os.Exit(1)
+		}
+		if len(done) == 0 {
+			logger.PrintInfo("no applied migrations", nil)
+		}
+
+	case "status":
+		err = printStatus(ctx, migrator)
+		if err != nil {
+			logger.PrintFatal(err, nil)
+			os.Exit(1)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func logApplied(logger *jsonlog.Logger, message string, done []migrations.Migration) {
+	for _, migration := range done {
+		logger.PrintInfo(message, map[string]string{
+			"version":     strconv.Itoa(migration.Version),
+			"description": migration.Description,
+		})
+	}
+}
+
+func printStatus(ctx context.Context, migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Current version:\t%d\n", current)
+	fmt.Printf("Latest version:\t\t%d\n\n", migrator.Latest())
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tAPPLIED AT\tDESCRIPTION")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", status.Version, appliedAt, status.Description)
+	}
+
+	return tw.Flush()
+}
+
+func openDB(ctx context.Context, cfg config) (*mongo.Client, error) {
+	client, err := mongo.NewClient(options.Client().ApplyURI(cfg.db.uri))
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.Ping(ctx, readpref.Primary())
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
@@ -0,0 +1,160 @@
+// Package codec converts JSON documents to & from XML, CSV & MessagePack. Documents are decoded into generic values which keep the
+// order of object members, so the other formats list fields in the same order as the JSON encoding.
+// Generic values are nil, bool, json.Number, string, []interface{} & Object
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// maxDepth is the deepest nesting of arrays & objects decoded, the same limit encoding/json has
+const maxDepth = 10000
+
+var (
+	// ErrSyntax error if a document isn't well-formed
+	ErrSyntax = errors.New("badly-formed document")
+	// ErrMultipleValues error if a document holds more than a single value
+	ErrMultipleValues = errors.New("more than a single value")
+	// ErrNotTabular error if a value can't be written as rows of a table
+	ErrNotTabular = errors.New("value is not tabular")
+)
+
+// Member is a single member of an Object
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// Object is a JSON object with its members in document order
+type Object []Member
+
+// FromJSON decodes a single JSON value into a generic value, numbers are kept as json.Number
+func FromJSON(js []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+
+	return decodeJSON(dec)
+}
+
+func decodeJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		object := Object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, Member{Key: key.(string), Value: value})
+		}
+
+		_, err = dec.Token()
+		return object, err
+
+	case json.Delim('['):
+		array := []interface{}{}
+		for dec.More() {
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+
+		_, err = dec.Token()
+		return array, err
+	}
+
+	return tok, nil
+}
+
+// ToJSON encodes a generic value as JSON
+func ToJSON(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := encodeJSON(&buf, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeJSON(buf *bytes.Buffer, value interface{}) error {
+	switch value := value.(type) {
+	case Object:
+		buf.WriteByte('{')
+		for i, member := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			key, err := json.Marshal(member.Key)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+
+			err = encodeJSON(buf, member.Value)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			err := encodeJSON(buf, item)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	default:
+		js, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(js)
+	}
+
+	return nil
+}
+
+// scalarText returns the text of a value which is neither an array nor an object, null is empty
+func scalarText(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		if value {
+			return "true"
+		}
+		return "false"
+	case json.Number:
+		return value.String()
+	case string:
+		return value
+	}
+
+	return ""
+}
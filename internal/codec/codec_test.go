@@ -0,0 +1,73 @@
+package codec
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// mustFromJSON decodes js into a generic value, failing the test if it isn't valid
+func mustFromJSON(t *testing.T, js string) interface{} {
+	t.Helper()
+
+	value, err := FromJSON([]byte(js))
+	if err != nil {
+		t.Fatalf("FromJSON(%s) error = %v", js, err)
+	}
+	return value
+}
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		js   string
+		want interface{}
+	}{
+		{`null`, nil},
+		{`true`, true},
+		{`1.50`, json.Number("1.50")},
+		{`"Heat"`, "Heat"},
+		{`[]`, []interface{}{}},
+		{`{}`, Object{}},
+		{`{"z":1,"a":[true,null]}`, Object{{"z", json.Number("1")}, {"a", []interface{}{true, nil}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			if got := mustFromJSON(t, tt.js); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	// Member order & number formatting are kept
+	tests := []string{
+		`null`,
+		`"a \"quoted\" string"`,
+		`{"title":"Heat","year":1995,"rating":8.50,"genres":["crime","drama"],"ids":{}}`,
+		`[{"z":1,"a":2},[],[[null]]]`,
+	}
+
+	for _, js := range tests {
+		t.Run(js, func(t *testing.T) {
+			got, err := ToJSON(mustFromJSON(t, js))
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != js {
+				t.Errorf("ToJSON() = %s, want %s", got, js)
+			}
+		})
+	}
+}
+
+func TestFromJSONInvalid(t *testing.T) {
+	for _, js := range []string{``, `{`, `{"a":}`, `[1,]`} {
+		t.Run(js, func(t *testing.T) {
+			if _, err := FromJSON([]byte(js)); err == nil {
+				t.Errorf("FromJSON(%s) error = nil, want an error", js)
+			}
+		})
+	}
+}
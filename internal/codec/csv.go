@@ -0,0 +1,90 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+)
+
+// MarshalCSV encodes an array of objects as CSV with a header row, other values return ErrNotTabular. The columns are the keys of
+// all objects in order of first appearance. Arrays of scalars are joined by commas like genres in imports, other nested values are
+// written as JSON
+func MarshalCSV(value interface{}) ([]byte, error) {
+	rows, ok := value.([]interface{})
+	if !ok {
+		return nil, ErrNotTabular
+	}
+
+	var columns []string
+	index := make(map[string]int)
+
+	for _, row := range rows {
+		object, ok := row.(Object)
+		if !ok {
+			return nil, ErrNotTabular
+		}
+
+		for _, member := range object {
+			if _, ok := index[member.Key]; !ok {
+				index[member.Key] = len(columns)
+				columns = append(columns, member.Key)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	// Without any rows there are no columns either
+	if len(columns) != 0 {
+		err := w.Write(columns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for _, member := range row.(Object) {
+			cell, err := csvCell(member.Value)
+			if err != nil {
+				return nil, err
+			}
+			record[index[member.Key]] = cell
+		}
+
+		err := w.Write(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func csvCell(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case Object:
+		js, err := ToJSON(value)
+		return string(js), err
+
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			switch item.(type) {
+			case Object, []interface{}:
+				js, err := ToJSON(value)
+				return string(js), err
+			}
+			items[i] = scalarText(item)
+		}
+		return strings.Join(items, ","), nil
+	}
+
+	return scalarText(value), nil
+}
@@ -0,0 +1,44 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMarshalCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		js      string
+		want    string
+		wantErr error
+	}{
+		{"rows", `[{"id":"a","title":"Heat","year":1995},{"id":"b","title":"Ronin","year":1998}]`, "id,title,year\na,Heat,1995\nb,Ronin,1998\n", nil},
+		{"columns in order of appearance", `[{"id":"a"},{"title":"Heat","id":"b"}]`, "id,title\na,\nb,Heat\n", nil},
+		{"scalar arrays joined", `[{"genres":["crime","drama"]}]`, "genres\n\"crime,drama\"\n", nil},
+		{"nested values as JSON", `[{"ids":{"imdb":"tt1"},"cast":[{"name":"x"}]}]`, "ids,cast\n\"{\"\"imdb\"\":\"\"tt1\"\"}\",\"[{\"\"name\"\":\"\"x\"\"}]\"\n", nil},
+		{"null and booleans", `[{"a":null,"b":true,"c":false}]`, "a,b,c\n,true,false\n", nil},
+		{"quoting", `[{"title":"Heat, \"the\" movie"}]`, "title\n\"Heat, \"\"the\"\" movie\"\n", nil},
+		{"empty", `[]`, "", nil},
+		{"object", `{"id":"a"}`, "", ErrNotTabular},
+		{"array of scalars", `["a","b"]`, "", ErrNotTabular},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalCSV(mustFromJSON(t, tt.js))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("MarshalCSV() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("MarshalCSV() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalCSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
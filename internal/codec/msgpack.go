@@ -0,0 +1,318 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// MarshalMsgpack encodes a generic value as MessagePack, integers take the smallest encoding & all other numbers are float64
+func MarshalMsgpack(value interface{}) ([]byte, error) {
+	return appendMsgpack(nil, value)
+}
+
+func appendMsgpack(b []byte, value interface{}) ([]byte, error) {
+	var err error
+
+	switch value := value.(type) {
+	case nil:
+		b = append(b, 0xc0)
+
+	case bool:
+		if value {
+			b = append(b, 0xc3)
+		} else {
+			b = append(b, 0xc2)
+		}
+
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return appendMsgpackInt(b, i), nil
+		}
+
+		f, err := value.Float64()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, 0xcb)
+		b = appendUint(b, math.Float64bits(f), 8)
+
+	case string:
+		b = appendMsgpackHeader(b, len(value), 0xa0, 31, 0xd9, 0xda, 0xdb)
+		b = append(b, value...)
+
+	case []interface{}:
+		b = appendMsgpackHeader(b, len(value), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, item := range value {
+			b, err = appendMsgpack(b, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	case Object:
+		b = appendMsgpackHeader(b, len(value), 0x80, 15, 0, 0xde, 0xdf)
+		for _, member := range value {
+			b, _ = appendMsgpack(b, member.Key)
+			b, err = appendMsgpack(b, member.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("codec: unsupported value of type %T", value)
+	}
+
+	return b, nil
+}
+
+func appendMsgpackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return append(b, byte(i))
+	case i >= 0 && i <= math.MaxUint8:
+		return append(b, 0xcc, byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		return appendUint(append(b, 0xcd), uint64(i), 2)
+	case i >= 0 && i <= math.MaxUint32:
+		return appendUint(append(b, 0xce), uint64(i), 4)
+	case i >= 0:
+		return appendUint(append(b, 0xcf), uint64(i), 8)
+	case i >= -32:
+		return append(b, byte(0xe0|(i+32)))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return appendUint(append(b, 0xd1), uint64(i), 2)
+	case i >= math.MinInt32:
+		return appendUint(append(b, 0xd2), uint64(i), 4)
+	default:
+		return appendUint(append(b, 0xd3), uint64(i), 8)
+	}
+}
+
+// appendUint appends the lowest n bytes of u in big-endian order
+func appendUint(b []byte, u uint64, n int) []byte {
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(u>>(8*i)))
+	}
+	return b
+}
+
+// appendMsgpackHeader appends the type & length of a string, array or map, using the fix type up to fixMax & the smallest of the 8,
+// 16 & 32 bit types after that. Arrays & maps have no 8 bit type, which is passed as 0
+func appendMsgpackHeader(b []byte, n int, fix byte, fixMax int, t8, t16, t32 byte) []byte {
+	switch {
+	case n <= fixMax:
+		return append(b, fix|byte(n))
+	case t8 != 0 && n <= math.MaxUint8:
+		return append(b, t8, byte(n))
+	case n <= math.MaxUint16:
+		return appendUint(append(b, t16), uint64(n), 2)
+	default:
+		return appendUint(append(b, t32), uint64(n), 4)
+	}
+}
+
+// UnmarshalMsgpack decodes a single MessagePack value into a generic value, numbers become json.Number. Maps must have string keys,
+// binary & extension types have no JSON counterpart & are rejected as well as floats which aren't finite
+func UnmarshalMsgpack(body []byte) (interface{}, error) {
+	if len(body) == 0 {
+		return nil, io.EOF
+	}
+
+	d := msgpackDecoder{b: body}
+
+	value, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(d.b) != 0 {
+		return nil, ErrMultipleValues
+	}
+
+	return value, nil
+}
+
+// msgpackDecoder reads MessagePack values from the front of b
+type msgpackDecoder struct {
+	b []byte
+}
+
+// next removes & returns the first n bytes
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.b) < n {
+		return nil, ErrSyntax
+	}
+
+	next := d.b[:n]
+	d.b = d.b[n:]
+	return next, nil
+}
+
+// uint reads a big-endian unsigned integer of n bytes
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+func (d *msgpackDecoder) value(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, ErrSyntax
+	}
+
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	t := b[0]
+
+	switch {
+	case t <= 0x7f:
+		return json.Number(strconv.Itoa(int(t))), nil
+	case t >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(t)))), nil
+	case t >= 0xa0 && t <= 0xbf:
+		return d.str(int(t & 0x1f))
+	case t >= 0x90 && t <= 0x9f:
+		return d.array(int(t&0x0f), depth)
+	case t >= 0x80 && t <= 0x8f:
+		return d.object(int(t&0x0f), depth)
+	}
+
+	switch t {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uint(1 << (t - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatUint(u, 10)), nil
+
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (t - 0xd0)
+		u, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend the value from its size to 64 bits
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(u<<shift)>>shift, 10)), nil
+
+	case 0xca, 0xcb:
+		var f float64
+		if t == 0xca {
+			u, err := d.uint(4)
+			if err != nil {
+				return nil, err
+			}
+			f = float64(math.Float32frombits(uint32(u)))
+		} else {
+			u, err := d.uint(8)
+			if err != nil {
+				return nil, err
+			}
+			f = math.Float64frombits(u)
+		}
+
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, ErrSyntax
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (t - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (t - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(int(n), depth)
+
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (t - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.object(int(n), depth)
+	}
+
+	// Binary, extension & the unused type 0xc1
+	return nil, ErrSyntax
+}
+
+func (d *msgpackDecoder) str(n int) (interface{}, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) array(n int, depth int) (interface{}, error) {
+	// Every item takes at least a byte, so longer arrays can't be complete
+	if n > len(d.b) {
+		return nil, ErrSyntax
+	}
+
+	array := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		item, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, item)
+	}
+
+	return array, nil
+}
+
+func (d *msgpackDecoder) object(n int, depth int) (interface{}, error) {
+	if 2*n > len(d.b) {
+		return nil, ErrSyntax
+	}
+
+	object := make(Object, 0, n)
+	for i := 0; i < n; i++ {
+		key, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		k, ok := key.(string)
+		if !ok {
+			return nil, ErrSyntax
+		}
+
+		value, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		object = append(object, Member{Key: k, Value: value})
+	}
+
+	return object, nil
+}
@@ -0,0 +1,177 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMarshalMsgpack(t *testing.T) {
+	tests := []struct {
+		js   string
+		want string
+	}{
+		{`null`, "c0"},
+		{`false`, "c2"},
+		{`true`, "c3"},
+		{`0`, "00"},
+		{`127`, "7f"},
+		{`128`, "cc80"},
+		{`256`, "cd0100"},
+		{`65536`, "ce00010000"},
+		{`4294967296`, "cf0000000100000000"},
+		{`-1`, "ff"},
+		{`-32`, "e0"},
+		{`-33`, "d0df"},
+		{`-129`, "d1ff7f"},
+		{`-32769`, "d2ffff7fff"},
+		{`-2147483649`, "d3ffffffff7fffffff"},
+		{`1.5`, "cb3ff8000000000000"},
+		{`""`, "a0"},
+		{`"Heat"`, "a448656174"},
+		{`"` + strings.Repeat("a", 32) + `"`, "d920" + strings.Repeat("61", 32)},
+		{`[]`, "90"},
+		{`[1,"a"]`, "9201a161"},
+		{`{}`, "80"},
+		{`{"b":1,"a":2}`, "82a16201a16102"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			got, err := MarshalMsgpack(mustFromJSON(t, tt.js))
+			if err != nil {
+				t.Fatalf("MarshalMsgpack() error = %v", err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("MarshalMsgpack() = %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalMsgpackLongContainers(t *testing.T) {
+	tests := []struct {
+		name   string
+		js     string
+		prefix string
+	}{
+		{"array16", "[" + strings.TrimSuffix(strings.Repeat("1,", 16), ",") + "]", "dc0010"},
+		{"map16", `{` + strings.TrimSuffix(strings.Repeat(`"a":1,`, 16), ",") + `}`, "de0010"},
+		{"str16", `"` + strings.Repeat("a", 256) + `"`, "da0100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalMsgpack(mustFromJSON(t, tt.js))
+			if err != nil {
+				t.Fatalf("MarshalMsgpack() error = %v", err)
+			}
+			if !strings.HasPrefix(hex.EncodeToString(got), tt.prefix) {
+				t.Errorf("MarshalMsgpack() = %x, want prefix %s", got, tt.prefix)
+			}
+		})
+	}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	tests := []string{
+		`null`,
+		`-9223372036854775808`,
+		`9223372036854775807`,
+		`0.1`,
+		`{"title":"Heat","year":1995,"rating":8.5,"genres":["crime","drama"],"ids":{"tmdb":949},"deleted":false,"poster":null}`,
+		`[[],{},[{"a":[-1,-200,70000]}]]`,
+	}
+
+	for _, js := range tests {
+		t.Run(js, func(t *testing.T) {
+			b, err := MarshalMsgpack(mustFromJSON(t, js))
+			if err != nil {
+				t.Fatalf("MarshalMsgpack() error = %v", err)
+			}
+
+			value, err := UnmarshalMsgpack(b)
+			if err != nil {
+				t.Fatalf("UnmarshalMsgpack() error = %v", err)
+			}
+
+			got, err := ToJSON(value)
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != js {
+				t.Errorf("round trip = %s, want %s", got, js)
+			}
+		})
+	}
+}
+
+func TestUnmarshalMsgpack(t *testing.T) {
+	tests := []struct {
+		name    string
+		hex     string
+		want    string
+		wantErr error
+	}{
+		{"float32", "ca3fc00000", `1.5`, nil},
+		{"uint64", "cfffffffffffffffff", `18446744073709551615`, nil},
+		{"int8", "d080", `-128`, nil},
+		{"str8", "d903616263", `"abc"`, nil},
+		{"array32", "dd0000000101", `[1]`, nil},
+		{"map32", "df00000001a16101", `{"a":1}`, nil},
+
+		{"empty", "", "", io.EOF},
+		{"trailing value", "c0c0", "", ErrMultipleValues},
+		{"truncated string", "a548656174", "", ErrSyntax},
+		{"truncated int", "cd01", "", ErrSyntax},
+		{"truncated array", "9301", "", ErrSyntax},
+		{"non-string key", "810101", "", ErrSyntax},
+		{"binary", "c40100", "", ErrSyntax},
+		{"extension", "d40100", "", ErrSyntax},
+		{"unused type", "c1", "", ErrSyntax},
+		{"NaN", "cb7ff8000000000001", "", ErrSyntax},
+		{"infinity", "ca7f800000", "", ErrSyntax},
+		{"huge array length", "ddffffffff", "", ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := hex.DecodeString(tt.hex)
+			if err != nil {
+				t.Fatalf("invalid test hex %s: %v", tt.hex, err)
+			}
+
+			value, err := UnmarshalMsgpack(b)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("UnmarshalMsgpack() = %v, %v, want %v", value, err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("UnmarshalMsgpack() error = %v", err)
+			}
+
+			got, err := ToJSON(value)
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("UnmarshalMsgpack() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalMsgpackDepth(t *testing.T) {
+	// Nesting deeper than maxDepth is rejected instead of exhausting the stack
+	b := append(bytes.Repeat([]byte{0x91}, maxDepth+1), 0xc0)
+
+	if _, err := UnmarshalMsgpack(b); !errors.Is(err, ErrSyntax) {
+		t.Errorf("UnmarshalMsgpack() error = %v, want %v", err, ErrSyntax)
+	}
+}
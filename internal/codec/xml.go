@@ -0,0 +1,312 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Elements holding array items are named xmlItem. Object members whose key isn't a valid XML name are xmlEntry elements with the key
+// in an attribute
+const (
+	xmlItem  = "item"
+	xmlEntry = "entry"
+	xmlKey   = "key"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// MarshalXML encodes a generic value as an XML document with a root element named root
+func MarshalXML(value interface{}, root string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+
+	err := encodeXML(enc, xml.StartElement{Name: xml.Name{Local: root}}, value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enc.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeXML(enc *xml.Encoder, start xml.StartElement, value interface{}) error {
+	err := enc.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case Object:
+		for _, member := range value {
+			child := xml.StartElement{Name: xml.Name{Local: member.Key}}
+			if !validXMLName(member.Key) {
+				child = xml.StartElement{Name: xml.Name{Local: xmlEntry}, Attr: []xml.Attr{{Name: xml.Name{Local: xmlKey}, Value: member.Key}}}
+			}
+
+			err = encodeXML(enc, child, member.Value)
+			if err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		for _, item := range value {
+			err = encodeXML(enc, xml.StartElement{Name: xml.Name{Local: xmlItem}}, item)
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		if text := scalarText(value); text != "" {
+			err = enc.EncodeToken(xml.CharData(text))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// validXMLName reports if name can be used as an element name, names with colons are left out as they'd be read as namespaced
+func validXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// xmlNode is an element of a decoded XML document
+type xmlNode struct {
+	key      string
+	text     strings.Builder
+	children []*xmlNode
+}
+
+// UnmarshalXML decodes an XML document into a generic value shaped after t, the type the value is decoded into as JSON afterwards.
+// XML has no types of its own, so t decides if an element is an object, an array, a number, a boolean or a string. Elements which
+// don't match a field of t are decoded as strings, or objects if they have children, so decoding the JSON reports them as unknown
+func UnmarshalXML(body []byte, t reflect.Type) (interface{}, error) {
+	root, err := parseXML(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return root.value(t), nil
+}
+
+// parseXML reads the element tree of an XML document, the name of the root element doesn't matter
+func parseXML(body []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+
+	var root *xmlNode
+	var stack []*xmlNode
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, ErrSyntax
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{key: tok.Name.Local}
+			for _, attr := range tok.Attr {
+				if tok.Name.Local == xmlEntry && attr.Name.Local == xmlKey {
+					node.key = attr.Value
+				}
+			}
+
+			switch {
+			case len(stack) > 0:
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			case root != nil:
+				return nil, ErrMultipleValues
+			default:
+				root = node
+			}
+
+			stack = append(stack, node)
+			if len(stack) > maxDepth {
+				return nil, ErrSyntax
+			}
+
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(tok)
+			} else if len(bytes.TrimSpace(tok)) != 0 {
+				return nil, ErrSyntax
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, io.EOF
+	}
+
+	return root, nil
+}
+
+// value converts the element to a generic value of the kind t is decoded from, t is nil if the type isn't known
+func (n *xmlNode) value(t reflect.Type) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	text := n.text.String()
+
+	// Types decoding themselves get the text, e.g. data.Runtime parses "1h 52m"
+	if t != nil && len(n.children) == 0 && reflect.PtrTo(t).Implements(unmarshalerType) {
+		return text
+	}
+
+	kind := reflect.Invalid
+	if t != nil {
+		kind = t.Kind()
+	}
+
+	switch kind {
+	case reflect.Struct:
+		object := Object{}
+		for _, child := range n.children {
+			object = append(object, Member{Key: child.key, Value: child.value(fieldType(t, child.key))})
+		}
+		return object
+
+	case reflect.Map:
+		object := Object{}
+		for _, child := range n.children {
+			object = append(object, Member{Key: child.key, Value: child.value(t.Elem())})
+		}
+		return object
+
+	case reflect.Slice, reflect.Array:
+		array := []interface{}{}
+		for _, child := range n.children {
+			array = append(array, child.value(t.Elem()))
+		}
+		return array
+
+	// Text which isn't a boolean or number is kept, so decoding the JSON reports the incorrect type
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(strings.TrimSpace(text)); err == nil {
+			return b
+		}
+		return text
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if number := strings.TrimSpace(text); validNumber(number) {
+			return json.Number(number)
+		}
+		return text
+
+	case reflect.String:
+		return text
+	}
+
+	// Without a type to go by, elements holding only items are arrays & other elements with children objects
+	if len(n.children) == 0 {
+		return text
+	}
+
+	items := true
+	for _, child := range n.children {
+		items = items && child.key == xmlItem
+	}
+
+	if items {
+		array := []interface{}{}
+		for _, child := range n.children {
+			array = append(array, child.value(nil))
+		}
+		return array
+	}
+
+	object := Object{}
+	for _, child := range n.children {
+		object = append(object, Member{Key: child.key, Value: child.value(nil)})
+	}
+	return object
+}
+
+// fieldType returns the type of the field of struct t which key is decoded into as JSON, matching names like encoding/json does
+func fieldType(t reflect.Type, key string) reflect.Type {
+	var folded reflect.Type
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+
+		// Fields of embedded structs are promoted
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if ft := fieldType(embedded, key); ft != nil {
+					return ft
+				}
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if name == key {
+			return field.Type
+		}
+		if folded == nil && strings.EqualFold(name, key) {
+			folded = field.Type
+		}
+	}
+
+	return folded
+}
+
+// validNumber reports if s is a JSON number
+func validNumber(s string) bool {
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return false
+	}
+	return json.Valid([]byte(s))
+}
@@ -0,0 +1,162 @@
+package codec
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMarshalXML(t *testing.T) {
+	tests := []struct {
+		name string
+		js   string
+		want string
+	}{
+		{"scalars", `{"title":"Heat","year":1995,"rating":8.5,"deleted":false,"poster":null}`, `<response><title>Heat</title><year>1995</year><rating>8.5</rating><deleted>false</deleted><poster></poster></response>`},
+		{"arrays", `{"genres":["crime","drama"],"empty":[]}`, `<response><genres><item>crime</item><item>drama</item></genres><empty></empty></response>`},
+		{"nested objects", `{"movie":{"ids":{"imdb":"tt0113277"}}}`, `<response><movie><ids><imdb>tt0113277</imdb></ids></movie></response>`},
+		{"invalid names", `{"titles":{"pt-BR":"Fogo","1st":"a","x:y":"b","":"c"}}`, `<response><titles><pt-BR>Fogo</pt-BR><entry key="1st">a</entry><entry key="x:y">b</entry><entry key="">c</entry></titles></response>`},
+		{"escaping", `{"title":"Tom & Jerry <3"}`, `<response><title>Tom &amp; Jerry &lt;3</title></response>`},
+		{"array root", `[1,2]`, `<response><item>1</item><item>2</item></response>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalXML(mustFromJSON(t, tt.js), "response")
+			if err != nil {
+				t.Fatalf("MarshalXML() error = %v", err)
+			}
+
+			body := strings.TrimPrefix(string(got), `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+			if body != tt.want {
+				t.Errorf("MarshalXML() = %s, want %s", body, tt.want)
+			}
+		})
+	}
+}
+
+// xmlRuntime decodes itself like data.Runtime, so it's given the element text
+type xmlRuntime int
+
+func (r *xmlRuntime) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(s, " mins"))
+	*r = xmlRuntime(n)
+	return err
+}
+
+type xmlIDs struct {
+	IMDb string `json:"imdb"`
+	TMDb int64  `json:"tmdb"`
+}
+
+type xmlEmbedded struct {
+	Rating float64 `json:"rating"`
+}
+
+type xmlMovie struct {
+	xmlEmbedded
+	Title   string            `json:"title"`
+	Year    *int32            `json:"year"`
+	Runtime xmlRuntime        `json:"runtime"`
+	Genres  []string          `json:"genres"`
+	Titles  map[string]string `json:"titles"`
+	IDs     *xmlIDs           `json:"external_ids"`
+	Watched bool              `json:"watched"`
+	Skipped string            `json:"-"`
+}
+
+func TestUnmarshalXML(t *testing.T) {
+	movieType := reflect.TypeOf(&xmlMovie{})
+
+	tests := []struct {
+		name string
+		xml  string
+		t    reflect.Type
+		want string
+	}{
+		{"typed fields", `<movie><title>1995</title><year> 1995 </year><runtime>170 mins</runtime><watched>true</watched><rating>8.5</rating></movie>`, movieType,
+			`{"title":"1995","year":1995,"runtime":"170 mins","watched":true,"rating":8.5}`},
+		{"arrays", `<movie><genres><item>crime</item><item>42</item></genres></movie>`, movieType, `{"genres":["crime","42"]}`},
+		{"empty array", `<movie><genres/></movie>`, movieType, `{"genres":[]}`},
+		{"maps", `<movie><titles><de>Heat</de><entry key="pt-BR">Fogo</entry></titles></movie>`, movieType, `{"titles":{"de":"Heat","pt-BR":"Fogo"}}`},
+		{"nested struct", `<movie><external_ids><imdb>tt0113277</imdb><tmdb>949</tmdb></external_ids></movie>`, movieType, `{"external_ids":{"imdb":"tt0113277","tmdb":949}}`},
+		{"case-insensitive field", `<movie><TITLE>Heat</TITLE><Year>1995</Year></movie>`, movieType, `{"TITLE":"Heat","Year":1995}`},
+		{"invalid number kept as text", `<movie><year>soon</year><watched>maybe</watched></movie>`, movieType, `{"year":"soon","watched":"maybe"}`},
+		{"unknown elements", `<movie><cast><item>a</item></cast><crew><name>b</name></crew><note>c</note></movie>`, movieType,
+			`{"cast":["a"],"crew":{"name":"b"},"note":"c"}`},
+		{"ignored field", `<movie><Skipped>x</Skipped></movie>`, movieType, `{"Skipped":"x"}`},
+		{"untyped", `<response><a>1</a><b><item>x</item></b></response>`, nil, `{"a":"1","b":["x"]}`},
+		{"root name ignored", `<anything><title>Heat</title></anything>`, movieType, `{"title":"Heat"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := UnmarshalXML([]byte(tt.xml), tt.t)
+			if err != nil {
+				t.Fatalf("UnmarshalXML() error = %v", err)
+			}
+
+			got, err := ToJSON(value)
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("UnmarshalXML() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalXMLInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		xml     string
+		wantErr error
+	}{
+		{"empty", ``, io.EOF},
+		{"only a declaration", `<?xml version="1.0"?>`, io.EOF},
+		{"unclosed element", `<movie><title>Heat</movie>`, ErrSyntax},
+		{"text outside the root", `<movie/>text`, ErrSyntax},
+		{"two roots", `<movie/><movie/>`, ErrMultipleValues},
+		{"too deep", strings.Repeat("<a>", maxDepth+1) + strings.Repeat("</a>", maxDepth+1), ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalXML([]byte(tt.xml), reflect.TypeOf(&xmlMovie{}))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalXML() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	// Responses decoded with the type they were encoded from give back the same JSON
+	js := `{"title":"Heat","year":1995,"runtime":"170 mins","genres":["crime","drama"],"titles":{"pt-BR":"Fogo contra Fogo"},"external_ids":{"imdb":"tt0113277","tmdb":949},"watched":true,"rating":8.5}`
+
+	body, err := MarshalXML(mustFromJSON(t, js), "response")
+	if err != nil {
+		t.Fatalf("MarshalXML() error = %v", err)
+	}
+
+	value, err := UnmarshalXML(body, reflect.TypeOf(&xmlMovie{}))
+	if err != nil {
+		t.Fatalf("UnmarshalXML() error = %v", err)
+	}
+
+	got, err := ToJSON(value)
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	if string(got) != js {
+		t.Errorf("round trip = %s, want %s", got, js)
+	}
+}
@@ -0,0 +1,164 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreditRoles holds the roles a person can be credited with, in the order credits are listed
+var CreditRoles = []string{"director", "writer", "producer", "cast", "composer", "cinematographer", "editor"}
+
+// Credit struct links a person to a movie, Person or Movie is filled in by the credits & filmography lookups
+type Credit struct {
+	OID       primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	ID        string             `json:"id" bson:"id"`
+	MovieID   string             `json:"movie_id" bson:"movie_id"`
+	PersonID  string             `json:"person_id" bson:"person_id"`
+	Role      string             `json:"role" bson:"role"`
+	Character string             `json:"character,omitempty" bson:"character,omitempty"`
+	Billing   int32              `json:"billing,omitempty" bson:"billing,omitempty"`
+	CreatedAt time.Time          `json:"-" bson:"created_at"`
+	Person    *Person            `json:"person,omitempty" bson:"person,omitempty"`
+	Movie     *Movie             `json:"movie,omitempty" bson:"movie,omitempty"`
+}
+
+// ValidateCredit checks the fields of a credit, the referenced movie & person are checked by the caller
+func ValidateCredit(v *validator.Validator, credit *Credit) {
+	v.Check(credit.PersonID != "", "person_id", "must be provided")
+
+	v.Check(credit.Role != "", "role", "must be provided")
+	v.Check(credit.Role == "" || validator.In(credit.Role, CreditRoles...), "role", "invalid role value")
+
+	v.Check(credit.Character == "" || credit.Role == "cast", "character", "must only be provided for cast credits")
+	v.Check(len(credit.Character) <= 500, "character", "must not be more than 500 bytes long")
+
+	v.Check(credit.Billing >= 0, "billing", "must not be negative")
+}
+
+// roleOrder returns the position of role in CreditRoles
+func roleOrder(role string) int {
+	for i, r := range CreditRoles {
+		if r == role {
+			return i
+		}
+	}
+	return len(CreditRoles)
+}
+
+// CreditModel struct type wraps the credits collection & the collections credits link
+type CreditModel struct {
+	Collection *mongo.Collection
+	People     *mongo.Collection
+	Movies     *mongo.Collection
+}
+
+// Insert method for creating a new record
+func (m CreditModel) Insert(credit *Credit) (string, error) {
+	oid := primitive.NewObjectID()
+
+	credit.OID = oid
+	credit.ID = oid.Hex()
+	credit.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.Collection.InsertOne(ctx, credit)
+	if err != nil {
+		return "", err
+	}
+
+	return credit.ID, nil
+}
+
+// Delete method for deleting a specific credit of a movie
+func (m CreditModel) Delete(movieID, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := m.Collection.DeleteOne(ctx, bson.M{"_id": oid, "movie_id": movieID})
+	if err != nil {
+		return err
+	}
+
+	if res.DeletedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteAllForPerson method removes all credits of a person
+func (m CreditModel) DeleteAllForPerson(personID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.Collection.DeleteMany(ctx, bson.M{"person_id": personID})
+	return err
+}
+
+// GetForMovie method lists the credits of a movie with their person, ordered by role & billing
+func (m CreditModel) GetForMovie(movieID string) ([]*Credit, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"movie_id": movieID}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         m.People.Name(),
+			"localField":   "person_id",
+			"foreignField": "id",
+			"as":           "person",
+		}}},
+		{{Key: "$unwind", Value: "$person"}},
+		{{Key: "$addFields", Value: bson.M{"role_order": bson.M{"$indexOfArray": bson.A{CreditRoles, "$role"}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "role_order", Value: 1}, {Key: "billing", Value: 1}, {Key: "_id", Value: 1}}}},
+	}
+
+	return m.aggregate(pipeline)
+}
+
+// GetForPerson method lists the credits of a person with their movie, newest movies first. Movies in the trash are left out
+func (m CreditModel) GetForPerson(personID string) ([]*Credit, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"person_id": personID}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         m.Movies.Name(),
+			"localField":   "movie_id",
+			"foreignField": "id",
+			"as":           "movie",
+		}}},
+		{{Key: "$unwind", Value: "$movie"}},
+		{{Key: "$match", Value: bson.M{"movie.deleted_at": bson.M{"$exists": false}}}},
+		{{Key: "$addFields", Value: bson.M{"role_order": bson.M{"$indexOfArray": bson.A{CreditRoles, "$role"}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "movie.year", Value: -1}, {Key: "movie_id", Value: 1}, {Key: "role_order", Value: 1}, {Key: "_id", Value: 1}}}},
+	}
+
+	return m.aggregate(pipeline)
+}
+
+func (m CreditModel) aggregate(pipeline mongo.Pipeline) ([]*Credit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	credits := []*Credit{}
+	err = cursor.All(ctx, &credits)
+	if err != nil {
+		return nil, err
+	}
+
+	return credits, nil
+}
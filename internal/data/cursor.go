@@ -0,0 +1,100 @@
+package data
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidCursor error if a cursor can't be decoded or its signature doesn't match
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor marks the last record of a page for keyset pagination, holds the sort field value & the id tiebreaker
+type cursor struct {
+	Sort string  `json:"s"`
+	Str  string  `json:"t,omitempty"`
+	Num  float64 `json:"n,omitempty"`
+	ID   string  `json:"i"`
+}
+
+// newCursor returns the cursor positioned on movie for the given SortSafelist value
+func newCursor(sort string, movie *Movie) cursor {
+	c := cursor{Sort: sort, ID: movie.ID}
+
+	switch strings.TrimPrefix(sort, "-") {
+	case "title":
+		c.Str = movie.Title
+	case "year":
+		c.Num = float64(movie.Year)
+	case "runtime":
+		c.Num = float64(movie.Runtime)
+	case "rating":
+		c.Num = movie.Rating
+	case SortRelevance:
+		c.Num = movie.Score
+	}
+
+	return c
+}
+
+// value returns the sort field value held by the cursor
+func (c cursor) value() interface{} {
+	switch strings.TrimPrefix(c.Sort, "-") {
+	case "title":
+		return c.Str
+	case "rating", SortRelevance:
+		return c.Num
+	default:
+		return int64(c.Num)
+	}
+}
+
+// encode returns the opaque cursor string: base64 JSON payload & its HMAC-SHA256 signature
+func (c cursor) encode(secret []byte) string {
+	payload, _ := json.Marshal(c)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// decodeCursor verifies the signature of an opaque cursor string & decodes it
+func decodeCursor(secret []byte, s string) (cursor, error) {
+	var c cursor
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 2 {
+		return c, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return c, ErrInvalidCursor
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+
+	err = dec.Decode(&c)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	secret := []byte("cursor-secret")
+	movie := &Movie{ID: "61b0c1f2a3b4c5d6e7f80912", Title: "Heat", Year: 1995, Runtime: 170, Rating: 8.5, Score: 1.25}
+
+	tests := []struct {
+		sort  string
+		value interface{}
+	}{
+		{"id", int64(0)},
+		{"title", "Heat"},
+		{"-title", "Heat"},
+		{"year", int64(1995)},
+		{"-year", int64(1995)},
+		{"runtime", int64(170)},
+		{"rating", 8.5},
+		{"-rating", 8.5},
+		{SortRelevance, 1.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			encoded := newCursor(tt.sort, movie).encode(secret)
+
+			c, err := decodeCursor(secret, encoded)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+
+			if c.Sort != tt.sort || c.ID != movie.ID {
+				t.Errorf("decodeCursor() = %+v, want sort %q & id %q", c, tt.sort, movie.ID)
+			}
+
+			if got := c.value(); got != tt.value {
+				t.Errorf("value() = %#v, want %#v", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	secret := []byte("cursor-secret")
+	valid := newCursor("year", &Movie{ID: "61b0c1f2a3b4c5d6e7f80912", Year: 1995}).encode(secret)
+	parts := strings.Split(valid, ".")
+
+	// sign returns a correctly signed cursor string for an arbitrary payload
+	sign := func(payload string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(payload))
+		return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	tampered, _ := json.Marshal(cursor{Sort: "year", Num: 2000, ID: "61b0c1f2a3b4c5d6e7f80912"})
+
+	tests := []struct {
+		name   string
+		secret []byte
+		cursor string
+	}{
+		{"empty", secret, ""},
+		{"no signature", secret, parts[0]},
+		{"too many parts", secret, valid + "." + parts[1]},
+		{"payload not base64", secret, "!!!." + parts[1]},
+		{"signature not base64", secret, parts[0] + ".!!!"},
+		{"other secret", []byte("other-secret"), valid},
+		{"changed payload", secret, base64.RawURLEncoding.EncodeToString(tampered) + "." + parts[1]},
+		{"changed signature", secret, parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte("signature"))},
+		{"payload not JSON", secret, sign("not json")},
+		{"unknown field", secret, sign(`{"s":"year","n":1995,"i":"61b0c1f2a3b4c5d6e7f80912","x":1}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.secret, tt.cursor)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestValidateFiltersCursor(t *testing.T) {
+	secret := []byte("cursor-secret")
+	yearCursor := newCursor("year", &Movie{ID: "61b0c1f2a3b4c5d6e7f80912", Year: 1995}).encode(secret)
+
+	tests := []struct {
+		name    string
+		filters Filters
+		wantErr string
+	}{
+		{"valid", Filters{Sort: "year", Cursor: yearCursor}, ""},
+		{"other sort", Filters{Sort: "-year", Cursor: yearCursor}, "was issued for a different sort value"},
+		{"other secret", Filters{Sort: "year", Cursor: yearCursor, CursorSecret: []byte("other-secret")}, "is invalid or has been tampered with"},
+		{"with page", Filters{Page: 2, Sort: "year", Cursor: yearCursor}, "must not be combined with page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.filters
+			f.SortSafelist = []string{"year", "-year"}
+			if f.CursorSecret == nil {
+				f.CursorSecret = secret
+			}
+
+			v := validator.New()
+			ValidateFilters(v, f)
+
+			if got := v.Errors["cursor"]; got != tt.wantErr {
+				t.Errorf("cursor error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFiltersNextCursor(t *testing.T) {
+	secret := []byte("cursor-secret")
+	results := []*Movie{{ID: "a", Year: 1990}, {ID: "b", Year: 1995}, {ID: "c", Year: 2000}}
+
+	tests := []struct {
+		name    string
+		filters Filters
+		wantID  string
+	}{
+		{"more results", Filters{PageSize: 2, Sort: "year", CursorSecret: secret}, "b"},
+		{"last page", Filters{PageSize: 3, Sort: "year", CursorSecret: secret}, ""},
+		{"page numbers", Filters{Page: 1, PageSize: 2, Sort: "year", CursorSecret: secret}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := tt.filters.nextCursor(results)
+			if tt.wantID == "" {
+				if next != "" {
+					t.Errorf("nextCursor() = %q, want none", next)
+				}
+				return
+			}
+
+			c, err := decodeCursor(secret, next)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if c.ID != tt.wantID || c.Sort != tt.filters.Sort {
+				t.Errorf("nextCursor() positioned on %+v, want id %q", c, tt.wantID)
+			}
+		})
+	}
+}
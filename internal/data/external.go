@@ -0,0 +1,128 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicateExternalID error if an external ID is already used by another movie
+var ErrDuplicateExternalID = errors.New("duplicate external id")
+
+// Formats of the external IDs, TMDb IDs are positive integers
+var (
+	imdbRX     = regexp.MustCompile(`^tt\d{7,8}$`)
+	wikidataRX = regexp.MustCompile(`^Q[1-9]\d*$`)
+)
+
+// ExternalIDs struct holds the identifiers of a movie in other databases, every one of them is optional & unique
+type ExternalIDs struct {
+	IMDb     string `json:"imdb,omitempty" bson:"imdb,omitempty"`
+	TMDb     int64  `json:"tmdb,omitempty" bson:"tmdb,omitempty"`
+	Wikidata string `json:"wikidata,omitempty" bson:"wikidata,omitempty"`
+}
+
+// Empty reports if none of the IDs are set
+func (e *ExternalIDs) Empty() bool {
+	return e == nil || *e == ExternalIDs{}
+}
+
+// ValidateExternalIDs checks the format of the IDs which are set
+func ValidateExternalIDs(v *validator.Validator, ids *ExternalIDs) {
+	if ids == nil {
+		return
+	}
+
+	v.Check(ids.IMDb == "" || validator.Matches(ids.IMDb, imdbRX), "external_ids.imdb", "must be an IMDb title ID like tt0113277")
+	v.Check(ids.TMDb >= 0, "external_ids.tmdb", "must be a positive integer")
+	v.Check(ids.Wikidata == "" || validator.Matches(ids.Wikidata, wikidataRX), "external_ids.wikidata", "must be a Wikidata item ID like Q23")
+}
+
+// filter returns the conditions matching any of the IDs which are set
+func (e ExternalIDs) filter() bson.A {
+	conditions := bson.A{}
+	if e.IMDb != "" {
+		conditions = append(conditions, bson.M{"external_ids.imdb": e.IMDb})
+	}
+	if e.TMDb != 0 {
+		conditions = append(conditions, bson.M{"external_ids.tmdb": e.TMDb})
+	}
+	if e.Wikidata != "" {
+		conditions = append(conditions, bson.M{"external_ids.wikidata": e.Wikidata})
+	}
+	return conditions
+}
+
+// shares reports if any ID set in e is also set to the same value in other
+func (e ExternalIDs) shares(other *ExternalIDs) bool {
+	if other == nil {
+		return false
+	}
+	return (e.IMDb != "" && e.IMDb == other.IMDb) ||
+		(e.TMDb != 0 && e.TMDb == other.TMDb) ||
+		(e.Wikidata != "" && e.Wikidata == other.Wikidata)
+}
+
+func equalExternalIDs(a, b *ExternalIDs) bool {
+	if a.Empty() || b.Empty() {
+		return a.Empty() && b.Empty()
+	}
+	return *a == *b
+}
+
+// GetByExternalID method fetches the movie with any of the set IDs, movies in the trash are left out
+func (m MovieModel) GetByExternalID(ids ExternalIDs) (*Movie, error) {
+	if ids.Empty() {
+		return nil, ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var movie Movie
+	err := m.Collection.FindOne(ctx, bson.D{{Key: "$or", Value: ids.filter()}, notDeleted}).Decode(&movie)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &movie, nil
+}
+
+// FindDuplicates method lists the movies which are likely the same as a new one, with the same normalized title & a year at most one apart
+func (m MovieModel) FindDuplicates(title string, year int32) ([]*Movie, error) {
+	filter := bson.D{
+		{Key: "title_key", Value: NormalizeTitle(title)},
+		{Key: "year", Value: bson.M{"$gte": year - 1, "$lte": year + 1}},
+		notDeleted,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetProjection(movieProjection([]string{"title", "year"})).SetSort(bson.M{"_id": 1}).SetLimit(10)
+
+	cursor, err := m.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	movies := []*Movie{}
+	err = cursor.All(ctx, &movies)
+	if err != nil {
+		return nil, err
+	}
+
+	return movies, nil
+}
@@ -0,0 +1,212 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FacetSafelist holds the supported facet names
+var FacetSafelist = []string{"genres", "decade", "runtime"}
+
+// runtimeBuckets holds the lower bounds of the runtime facet buckets in minutes, the last bucket is open-ended
+var runtimeBuckets = []int32{0, 90, 120, 150, 180}
+
+// FacetCount struct holds the number of matching records sharing a facet value
+type FacetCount struct {
+	Value interface{} `json:"value"`
+	Count int64       `json:"count"`
+}
+
+// Facets maps facet names to their counts
+type Facets map[string][]FacetCount
+
+// ValidateFacets checks the requested facet names
+func ValidateFacets(v *validator.Validator, facets []string) {
+	v.Check(validator.AllIn(facets, FacetSafelist...), "facets", "invalid facet value")
+	v.Check(validator.Unique(facets), "facets", "must not contain duplicate values")
+}
+
+// runtimeBucket returns the index of the bucket runtime falls into
+func runtimeBucket(runtime Runtime) int {
+	i := sort.Search(len(runtimeBuckets), func(i int) bool {
+		return runtimeBuckets[i] > int32(runtime)
+	})
+	if i == 0 {
+		return 0
+	}
+	return i - 1
+}
+
+// runtimeBucketLabel returns the facet value of a runtime bucket, e.g. "90-119 mins"
+func runtimeBucketLabel(i int) string {
+	if i == len(runtimeBuckets)-1 {
+		return fmt.Sprintf("%d+ mins", runtimeBuckets[i])
+	}
+	return fmt.Sprintf("%d-%d mins", runtimeBuckets[i], runtimeBuckets[i+1]-1)
+}
+
+// facetStages returns the $facet sub-pipeline computing a single facet
+func facetStages(name string) mongo.Pipeline {
+	switch name {
+	case "genres":
+		return mongo.Pipeline{
+			{{Key: "$unwind", Value: "$genres"}},
+			{{Key: "$group", Value: bson.M{"_id": "$genres", "count": bson.M{"$sum": 1}}}},
+			{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		}
+	case "decade":
+		return mongo.Pipeline{
+			{{Key: "$group", Value: bson.M{
+				"_id":   bson.M{"$subtract": bson.A{"$year", bson.M{"$mod": bson.A{"$year", 10}}}},
+				"count": bson.M{"$sum": 1},
+			}}},
+			{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		}
+	default:
+		boundaries := bson.A{}
+		for _, bound := range runtimeBuckets {
+			boundaries = append(boundaries, bound)
+		}
+		// Runtimes from the last bound upwards end up in the default bucket, which is the open-ended one
+		return mongo.Pipeline{
+			{{Key: "$bucket", Value: bson.M{
+				"groupBy":    "$runtime",
+				"boundaries": boundaries,
+				"default":    runtimeBuckets[len(runtimeBuckets)-1],
+				"output":     bson.M{"count": bson.M{"$sum": 1}},
+			}}},
+		}
+	}
+}
+
+// Facets method counts all records matching query by the requested facets, the counts don't depend on pagination
+func (m MovieModel) Facets(query MovieQuery, names []string) (Facets, error) {
+	facets := Facets{}
+	if len(names) == 0 {
+		return facets, nil
+	}
+
+	stages := bson.M{}
+	for _, name := range names {
+		stages[name] = facetStages(name)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: append(query.filter(), notDeleted)}},
+		{{Key: "$facet", Value: stages}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []map[string][]struct {
+		ID    interface{} `bson:"_id"`
+		Count int64       `bson:"count"`
+	}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		counts := []FacetCount{}
+
+		if len(results) != 0 {
+			for _, group := range results[0][name] {
+				value := group.ID
+
+				// Buckets are identified by their lower bound
+				if name == "runtime" {
+					var bound int32
+					switch n := value.(type) {
+					case int32:
+						bound = n
+					case int64:
+						bound = int32(n)
+					case float64:
+						bound = int32(n)
+					}
+					value = runtimeBucketLabel(runtimeBucket(Runtime(bound)))
+				}
+
+				counts = append(counts, FacetCount{Value: value, Count: group.Count})
+			}
+		}
+
+		facets[name] = counts
+	}
+
+	return facets, nil
+}
+
+// countFacets computes facet counts of already matched movies in the order of the aggregation, used by the in-memory store
+func countFacets(movies []*Movie, names []string) Facets {
+	facets := Facets{}
+
+	for _, name := range names {
+		counts := []FacetCount{}
+
+		switch name {
+		case "genres":
+			byGenre := make(map[string]int64)
+			for _, movie := range movies {
+				for _, genre := range movie.Genres {
+					byGenre[genre]++
+				}
+			}
+
+			for genre, count := range byGenre {
+				counts = append(counts, FacetCount{Value: genre, Count: count})
+			}
+
+			sort.Slice(counts, func(i, j int) bool {
+				if counts[i].Count != counts[j].Count {
+					return counts[i].Count > counts[j].Count
+				}
+				return counts[i].Value.(string) < counts[j].Value.(string)
+			})
+
+		case "decade":
+			byDecade := make(map[int32]int64)
+			for _, movie := range movies {
+				byDecade[movie.Year-movie.Year%10]++
+			}
+
+			for decade, count := range byDecade {
+				counts = append(counts, FacetCount{Value: decade, Count: count})
+			}
+
+			sort.Slice(counts, func(i, j int) bool {
+				return counts[i].Value.(int32) < counts[j].Value.(int32)
+			})
+
+		case "runtime":
+			byBucket := make([]int64, len(runtimeBuckets))
+			for _, movie := range movies {
+				byBucket[runtimeBucket(movie.Runtime)]++
+			}
+
+			for i, count := range byBucket {
+				if count != 0 {
+					counts = append(counts, FacetCount{Value: runtimeBucketLabel(i), Count: count})
+				}
+			}
+		}
+
+		facets[name] = counts
+	}
+
+	return facets
+}
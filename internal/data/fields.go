@@ -0,0 +1,97 @@
+package data
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// movieFieldKeys maps the selectable JSON fields of a movie to the document fields they are read from
+var movieFieldKeys = map[string]string{
+	"id":                "id",
+	"title":             "title",
+	"titles":            "titles",
+	"original_language": "original_language",
+	"year":              "year",
+	"runtime":           "runtime",
+	"genres":            "genres",
+	"external_ids":      "external_ids",
+	"rating":            "rating",
+	"rating_count":      "rating_count",
+	"score":             "score",
+	"highlights":        "title",
+	"posters":           "poster_at",
+}
+
+// movieProjection returns the MongoDB projection fetching the selected fields plus the ones needed internally, nil selects all fields.
+// id & version are always fetched, the latter for the ETag
+func movieProjection(fields []string, extra ...string) bson.M {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	projection := bson.M{"id": 1, "version": 1}
+	for _, field := range fields {
+		// The text score isn't a stored field, it's added by find if there is a $text search
+		if key := movieFieldKeys[field]; key != "" && key != "score" {
+			projection[key] = 1
+		}
+	}
+	for _, key := range extra {
+		if key != "_id" && key != "score" {
+			projection[key] = 1
+		}
+	}
+
+	return projection
+}
+
+// trimFields clears all fields of movie which weren't selected, the id is always kept & nothing is cleared if fields is empty
+func trimFields(movie *Movie, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+
+	selected := make(map[string]bool)
+	for _, field := range fields {
+		selected[field] = true
+	}
+
+	trimmed := Movie{OID: movie.OID, ID: movie.ID, Version: movie.Version}
+	if selected["title"] {
+		trimmed.Title = movie.Title
+	}
+	if selected["titles"] {
+		trimmed.Titles = movie.Titles
+	}
+	if selected["original_language"] {
+		trimmed.OriginalLanguage = movie.OriginalLanguage
+	}
+	if selected["year"] {
+		trimmed.Year = movie.Year
+	}
+	if selected["runtime"] {
+		trimmed.Runtime = movie.Runtime
+	}
+	if selected["genres"] {
+		trimmed.Genres = movie.Genres
+	}
+	if selected["external_ids"] {
+		trimmed.ExternalIDs = movie.ExternalIDs
+	}
+	if selected["rating"] {
+		trimmed.Rating = movie.Rating
+	}
+	if selected["rating_count"] {
+		trimmed.RatingCount = movie.RatingCount
+	}
+	if selected["score"] {
+		trimmed.Score = movie.Score
+	}
+	if selected["highlights"] {
+		trimmed.Highlights = movie.Highlights
+	}
+	if selected["posters"] {
+		trimmed.PosterAt = movie.PosterAt
+	}
+
+	*movie = trimmed
+}
@@ -10,21 +10,39 @@ type Filters struct {
 	PageSize     int
 	Sort         string
 	SortSafelist []string
+	Cursor       string
+	CursorSecret []byte
+	// Fields selects the returned movie fields, all fields are returned if empty
+	Fields []string
 }
 
 // Metadata holds pagination info
 type Metadata struct {
-	CurrentPage  int `json:"current_page,omitempty"`
-	PageSize     int `json:"page_size,omitempty"`
-	TotalRecords int `json:"total_records,omitempty"`
+	CurrentPage  int    `json:"current_page,omitempty"`
+	PageSize     int    `json:"page_size,omitempty"`
+	FirstPage    int    `json:"first_page,omitempty"`
+	LastPage     int    `json:"last_page,omitempty"`
+	TotalRecords int    `json:"total_records"`
+	HasNext      bool   `json:"has_next"`
+	NextCursor   string `json:"next_cursor,omitempty"`
 }
 
 // ValidateFilters validates query values
 func ValidateFilters(v *validator.Validator, f Filters) {
+	v.Check(f.Page >= 0, "page", "must not be negative")
 	v.Check(f.Page <= 10_000_000, "page", "must be a maximum of 10 million")
+	v.Check(f.PageSize >= 0, "page_size", "must not be negative")
 	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
 
 	v.Check(validator.In(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+
+	if f.Cursor != "" {
+		v.Check(f.Page == 0, "cursor", "must not be combined with page")
+
+		c, err := decodeCursor(f.CursorSecret, f.Cursor)
+		v.Check(err == nil, "cursor", "is invalid or has been tampered with")
+		v.Check(err != nil || c.Sort == f.Sort, "cursor", "was issued for a different sort value")
+	}
 }
 
 func (f Filters) limit() int {
@@ -38,14 +56,58 @@ func (f Filters) offset() int {
 	return (f.Page - 1) * f.PageSize
 }
 
+// keyset reports if results are paged with cursors instead of page numbers
+func (f Filters) keyset() bool {
+	return f.Page == 0
+}
+
+// after returns the decoded cursor the page starts after, nil for the first page
+func (f Filters) after() *cursor {
+	if f.Cursor == "" {
+		return nil
+	}
+
+	c, err := decodeCursor(f.CursorSecret, f.Cursor)
+	if err != nil {
+		return nil
+	}
+
+	return &c
+}
+
+// nextCursor returns the cursor for the page following results if there is one, results must hold one more record than the page size
+func (f Filters) nextCursor(results []*Movie) string {
+	if !f.keyset() || f.limit() == 0 || len(results) <= f.limit() {
+		return ""
+	}
+
+	return newCursor(f.Sort, results[f.limit()-1]).encode(f.CursorSecret)
+}
+
 func calculateMetadata(totalRecords, page, pageSize int) Metadata {
 	if totalRecords == 0 {
 		return Metadata{}
 	}
 
+	// Keyset pages have no page number, HasNext is set once the next cursor is known
+	if page == 0 {
+		return Metadata{
+			PageSize:     pageSize,
+			TotalRecords: totalRecords,
+		}
+	}
+
+	lastPage := 1
+	if pageSize != 0 {
+		lastPage = (totalRecords + pageSize - 1) / pageSize
+	}
+
 	return Metadata{
 		CurrentPage:  page,
 		PageSize:     pageSize,
+		FirstPage:    1,
+		LastPage:     lastPage,
 		TotalRecords: totalRecords,
+		HasNext:      page < lastPage,
 	}
 }
@@ -0,0 +1,109 @@
+package data
+
+import (
+	"sort"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+	"golang.org/x/text/language"
+)
+
+// maxTitles is the largest number of localized titles of a movie
+const maxTitles = 100
+
+// ValidLanguage reports if tag is a language tag in canonical form like de, pt-BR or zh-Hant
+func ValidLanguage(tag string) bool {
+	t, err := language.Parse(tag)
+	return err == nil && t != language.Und && t.String() == tag
+}
+
+// ValidateTitles checks the localized titles & the original language of a movie
+func ValidateTitles(v *validator.Validator, titles map[string]string, originalLanguage string) {
+	v.Check(len(titles) <= maxTitles, "titles", "must not contain more than 100 titles")
+
+	for tag, title := range titles {
+		if !ValidLanguage(tag) {
+			v.AddError("titles", "must only have language tags like de or pt-BR as keys")
+			continue
+		}
+
+		v.Check(title != "", "titles."+tag, "must not be empty")
+		v.Check(len(title) <= 500, "titles."+tag, "must not be more than 500 bytes long")
+	}
+
+	v.Check(originalLanguage == "" || ValidLanguage(originalLanguage), "original_language", "must be a language tag like ja or pt-BR")
+}
+
+// altTitles returns the localized titles ordered by language, stored as alt_titles for the text index
+func altTitles(titles map[string]string) []string {
+	tags := make([]string, 0, len(titles))
+	for tag := range titles {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	var alt []string
+	for _, tag := range tags {
+		alt = append(alt, titles[tag])
+	}
+
+	return alt
+}
+
+func equalTitles(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for tag, title := range a {
+		if other, ok := b[tag]; !ok || other != title {
+			return false
+		}
+	}
+	return true
+}
+
+// Localize sets the title of movie to the one best matching the preferred languages & returns its language, the original title
+// is kept as OriginalTitle. For every preferred language the title of the language itself or of its parents is used, e.g. zh-Hant
+// for zh-Hant-TW & de for de-AT. If none matches, a title of the same base language is used, e.g. pt-BR for pt. The original title
+// is the final fallback & counts as a title in the original language
+func (movie *Movie) Localize(prefs []language.Tag) string {
+	titles := make(map[string]string, len(movie.Titles)+1)
+	for tag, title := range movie.Titles {
+		titles[tag] = title
+	}
+	if movie.OriginalLanguage != "" {
+		titles[movie.OriginalLanguage] = movie.Title
+	}
+
+	pick := func(tag string) string {
+		if title := titles[tag]; title != movie.Title {
+			movie.OriginalTitle = movie.Title
+			movie.Title = title
+		}
+		return tag
+	}
+
+	for _, pref := range prefs {
+		for t := pref; t != language.Und; t = t.Parent() {
+			if _, ok := titles[t.String()]; ok {
+				return pick(t.String())
+			}
+		}
+	}
+
+	tags := make([]string, 0, len(titles))
+	for tag := range titles {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	for _, pref := range prefs {
+		base, _ := pref.Base()
+		for _, tag := range tags {
+			if b, _ := language.Make(tag).Base(); b == base {
+				return pick(tag)
+			}
+		}
+	}
+
+	return movie.OriginalLanguage
+}
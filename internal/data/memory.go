@@ -0,0 +1,1843 @@
+package data
+
+import (
+	"crypto/sha256"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryMovieModel keeps movies in a map guarded by a mutex, mirrors the rules of MovieModel
+type memoryMovieModel struct {
+	mu        sync.RWMutex
+	movies    map[string]*Movie
+	revisions *memoryRevisionModel
+}
+
+func newMemoryMovieModel(revisions *memoryRevisionModel) *memoryMovieModel {
+	return &memoryMovieModel{movies: make(map[string]*Movie), revisions: revisions}
+}
+
+// copyMovie returns a deep copy so callers never share state with the store
+func copyMovie(movie *Movie) *Movie {
+	c := *movie
+	if movie.Genres != nil {
+		c.Genres = append([]string{}, movie.Genres...)
+	}
+	if movie.DeletedAt != nil {
+		deletedAt := *movie.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	if movie.ExternalIDs != nil {
+		ids := *movie.ExternalIDs
+		c.ExternalIDs = &ids
+	}
+	if movie.Titles != nil {
+		c.Titles = make(map[string]string, len(movie.Titles))
+		for tag, title := range movie.Titles {
+			c.Titles[tag] = title
+		}
+	}
+	return &c
+}
+
+// usesExternalIDs reports if a movie other than id, including ones in the trash, has any of the IDs like the unique indexes do
+func (m *memoryMovieModel) usesExternalIDs(ids *ExternalIDs, id string) bool {
+	if ids.Empty() {
+		return false
+	}
+
+	for _, movie := range m.movies {
+		if movie.ID != id && ids.shares(movie.ExternalIDs) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Insert method for creating a new record along with its first revision
+func (m *memoryMovieModel) Insert(movie *Movie) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	args, err := m.newRecord(movie, nil)
+	if err != nil {
+		return "", err
+	}
+
+	m.movies[args.ID] = args
+	m.revisions.add(NewRevision(args, RevisionInsert, args.CreatedBy))
+
+	movie.Version = args.Version
+	return args.ID, nil
+}
+
+// InsertMany method for creating several records along with their first revisions, sets ID & Version of every movie. Either all
+// movies are created or none
+func (m *memoryMovieModel) InsertMany(movies []*Movie) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := make([]*Movie, 0, len(movies))
+	for _, movie := range movies {
+		args, err := m.newRecord(movie, records)
+		if err != nil {
+			return err
+		}
+		records = append(records, args)
+	}
+
+	for i, args := range records {
+		m.movies[args.ID] = args
+		m.revisions.add(NewRevision(args, RevisionInsert, args.CreatedBy))
+
+		movies[i].ID = args.ID
+		movies[i].Version = args.Version
+	}
+
+	return nil
+}
+
+// newRecord returns the record for a new movie, fails with ErrDuplicateExternalID if a stored movie or one of batch uses its IDs
+func (m *memoryMovieModel) newRecord(movie *Movie, batch []*Movie) (*Movie, error) {
+	oid := primitive.NewObjectID()
+
+	args := copyMovie(movie)
+	args.OID = oid
+	args.ID = oid.Hex()
+	args.CreatedAt = time.Now()
+	args.Version = 1
+	if args.ExternalIDs.Empty() {
+		args.ExternalIDs = nil
+	}
+
+	if m.usesExternalIDs(args.ExternalIDs, args.ID) {
+		return nil, ErrDuplicateExternalID
+	}
+
+	for _, other := range batch {
+		if !args.ExternalIDs.Empty() && args.ExternalIDs.shares(other.ExternalIDs) {
+			return nil, ErrDuplicateExternalID
+		}
+	}
+
+	return args, nil
+}
+
+// Get method for fetching a specific record
+func (m *memoryMovieModel) Get(id string, fields []string) (*Movie, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movie, ok := m.movies[id]
+	if !ok || movie.DeletedAt != nil {
+		return nil, ErrRecordNotFound
+	}
+
+	result := copyMovie(movie)
+	trimFields(result, fields)
+
+	return result, nil
+}
+
+// GetByTitle method for fetching a record by its exact title & release year
+func (m *memoryMovieModel) GetByTitle(title string, year int32) (*Movie, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, movie := range m.movies {
+		if movie.Title == title && movie.Year == year && movie.DeletedAt == nil {
+			return copyMovie(movie), nil
+		}
+	}
+
+	return nil, ErrRecordNotFound
+}
+
+// GetByExternalID method fetches the movie with any of the set IDs
+func (m *memoryMovieModel) GetByExternalID(ids ExternalIDs) (*Movie, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, movie := range m.movies {
+		if movie.DeletedAt == nil && ids.shares(movie.ExternalIDs) {
+			return copyMovie(movie), nil
+		}
+	}
+
+	return nil, ErrRecordNotFound
+}
+
+// GetMergedInto method returns the ID of the movie a merged movie redirects to
+func (m *memoryMovieModel) GetMergedInto(id string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movie, ok := m.movies[id]
+	if !ok || movie.MergedInto == "" {
+		return "", ErrRecordNotFound
+	}
+
+	return movie.MergedInto, nil
+}
+
+// FindDuplicates method lists the movies with the same normalized title & a year at most one apart
+func (m *memoryMovieModel) FindDuplicates(title string, year int32) ([]*Movie, error) {
+	key := NormalizeTitle(title)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movies := []*Movie{}
+	for _, movie := range m.movies {
+		if movie.DeletedAt == nil && NormalizeTitle(movie.Title) == key && movie.Year >= year-1 && movie.Year <= year+1 {
+			movies = append(movies, &Movie{ID: movie.ID, Title: movie.Title, Year: movie.Year})
+		}
+	}
+
+	sort.Slice(movies, func(i, j int) bool {
+		return movies[i].ID < movies[j].ID
+	})
+	if len(movies) > 10 {
+		movies = movies[:10]
+	}
+
+	return movies, nil
+}
+
+// Patch method applies targeted changes to a specific record & records change as a revision
+func (m *memoryMovieModel) Patch(movie *Movie, update MovieUpdate, change Change) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.movies[movie.ID]
+	if !ok || existing.Version != movie.Version || existing.DeletedAt != nil {
+		return ErrEditConflict
+	}
+
+	if m.usesExternalIDs(update.ExternalIDs, movie.ID) {
+		return ErrDuplicateExternalID
+	}
+
+	updated := copyMovie(existing)
+	update.apply(updated)
+	updated.Version++
+
+	m.movies[movie.ID] = updated
+	m.revisions.add(change.revision(updated))
+
+	update.apply(movie)
+	movie.Version = updated.Version
+	return nil
+}
+
+// Delete method moves a specific record to the trash along with a revision
+func (m *memoryMovieModel) Delete(id, userID string) (*Movie, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.movies[id]
+	if !ok || existing.DeletedAt != nil {
+		return nil, ErrRecordNotFound
+	}
+
+	now := time.Now()
+
+	deleted := copyMovie(existing)
+	deleted.DeletedAt = &now
+	deleted.DeletedBy = userID
+	deleted.Version++
+
+	m.movies[id] = deleted
+	m.revisions.add(NewRevision(deleted, RevisionDelete, userID))
+
+	return copyMovie(deleted), nil
+}
+
+// Restore method moves a specific record out of the trash along with a revision, merged records can't be restored
+func (m *memoryMovieModel) Restore(id, userID string) (*Movie, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.movies[id]
+	if !ok || existing.DeletedAt == nil || existing.MergedInto != "" {
+		return nil, ErrRecordNotFound
+	}
+
+	restored := copyMovie(existing)
+	restored.DeletedAt = nil
+	restored.DeletedBy = ""
+	restored.Version++
+
+	m.movies[id] = restored
+	m.revisions.add(NewRevision(restored, RevisionRestore, userID))
+
+	return copyMovie(restored), nil
+}
+
+// GetAll method to list of all records
+func (m *memoryMovieModel) GetAll(query MovieQuery, filters Filters) ([]*Movie, Metadata, error) {
+	movies, metadata, err := m.find(queryMatch(query), query.textScore, filters)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	query.Highlight(movies...)
+	for _, movie := range movies {
+		trimFields(movie, filters.Fields)
+	}
+
+	return movies, metadata, nil
+}
+
+// Export method calls fn for every record matching query in sort order
+func (m *memoryMovieModel) Export(query MovieQuery, sort string, fn func(movie *Movie) error) error {
+	movies, _, err := m.find(queryMatch(query), nil, Filters{Sort: sort})
+	if err != nil {
+		return err
+	}
+
+	for _, movie := range movies {
+		err = fn(movie)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Facets method counts all records matching query by the requested facets
+func (m *memoryMovieModel) Facets(query MovieQuery, names []string) (Facets, error) {
+	movies, _, err := m.find(queryMatch(query), nil, Filters{})
+	if err != nil {
+		return nil, err
+	}
+
+	return countFacets(movies, names), nil
+}
+
+// Suggest method returns up to limit titles starting with q or containing a word starting with q
+func (m *memoryMovieModel) Suggest(q string, limit int) ([]*Movie, error) {
+	movies, _, err := m.find(queryMatch(MovieQuery{}), nil, Filters{})
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestMovies(movies, q, limit), nil
+}
+
+// AddPopularity method adds delta to the popularity of a specific record without changing its version
+func (m *memoryMovieModel) AddPopularity(id string, delta int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	movie, ok := m.movies[id]
+	if !ok {
+		return ErrRecordNotFound
+	}
+
+	updated := copyMovie(movie)
+	updated.Popularity += delta
+	m.movies[id] = updated
+
+	return nil
+}
+
+// queryMatch returns the predicate for GetAll & Export, deleted movies never match
+func queryMatch(query MovieQuery) func(movie *Movie) bool {
+	return func(movie *Movie) bool {
+		return movie.DeletedAt == nil && query.match(movie)
+	}
+}
+
+// GetTrash method to list records which were moved to the trash, merged records are left out
+func (m *memoryMovieModel) GetTrash(filters Filters) ([]*Movie, Metadata, error) {
+	return m.find(func(movie *Movie) bool {
+		return movie.DeletedAt != nil && movie.MergedInto == ""
+	}, nil, filters)
+}
+
+// find returns one page of the records matching the predicate along with the pagination metadata, score is optional & sets the text score
+func (m *memoryMovieModel) find(match func(movie *Movie) bool, score func(movie *Movie) float64, filters Filters) ([]*Movie, Metadata, error) {
+	m.mu.RLock()
+	var matches []*Movie
+	for _, movie := range m.movies {
+		if match(movie) {
+			c := copyMovie(movie)
+			if score != nil {
+				c.Score = score(movie)
+			}
+			matches = append(matches, c)
+		}
+	}
+	m.mu.RUnlock()
+
+	sortMovies(matches, filters.Sort)
+
+	// Apply skip & limit the same way the MongoDB find options do
+	start := filters.offset()
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := len(matches)
+	if filters.limit() != 0 && start+filters.limit() < end {
+		end = start + filters.limit()
+	}
+
+	metadata := calculateMetadata(len(matches), filters.Page, filters.PageSize)
+
+	if filters.keyset() {
+		// Continue after the cursor & keep one extra record to find out if there is a next page
+		if after := filters.after(); after != nil {
+			position := &Movie{ID: after.ID, Title: after.Str, Year: int32(after.Num), Runtime: Runtime(after.Num), Rating: after.Num, Score: after.Num}
+			start = sort.Search(len(matches), func(i int) bool {
+				return compareMovies(matches[i], position, filters.Sort) > 0
+			})
+		}
+
+		end = len(matches)
+		if filters.limit() != 0 && start+filters.limit()+1 < end {
+			end = start + filters.limit() + 1
+		}
+	}
+	results := matches[start:end]
+
+	metadata.NextCursor = filters.nextCursor(results)
+	if metadata.NextCursor != "" {
+		results = results[:filters.limit()]
+		metadata.HasNext = true
+	}
+
+	return results, metadata, nil
+}
+
+// sortMovies orders movies by a SortSafelist value, ties are broken by id like the MongoDB sort
+func sortMovies(movies []*Movie, sortValue string) {
+	sort.SliceStable(movies, func(i, j int) bool {
+		return compareMovies(movies[i], movies[j], sortValue) < 0
+	})
+}
+
+// compareMovies returns the relative order of a & b for a SortSafelist value
+func compareMovies(a, b *Movie, sortValue string) int {
+	var c int
+	switch strings.TrimPrefix(sortValue, "-") {
+	case "title":
+		c = strings.Compare(a.Title, b.Title)
+	case "year":
+		c = int(a.Year - b.Year)
+	case "runtime":
+		c = int(a.Runtime - b.Runtime)
+	case "rating":
+		c = compareFloats(a.Rating, b.Rating)
+	case SortRelevance:
+		c = compareFloats(a.Score, b.Score)
+	}
+
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+
+	// Relevance is always descending, best matches first
+	if strings.HasPrefix(sortValue, "-") || sortValue == SortRelevance {
+		return -c
+	}
+	return c
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// textMatch approximates a MongoDB $text search, true if any search term matches a word of the given fields
+func textMatch(search string, fields ...string) bool {
+	words := make(map[string]bool)
+	for _, field := range fields {
+		for _, word := range splitWords(field) {
+			words[stem(word)] = true
+		}
+	}
+
+	for _, term := range splitWords(search) {
+		if words[stem(term)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// stem strips simple plural suffixes so "dramas" matches "drama"
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	default:
+		return word
+	}
+}
+
+// memoryUserModel keeps users in a map guarded by a mutex, mirrors the rules of UserModel
+type memoryUserModel struct {
+	mu    sync.RWMutex
+	users map[string]*User
+}
+
+func newMemoryUserModel() *memoryUserModel {
+	return &memoryUserModel{users: make(map[string]*User)}
+}
+
+func copyUser(user *User) *User {
+	c := *user
+	c.Password.plaintext = nil
+	if user.Permissions != nil {
+		c.Permissions = append([]string{}, user.Permissions...)
+	}
+	return &c
+}
+
+// checkUnique enforces the case-insensitive unique name & email indexes, skipping the user with the given id
+func (m *memoryUserModel) checkUnique(user *User, id string) error {
+	for _, existing := range m.users {
+		if existing.ID == id {
+			continue
+		}
+		if strings.EqualFold(existing.Name, user.Name) {
+			return ErrDuplicateName
+		}
+		if strings.EqualFold(existing.Email, user.Email) {
+			return ErrDuplicateEmail
+		}
+	}
+	return nil
+}
+
+// Insert method to create a new user
+func (m *memoryUserModel) Insert(user *User) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := m.checkUnique(user, "")
+	if err != nil {
+		return "", err
+	}
+
+	oid := primitive.NewObjectID()
+
+	args := copyUser(user)
+	args.OID = oid
+	args.ID = oid.Hex()
+	args.CreatedAt = time.Now()
+	args.Version = 1
+
+	m.users[args.ID] = args
+	return args.ID, nil
+}
+
+// GetByEmail method to get details of specific user
+func (m *memoryUserModel) GetByEmail(email string) (*User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, user := range m.users {
+		if user.Email == email {
+			return copyUser(user), nil
+		}
+	}
+
+	return nil, ErrRecordNotFound
+}
+
+// Update method for editing user's details
+func (m *memoryUserModel) Update(user *User, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.users[id]
+	if !ok {
+		return ErrEditConflict
+	}
+
+	err := m.checkUnique(user, id)
+	if err != nil {
+		return ErrDuplicateEmail
+	}
+
+	updated := copyUser(existing)
+	updated.Name = user.Name
+	updated.Email = user.Email
+	updated.Password.Hash = user.Password.Hash
+	updated.Activated = user.Activated
+	updated.Version++
+
+	m.users[id] = updated
+	return nil
+}
+
+// GetForToken method for user details from token
+func (m *memoryUserModel) GetForToken(userID string) (*User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	return copyUser(user), nil
+}
+
+// GetPermissions for specific user
+func (m *memoryUserModel) GetPermissions(userID string) ([]string, error) {
+	user, err := m.GetForToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.Permissions, nil
+}
+
+// memoryTokenModel keeps tokens in a slice guarded by a mutex, expired tokens are never returned
+type memoryTokenModel struct {
+	mu     sync.Mutex
+	tokens []*Token
+}
+
+func newMemoryTokenModel() *memoryTokenModel {
+	return &memoryTokenModel{}
+}
+
+// New shortcut method to create a Token struct & add it to the store
+func (m *memoryTokenModel) New(userID string, ttl time.Duration, scope string) (*Token, error) {
+	token, err := generateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.Insert(token)
+	return token, err
+}
+
+// Insert adds specific token to the store
+func (m *memoryTokenModel) Insert(token *Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens = append(m.tokens, &Token{
+		OID:    primitive.NewObjectID(),
+		Hash:   append([]byte{}, token.Hash...),
+		UserID: token.UserID,
+		Expiry: token.Expiry,
+		Scope:  token.Scope,
+	})
+
+	return nil
+}
+
+// Get method for userID via token
+func (m *memoryTokenModel) Get(tokenScope, tokenPlaintext string) (string, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeExpired()
+
+	for _, token := range m.tokens {
+		if token.Scope == tokenScope && string(token.Hash) == string(tokenHash[:]) {
+			return token.UserID.Hex(), nil
+		}
+	}
+
+	return "", ErrRecordNotFound
+}
+
+// DeleteAllForUser removes all tokens for specific user & scope
+func (m *memoryTokenModel) DeleteAllForUser(scope, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeExpired()
+
+	kept := m.tokens[:0]
+	for _, token := range m.tokens {
+		if token.Scope != scope || token.UserID.Hex() != userID {
+			kept = append(kept, token)
+		}
+	}
+
+	deleted := len(m.tokens) - len(kept)
+	m.tokens = kept
+
+	if deleted == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// removeExpired stands in for the TTL index on expiry, caller must hold the mutex
+func (m *memoryTokenModel) removeExpired() {
+	now := time.Now()
+
+	kept := m.tokens[:0]
+	for _, token := range m.tokens {
+		if token.Expiry.After(now) {
+			kept = append(kept, token)
+		}
+	}
+
+	m.tokens = kept
+}
+
+// memoryRevisionModel keeps revisions in a slice guarded by a mutex, revisions are never changed once added
+type memoryRevisionModel struct {
+	mu        sync.RWMutex
+	revisions []*Revision
+}
+
+func newMemoryRevisionModel() *memoryRevisionModel {
+	return &memoryRevisionModel{}
+}
+
+func copyRevision(revision *Revision) *Revision {
+	c := *revision
+	c.Movie = *copyMovie(&revision.Movie)
+	return &c
+}
+
+// add stores a copy of revision, the movie stores call it while holding their own lock so the revision is added along with the change
+func (m *memoryRevisionModel) add(revision *Revision) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	revision.OID = primitive.NewObjectID()
+	m.revisions = append(m.revisions, copyRevision(revision))
+}
+
+// Get method for fetching a specific version of a movie
+func (m *memoryRevisionModel) Get(movieID string, version int32) (*Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, revision := range m.revisions {
+		if revision.MovieID == movieID && revision.Version == version {
+			return copyRevision(revision), nil
+		}
+	}
+
+	return nil, ErrRecordNotFound
+}
+
+// GetAll method to list all revisions of a movie, oldest first
+func (m *memoryRevisionModel) GetAll(movieID string) ([]*Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revisions := []*Revision{}
+	for _, revision := range m.revisions {
+		if revision.MovieID == movieID {
+			revisions = append(revisions, copyRevision(revision))
+		}
+	}
+
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].Version < revisions[j].Version
+	})
+
+	return revisions, nil
+}
+
+// memoryPersonModel keeps people in a map guarded by a mutex, mirrors the rules of PersonModel
+type memoryPersonModel struct {
+	mu     sync.RWMutex
+	people map[string]*Person
+}
+
+func newMemoryPersonModel() *memoryPersonModel {
+	return &memoryPersonModel{people: make(map[string]*Person)}
+}
+
+// Insert method for creating a new record
+func (m *memoryPersonModel) Insert(person *Person) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oid := primitive.NewObjectID()
+
+	person.OID = oid
+	person.ID = oid.Hex()
+	person.CreatedAt = time.Now()
+	person.NameKey = NormalizeTitle(person.Name)
+	person.Version = 1
+
+	c := *person
+	m.people[person.ID] = &c
+
+	return person.ID, nil
+}
+
+// Get method for fetching a specific record
+func (m *memoryPersonModel) Get(id string) (*Person, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	person, ok := m.people[id]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	c := *person
+	return &c, nil
+}
+
+// Update method for updating a specific record, fails with ErrEditConflict if it was changed since it was read
+func (m *memoryPersonModel) Update(person *Person) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.people[person.ID]
+	if !ok || existing.Version != person.Version {
+		return ErrEditConflict
+	}
+
+	person.NameKey = NormalizeTitle(person.Name)
+	person.Version++
+
+	c := *person
+	m.people[person.ID] = &c
+
+	return nil
+}
+
+// Delete method for deleting a specific record
+func (m *memoryPersonModel) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.people[id]; !ok {
+		return ErrRecordNotFound
+	}
+
+	delete(m.people, id)
+
+	return nil
+}
+
+// GetAll method to list people whose name starts with name, ordered by name or id
+func (m *memoryPersonModel) GetAll(name string, filters Filters) ([]*Person, Metadata, error) {
+	m.mu.RLock()
+	prefix := NormalizeTitle(name)
+	people := []*Person{}
+	for _, person := range m.people {
+		if strings.HasPrefix(person.NameKey, prefix) {
+			c := *person
+			people = append(people, &c)
+		}
+	}
+	m.mu.RUnlock()
+
+	field, direction := personSortField(filters.Sort)
+	sort.Slice(people, func(i, j int) bool {
+		a, b := people[i], people[j]
+		if direction < 0 {
+			a, b = b, a
+		}
+		if field == "name_key" && a.NameKey != b.NameKey {
+			return a.NameKey < b.NameKey
+		}
+		return a.ID < b.ID
+	})
+
+	metadata := calculateMetadata(len(people), filters.Page, filters.PageSize)
+
+	// Apply skip & limit the same way the MongoDB find options do
+	start := filters.offset()
+	if start > len(people) {
+		start = len(people)
+	}
+	end := len(people)
+	if filters.limit() != 0 && start+filters.limit() < end {
+		end = start + filters.limit()
+	}
+
+	return people[start:end], metadata, nil
+}
+
+// memoryCreditModel keeps credits in a slice guarded by a mutex, joins them with the in-memory people & movies
+type memoryCreditModel struct {
+	mu      sync.RWMutex
+	credits []*Credit
+	people  *memoryPersonModel
+	movies  *memoryMovieModel
+}
+
+func newMemoryCreditModel(people *memoryPersonModel, movies *memoryMovieModel) *memoryCreditModel {
+	return &memoryCreditModel{people: people, movies: movies}
+}
+
+// Insert method for creating a new record
+func (m *memoryCreditModel) Insert(credit *Credit) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oid := primitive.NewObjectID()
+
+	credit.OID = oid
+	credit.ID = oid.Hex()
+	credit.CreatedAt = time.Now()
+
+	c := *credit
+	c.Person, c.Movie = nil, nil
+	m.credits = append(m.credits, &c)
+
+	return credit.ID, nil
+}
+
+// Delete method for deleting a specific credit of a movie
+func (m *memoryCreditModel) Delete(movieID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, credit := range m.credits {
+		if credit.ID == id && credit.MovieID == movieID {
+			m.credits = append(m.credits[:i], m.credits[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrRecordNotFound
+}
+
+// DeleteAllForPerson method removes all credits of a person
+func (m *memoryCreditModel) DeleteAllForPerson(personID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	credits := m.credits[:0]
+	for _, credit := range m.credits {
+		if credit.PersonID != personID {
+			credits = append(credits, credit)
+		}
+	}
+	m.credits = credits
+
+	return nil
+}
+
+// GetForMovie method lists the credits of a movie with their person, ordered by role & billing
+func (m *memoryCreditModel) GetForMovie(movieID string) ([]*Credit, error) {
+	credits := []*Credit{}
+	for _, credit := range m.match(func(credit *Credit) bool { return credit.MovieID == movieID }) {
+		person, err := m.people.Get(credit.PersonID)
+		if err != nil {
+			continue
+		}
+		credit.Person = person
+		credits = append(credits, credit)
+	}
+
+	sort.SliceStable(credits, func(i, j int) bool {
+		a, b := credits[i], credits[j]
+		if roleOrder(a.Role) != roleOrder(b.Role) {
+			return roleOrder(a.Role) < roleOrder(b.Role)
+		}
+		if a.Billing != b.Billing {
+			return a.Billing < b.Billing
+		}
+		return a.ID < b.ID
+	})
+
+	return credits, nil
+}
+
+// GetForPerson method lists the credits of a person with their movie, newest movies first. Movies in the trash are left out
+func (m *memoryCreditModel) GetForPerson(personID string) ([]*Credit, error) {
+	credits := []*Credit{}
+	for _, credit := range m.match(func(credit *Credit) bool { return credit.PersonID == personID }) {
+		movie, err := m.movies.Get(credit.MovieID, nil)
+		if err != nil {
+			continue
+		}
+		credit.Movie = movie
+		credits = append(credits, credit)
+	}
+
+	sort.SliceStable(credits, func(i, j int) bool {
+		a, b := credits[i], credits[j]
+		if a.Movie.Year != b.Movie.Year {
+			return a.Movie.Year > b.Movie.Year
+		}
+		if a.MovieID != b.MovieID {
+			return a.MovieID < b.MovieID
+		}
+		if roleOrder(a.Role) != roleOrder(b.Role) {
+			return roleOrder(a.Role) < roleOrder(b.Role)
+		}
+		return a.ID < b.ID
+	})
+
+	return credits, nil
+}
+
+// match returns copies of the credits matching the predicate
+func (m *memoryCreditModel) match(fn func(credit *Credit) bool) []*Credit {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var credits []*Credit
+	for _, credit := range m.credits {
+		if fn(credit) {
+			c := *credit
+			credits = append(credits, &c)
+		}
+	}
+
+	return credits
+}
+
+// memoryReviewModel keeps reviews in a slice guarded by a mutex, mirrors the rules of ReviewModel. Writes hold the lock of the movies
+// as well, so the ratings they compute never disagree with the reviews
+type memoryReviewModel struct {
+	mu      sync.RWMutex
+	reviews []*Review
+	movies  *memoryMovieModel
+}
+
+func newMemoryReviewModel(movies *memoryMovieModel) *memoryReviewModel {
+	return &memoryReviewModel{movies: movies}
+}
+
+// Insert method for creating a new record & updating the rating of its movie, a user can only review a movie once
+func (m *memoryReviewModel) Insert(review *Review) error {
+	m.movies.mu.Lock()
+	defer m.movies.mu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.movies.movies[review.MovieID]; !ok {
+		return ErrRecordNotFound
+	}
+
+	for _, existing := range m.reviews {
+		if existing.MovieID == review.MovieID && existing.UserID == review.UserID {
+			return ErrDuplicateReview
+		}
+	}
+
+	oid := primitive.NewObjectID()
+
+	review.OID = oid
+	review.ID = oid.Hex()
+	review.CreatedAt = time.Now()
+	review.UpdatedAt = review.CreatedAt
+	review.Version = 1
+
+	c := *review
+	m.reviews = append(m.reviews, &c)
+	m.updateRating(review.MovieID)
+
+	return nil
+}
+
+// GetForUser method fetches the review a user wrote for a movie
+func (m *memoryReviewModel) GetForUser(movieID, userID string) (*Review, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, review := range m.reviews {
+		if review.MovieID == movieID && review.UserID == userID {
+			c := *review
+			return &c, nil
+		}
+	}
+
+	return nil, ErrRecordNotFound
+}
+
+// Update method for updating a specific record together with the rating of its movie, fails with ErrEditConflict if it was changed
+// since it was read
+func (m *memoryReviewModel) Update(review *Review) error {
+	m.movies.mu.Lock()
+	defer m.movies.mu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.reviews {
+		if existing.ID == review.ID && existing.Version == review.Version {
+			review.UpdatedAt = time.Now()
+			review.Version++
+
+			c := *review
+			m.reviews[i] = &c
+			m.updateRating(review.MovieID)
+			return nil
+		}
+	}
+
+	return ErrEditConflict
+}
+
+// Delete method for deleting a specific record together with its share of the rating of its movie, fails with ErrEditConflict if it
+// was changed since it was read
+func (m *memoryReviewModel) Delete(review *Review) error {
+	m.movies.mu.Lock()
+	defer m.movies.mu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.reviews {
+		if existing.ID == review.ID && existing.Version == review.Version {
+			m.reviews = append(m.reviews[:i], m.reviews[i+1:]...)
+			m.updateRating(review.MovieID)
+			return nil
+		}
+	}
+
+	return ErrEditConflict
+}
+
+// updateRating sets the rating of a movie to the average of its reviews & returns their sum & count, the caller holds both locks
+func (m *memoryReviewModel) updateRating(movieID string) (int64, int64) {
+	var sum, count int64
+	for _, review := range m.reviews {
+		if review.MovieID == movieID {
+			sum += int64(review.Score)
+			count++
+		}
+	}
+
+	if movie, ok := m.movies.movies[movieID]; ok {
+		updated := copyMovie(movie)
+		updated.RatingSum, updated.RatingCount, updated.Rating = sum, count, averageRating(sum, count)
+		m.movies.movies[movieID] = updated
+	}
+
+	return sum, count
+}
+
+// GetAll method to list the reviews of a movie, newest first by default
+func (m *memoryReviewModel) GetAll(movieID string, filters Filters) ([]*Review, Metadata, error) {
+	m.mu.RLock()
+	reviews := []*Review{}
+	for _, review := range m.reviews {
+		if review.MovieID == movieID {
+			c := *review
+			reviews = append(reviews, &c)
+		}
+	}
+	m.mu.RUnlock()
+
+	field, direction := reviewSortField(filters.Sort)
+	sort.Slice(reviews, func(i, j int) bool {
+		a, b := reviews[i], reviews[j]
+		if direction < 0 {
+			a, b = b, a
+		}
+		if field == "score" && a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		if field == "created_at" && !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	metadata := calculateMetadata(len(reviews), filters.Page, filters.PageSize)
+
+	// Apply skip & limit the same way the MongoDB find options do
+	start := filters.offset()
+	if start > len(reviews) {
+		start = len(reviews)
+	}
+	end := len(reviews)
+	if filters.limit() != 0 && start+filters.limit() < end {
+		end = start + filters.limit()
+	}
+
+	return reviews[start:end], metadata, nil
+}
+
+// lookup returns a copy of a movie including ones in the trash like a $lookup does, nil if there is none
+func (m *memoryMovieModel) lookup(id string) *Movie {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movie, ok := m.movies[id]
+	if !ok {
+		return nil
+	}
+
+	return copyMovie(movie)
+}
+
+// memoryWatchlistModel keeps watchlist entries in a slice guarded by a mutex, mirrors the rules of WatchlistModel
+type memoryWatchlistModel struct {
+	mu      sync.RWMutex
+	entries []*WatchlistEntry
+	movies  *memoryMovieModel
+}
+
+func newMemoryWatchlistModel(movies *memoryMovieModel) *memoryWatchlistModel {
+	return &memoryWatchlistModel{movies: movies}
+}
+
+// Add method appends a movie to the end of a user's watchlist
+func (m *memoryWatchlistModel) Add(userID, movieID string) (*WatchlistEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var position int32
+	for _, entry := range m.entries {
+		if entry.UserID != userID {
+			continue
+		}
+		if entry.MovieID == movieID {
+			return nil, ErrAlreadyOnWatchlist
+		}
+		if entry.Position > position {
+			position = entry.Position
+		}
+	}
+
+	entry := &WatchlistEntry{UserID: userID, MovieID: movieID, Position: position + 1, AddedAt: time.Now()}
+
+	c := *entry
+	m.entries = append(m.entries, &c)
+
+	return entry, nil
+}
+
+// Remove method removes a movie from a user's watchlist & closes the gap it leaves
+func (m *memoryWatchlistModel) Remove(userID, movieID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, entry := range m.entries {
+		if entry.UserID == userID && entry.MovieID == movieID {
+			m.entries = append(m.entries[:i], m.entries[i+1:]...)
+
+			for _, other := range m.entries {
+				if other.UserID == userID && other.Position > entry.Position {
+					other.Position--
+				}
+			}
+			return nil
+		}
+	}
+
+	return ErrRecordNotFound
+}
+
+// Reorder method sets the order of a user's watchlist, movieIDs must hold every movie on it exactly once
+func (m *memoryWatchlistModel) Reorder(userID string, movieIDs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	positions := make(map[string]int32)
+	for i, movieID := range movieIDs {
+		positions[movieID] = int32(i + 1)
+	}
+
+	var entries []*WatchlistEntry
+	for _, entry := range m.entries {
+		if entry.UserID == userID {
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) != len(movieIDs) || len(positions) != len(movieIDs) {
+		return ErrWatchlistMismatch
+	}
+	for _, entry := range entries {
+		if positions[entry.MovieID] == 0 {
+			return ErrWatchlistMismatch
+		}
+	}
+
+	for _, entry := range entries {
+		entry.Position = positions[entry.MovieID]
+	}
+
+	return nil
+}
+
+// GetAll method lists a user's watchlist in order with the movies
+func (m *memoryWatchlistModel) GetAll(userID string) ([]*WatchlistEntry, error) {
+	m.mu.RLock()
+	entries := []*WatchlistEntry{}
+	for _, entry := range m.entries {
+		if entry.UserID == userID {
+			c := *entry
+			entries = append(entries, &c)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Position < entries[j].Position
+	})
+
+	for _, entry := range entries {
+		entry.Movie = m.movies.lookup(entry.MovieID)
+	}
+
+	return entries, nil
+}
+
+// memoryWatchedModel keeps watched movies in a slice guarded by a mutex, mirrors the rules of WatchedModel
+type memoryWatchedModel struct {
+	mu      sync.RWMutex
+	watched []*WatchedMovie
+	movies  *memoryMovieModel
+}
+
+func newMemoryWatchedModel(movies *memoryMovieModel) *memoryWatchedModel {
+	return &memoryWatchedModel{movies: movies}
+}
+
+func copyWatched(watched *WatchedMovie) *WatchedMovie {
+	c := *watched
+	c.Dates = append([]time.Time{}, watched.Dates...)
+	c.RewatchCount = c.WatchCount - 1
+	return &c
+}
+
+// Log method records that a user watched a movie at the given time, every further time counts as a rewatch
+func (m *memoryWatchedModel) Log(userID, movieID string, at time.Time) (*WatchedMovie, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var watched *WatchedMovie
+	for _, w := range m.watched {
+		if w.UserID == userID && w.MovieID == movieID {
+			watched = w
+			break
+		}
+	}
+
+	if watched == nil {
+		watched = &WatchedMovie{UserID: userID, MovieID: movieID}
+		m.watched = append(m.watched, watched)
+	}
+
+	watched.Dates = append(watched.Dates, at)
+	sort.Slice(watched.Dates, func(i, j int) bool {
+		return watched.Dates[i].Before(watched.Dates[j])
+	})
+	watched.WatchCount++
+	if at.After(watched.LastWatchedAt) {
+		watched.LastWatchedAt = at
+	}
+
+	return copyWatched(watched), nil
+}
+
+// Remove method deletes a movie & all its dates from a user's watched history
+func (m *memoryWatchedModel) Remove(userID, movieID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, w := range m.watched {
+		if w.UserID == userID && w.MovieID == movieID {
+			m.watched = append(m.watched[:i], m.watched[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrRecordNotFound
+}
+
+// GetAll method lists a user's watched movies with the movies, most recently watched first by default
+func (m *memoryWatchedModel) GetAll(userID string, filters Filters) ([]*WatchedMovie, Metadata, error) {
+	watched := m.forUser(userID)
+
+	field, direction := watchedSortField(filters.Sort)
+	sort.Slice(watched, func(i, j int) bool {
+		a, b := watched[i], watched[j]
+		if direction < 0 {
+			a, b = b, a
+		}
+		if field == "watch_count" && a.WatchCount != b.WatchCount {
+			return a.WatchCount < b.WatchCount
+		}
+		if field == "last_watched_at" && !a.LastWatchedAt.Equal(b.LastWatchedAt) {
+			return a.LastWatchedAt.Before(b.LastWatchedAt)
+		}
+		return a.MovieID < b.MovieID
+	})
+
+	metadata := calculateMetadata(len(watched), filters.Page, filters.PageSize)
+
+	// Apply skip & limit the same way the aggregation does
+	start := filters.offset()
+	if start > len(watched) {
+		start = len(watched)
+	}
+	end := len(watched)
+	if filters.limit() != 0 && start+filters.limit() < end {
+		end = start + filters.limit()
+	}
+	watched = watched[start:end]
+
+	for _, w := range watched {
+		w.Movie = m.movies.lookup(w.MovieID)
+	}
+
+	return watched, metadata, nil
+}
+
+// Summary method totals a user's watched history, every view counts with the runtime of its movie
+func (m *memoryWatchedModel) Summary(userID string) (*WatchSummary, error) {
+	summary := &WatchSummary{ByGenre: []WatchTotal{}, ByYear: []WatchTotal{}}
+	byGenre := make(map[string]*WatchTotal)
+	byYear := make(map[int]*WatchTotal)
+
+	for _, w := range m.forUser(userID) {
+		movie := m.movies.lookup(w.MovieID)
+		if movie == nil {
+			continue
+		}
+
+		summary.Movies++
+		for _, date := range w.Dates {
+			minutes := int64(movie.Runtime)
+			summary.Views++
+			summary.Minutes += minutes
+
+			for _, genre := range movie.Genres {
+				if byGenre[genre] == nil {
+					byGenre[genre] = &WatchTotal{Value: genre}
+				}
+				byGenre[genre].Views++
+				byGenre[genre].Minutes += minutes
+			}
+
+			year := date.Year()
+			if byYear[year] == nil {
+				byYear[year] = &WatchTotal{Value: int32(year)}
+			}
+			byYear[year].Views++
+			byYear[year].Minutes += minutes
+		}
+	}
+
+	for _, total := range byGenre {
+		summary.ByGenre = append(summary.ByGenre, *total)
+	}
+	sort.Slice(summary.ByGenre, func(i, j int) bool {
+		a, b := summary.ByGenre[i], summary.ByGenre[j]
+		if a.Minutes != b.Minutes {
+			return a.Minutes > b.Minutes
+		}
+		return a.Value.(string) < b.Value.(string)
+	})
+
+	for _, total := range byYear {
+		summary.ByYear = append(summary.ByYear, *total)
+	}
+	sort.Slice(summary.ByYear, func(i, j int) bool {
+		return summary.ByYear[i].Value.(int32) < summary.ByYear[j].Value.(int32)
+	})
+
+	return summary, nil
+}
+
+// forUser returns copies of a user's watched movies
+func (m *memoryWatchedModel) forUser(userID string) []*WatchedMovie {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	watched := []*WatchedMovie{}
+	for _, w := range m.watched {
+		if w.UserID == userID {
+			watched = append(watched, copyWatched(w))
+		}
+	}
+
+	return watched
+}
+
+// SetPoster method records when the current poster of a specific record was uploaded
+func (m *memoryMovieModel) SetPoster(id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	movie, ok := m.movies[id]
+	if !ok || movie.DeletedAt != nil {
+		return ErrRecordNotFound
+	}
+
+	updated := copyMovie(movie)
+	updated.PosterAt = at
+	m.movies[id] = updated
+
+	return nil
+}
+
+// memoryPosterModel keeps the current poster of every movie by size in a map guarded by a mutex
+type memoryPosterModel struct {
+	mu      sync.RWMutex
+	posters map[string]map[string]*Poster
+}
+
+func newMemoryPosterModel() *memoryPosterModel {
+	return &memoryPosterModel{posters: make(map[string]map[string]*Poster)}
+}
+
+// Put method replaces all sizes of the poster of a movie
+func (m *memoryPosterModel) Put(movieID string, posters []*Poster) error {
+	now := time.Now()
+
+	sizes := make(map[string]*Poster)
+	for _, poster := range posters {
+		poster.stamp(movieID, now)
+
+		c := *poster
+		sizes[poster.Size] = &c
+	}
+
+	m.mu.Lock()
+	m.posters[movieID] = sizes
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Get method fetches one size of the current poster of a movie
+func (m *memoryPosterModel) Get(movieID, size string) (*Poster, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	poster, ok := m.posters[movieID][size]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	c := *poster
+	return &c, nil
+}
+
+// memoryMergeModel merges movies across the in-memory stores, holding all their locks makes a merge atomic like the transaction of MergeModel
+type memoryMergeModel struct {
+	movies    *memoryMovieModel
+	credits   *memoryCreditModel
+	reviews   *memoryReviewModel
+	watchlist *memoryWatchlistModel
+	watched   *memoryWatchedModel
+}
+
+// Merge method merges sources into survivor, see MergeModel.Merge
+func (m *memoryMergeModel) Merge(survivor *Movie, update MovieUpdate, sources []*Movie, userID string) error {
+	m.movies.mu.Lock()
+	defer m.movies.mu.Unlock()
+	m.credits.mu.Lock()
+	defer m.credits.mu.Unlock()
+	m.reviews.mu.Lock()
+	defer m.reviews.mu.Unlock()
+	m.watchlist.mu.Lock()
+	defer m.watchlist.mu.Unlock()
+	m.watched.mu.Lock()
+	defer m.watched.mu.Unlock()
+
+	// Check everything up front, nothing may change if the merge fails
+	merging := map[string]bool{survivor.ID: true}
+	for _, movie := range append([]*Movie{survivor}, sources...) {
+		existing, ok := m.movies.movies[movie.ID]
+		if !ok || existing.Version != movie.Version || existing.DeletedAt != nil {
+			return ErrEditConflict
+		}
+		merging[movie.ID] = true
+	}
+
+	if update.ExternalIDs != nil {
+		for _, movie := range m.movies.movies {
+			if !merging[movie.ID] && update.ExternalIDs.shares(movie.ExternalIDs) {
+				return ErrDuplicateExternalID
+			}
+		}
+	}
+
+	now := time.Now()
+
+	for _, source := range sources {
+		survivor.Popularity += source.Popularity
+		source.tombstone(survivor.ID, userID, now)
+
+		tombstone := copyMovie(m.movies.movies[source.ID])
+		tombstone.tombstone(survivor.ID, userID, now)
+		m.movies.movies[source.ID] = tombstone
+	}
+
+	// Redirects to the sources now lead to the survivor directly
+	for _, movie := range m.movies.movies {
+		if movie.MergedInto != "" && merging[movie.MergedInto] && movie.MergedInto != survivor.ID {
+			movie.MergedInto = survivor.ID
+		}
+	}
+
+	for _, credit := range m.credits.credits {
+		if merging[credit.MovieID] {
+			credit.MovieID = survivor.ID
+		}
+	}
+
+	for _, source := range sources {
+		m.moveReviews(source.ID, survivor.ID)
+		m.moveWatchlist(source.ID, survivor.ID)
+		m.moveWatched(source.ID, survivor.ID)
+	}
+
+	update.apply(survivor)
+	survivor.Version++
+
+	updated := copyMovie(m.movies.movies[survivor.ID])
+	update.apply(updated)
+	updated.Version = survivor.Version
+	updated.Popularity = survivor.Popularity
+	m.movies.movies[survivor.ID] = updated
+
+	// The rating is recomputed from the reviews the survivor ended up with
+	sum, count := m.reviews.updateRating(survivor.ID)
+	survivor.RatingSum = sum
+	survivor.RatingCount = count
+	survivor.Rating = averageRating(sum, count)
+
+	for _, movie := range append([]*Movie{survivor}, sources...) {
+		m.movies.revisions.add(NewRevision(m.movies.movies[movie.ID], RevisionMerge, userID))
+	}
+
+	return nil
+}
+
+// moveReviews moves the reviews of a source to the survivor, reviews of users who also reviewed the survivor are dropped
+func (m *memoryMergeModel) moveReviews(sourceID, survivorID string) {
+	reviewed := make(map[string]bool)
+	for _, review := range m.reviews.reviews {
+		if review.MovieID == survivorID {
+			reviewed[review.UserID] = true
+		}
+	}
+
+	reviews := m.reviews.reviews[:0]
+	for _, review := range m.reviews.reviews {
+		if review.MovieID == sourceID {
+			if reviewed[review.UserID] {
+				continue
+			}
+			review.MovieID = survivorID
+		}
+		reviews = append(reviews, review)
+	}
+	m.reviews.reviews = reviews
+}
+
+// moveWatchlist moves the watchlist entries of a source to the survivor, entries of users who also listed the survivor are
+// removed & close their gap
+func (m *memoryMergeModel) moveWatchlist(sourceID, survivorID string) {
+	listed := make(map[string]bool)
+	for _, entry := range m.watchlist.entries {
+		if entry.MovieID == survivorID {
+			listed[entry.UserID] = true
+		}
+	}
+
+	var removed []*WatchlistEntry
+	entries := m.watchlist.entries[:0]
+	for _, entry := range m.watchlist.entries {
+		if entry.MovieID == sourceID {
+			if listed[entry.UserID] {
+				removed = append(removed, entry)
+				continue
+			}
+			entry.MovieID = survivorID
+		}
+		entries = append(entries, entry)
+	}
+	m.watchlist.entries = entries
+
+	for _, gap := range removed {
+		for _, entry := range m.watchlist.entries {
+			if entry.UserID == gap.UserID && entry.Position > gap.Position {
+				entry.Position--
+			}
+		}
+	}
+}
+
+// moveWatched moves the watched history of a source to the survivor, the dates of users who also watched the survivor are combined
+func (m *memoryMergeModel) moveWatched(sourceID, survivorID string) {
+	existing := make(map[string]*WatchedMovie)
+	for _, w := range m.watched.watched {
+		if w.MovieID == survivorID {
+			existing[w.UserID] = w
+		}
+	}
+
+	watched := m.watched.watched[:0]
+	for _, w := range m.watched.watched {
+		if w.MovieID == sourceID {
+			if target, ok := existing[w.UserID]; ok {
+				target.Dates = append(target.Dates, w.Dates...)
+				sort.Slice(target.Dates, func(i, j int) bool { return target.Dates[i].Before(target.Dates[j]) })
+				target.WatchCount += w.WatchCount
+				if w.LastWatchedAt.After(target.LastWatchedAt) {
+					target.LastWatchedAt = w.LastWatchedAt
+				}
+				continue
+			}
+			w.MovieID = survivorID
+		}
+		watched = append(watched, w)
+	}
+	m.watched.watched = watched
+}
+
+// memoryPurgeModel purges movies from all in-memory stores, holding all their locks makes a purge atomic like the transaction of PurgeModel
+type memoryPurgeModel struct {
+	movies    *memoryMovieModel
+	credits   *memoryCreditModel
+	reviews   *memoryReviewModel
+	watchlist *memoryWatchlistModel
+	watched   *memoryWatchedModel
+	posters   *memoryPosterModel
+}
+
+// Purge method permanently removes movies which were moved to the trash before the cutoff & everything referring to them, see PurgeModel.Purge
+func (m *memoryPurgeModel) Purge(cutoff time.Time) (int64, error) {
+	m.movies.mu.Lock()
+	defer m.movies.mu.Unlock()
+	m.credits.mu.Lock()
+	defer m.credits.mu.Unlock()
+	m.reviews.mu.Lock()
+	defer m.reviews.mu.Unlock()
+	m.watchlist.mu.Lock()
+	defer m.watchlist.mu.Unlock()
+	m.watched.mu.Lock()
+	defer m.watched.mu.Unlock()
+
+	removed := make(map[string]bool)
+	var purged int64
+	for id, movie := range m.movies.movies {
+		if movie.DeletedAt != nil && movie.DeletedAt.Before(cutoff) && movie.MergedInto == "" {
+			removed[id] = true
+			purged++
+		}
+	}
+
+	// Redirects to the purged movies would lead nowhere
+	for id, movie := range m.movies.movies {
+		if removed[movie.MergedInto] {
+			removed[id] = true
+		}
+	}
+
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	for id := range removed {
+		delete(m.movies.movies, id)
+	}
+
+	credits := m.credits.credits[:0]
+	for _, credit := range m.credits.credits {
+		if !removed[credit.MovieID] {
+			credits = append(credits, credit)
+		}
+	}
+	m.credits.credits = credits
+
+	reviews := m.reviews.reviews[:0]
+	for _, review := range m.reviews.reviews {
+		if !removed[review.MovieID] {
+			reviews = append(reviews, review)
+		}
+	}
+	m.reviews.reviews = reviews
+
+	m.removeFromWatchlists(removed)
+
+	watched := m.watched.watched[:0]
+	for _, w := range m.watched.watched {
+		if !removed[w.MovieID] {
+			watched = append(watched, w)
+		}
+	}
+	m.watched.watched = watched
+
+	m.movies.revisions.remove(removed)
+	m.posters.remove(removed)
+
+	return purged, nil
+}
+
+// removeFromWatchlists removes the movies from every watchlist & closes the gaps they leave
+func (m *memoryPurgeModel) removeFromWatchlists(movieIDs map[string]bool) {
+	var removed []*WatchlistEntry
+	entries := m.watchlist.entries[:0]
+	for _, entry := range m.watchlist.entries {
+		if movieIDs[entry.MovieID] {
+			removed = append(removed, entry)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	m.watchlist.entries = entries
+
+	// Every remaining entry moves up by the number of removed entries of its user before it
+	for _, entry := range m.watchlist.entries {
+		var shift int32
+		for _, gap := range removed {
+			if gap.UserID == entry.UserID && gap.Position < entry.Position {
+				shift++
+			}
+		}
+		entry.Position -= shift
+	}
+}
+
+// remove deletes all revisions of the movies
+func (m *memoryRevisionModel) remove(movieIDs map[string]bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	revisions := m.revisions[:0]
+	for _, revision := range m.revisions {
+		if !movieIDs[revision.MovieID] {
+			revisions = append(revisions, revision)
+		}
+	}
+	m.revisions = revisions
+}
+
+// remove deletes the posters of the movies
+func (m *memoryPosterModel) remove(movieIDs map[string]bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id := range movieIDs {
+		delete(m.posters, id)
+	}
+}
@@ -0,0 +1,313 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// notMerged matches movies which haven't been merged into another one, merged movies stay behind in the trash as redirects
+var notMerged = bson.E{Key: "merged_into", Value: bson.M{"$exists": false}}
+
+// MergeModel struct type wraps the collections a merge moves data between & the revisions collection it's recorded in
+type MergeModel struct {
+	Movies    *mongo.Collection
+	Credits   *mongo.Collection
+	Reviews   *mongo.Collection
+	Watchlist *mongo.Collection
+	Watched   *mongo.Collection
+	Revisions *mongo.Collection
+}
+
+// Merge method merges sources into survivor in a single transaction. update is applied to survivor, the sources are moved to the trash as
+// redirects to it & their credits, reviews, watchlist entries & watched history are moved over. Where a user has both, the survivor's
+// review & watchlist entry win while watched dates are combined. Every merged movie gets a merge revision in the same transaction.
+// survivor & sources must hold the state they were read in & are updated in place, fails with ErrEditConflict if any of them changed
+// since
+func (m MergeModel) Merge(survivor *Movie, update MovieUpdate, sources []*Movie, userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	session, err := m.Movies.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	now := time.Now()
+
+	var ratingSum, ratingCount int64
+
+	// WithTransaction retries the whole callback on transient errors, so it must not change survivor or sources
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		ids := make(bson.A, len(sources))
+		var popularity int64
+		for i, source := range sources {
+			ids[i] = source.ID
+			popularity += source.Popularity
+
+			err := m.tombstone(sc, source, survivor.ID, userID, now)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Redirects to the sources now lead to the survivor directly
+		_, err := m.Movies.UpdateMany(sc, bson.M{"merged_into": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"merged_into": survivor.ID}})
+		if err != nil {
+			return nil, err
+		}
+
+		oid, err := primitive.ObjectIDFromHex(survivor.ID)
+		if err != nil {
+			return nil, ErrRecordNotFound
+		}
+
+		doc := update.document()
+		doc["$inc"].(bson.M)["popularity"] = popularity
+
+		// The sources gave up their external IDs above, so the survivor can take them over
+		res, err := m.Movies.UpdateOne(sc, bson.D{{Key: "_id", Value: oid}, {Key: "version", Value: survivor.Version}, notDeleted}, doc)
+		if err != nil {
+			switch {
+			case mongo.IsDuplicateKeyError(err):
+				return nil, ErrDuplicateExternalID
+			default:
+				return nil, err
+			}
+		}
+		if res.MatchedCount == 0 {
+			return nil, ErrEditConflict
+		}
+
+		_, err = m.Credits.UpdateMany(sc, bson.M{"movie_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"movie_id": survivor.ID}})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, source := range sources {
+			err = m.moveReviews(sc, source.ID, survivor.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			err = m.moveWatchlist(sc, source.ID, survivor.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			err = m.moveWatched(sc, source.ID, survivor.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// The rating is recomputed from the reviews the survivor ended up with
+		ratingSum, ratingCount, err = updateRating(sc, m.Reviews, m.Movies, survivor.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// The revisions hold the state the merge leaves the movies in
+		merged := copyMovie(survivor)
+		update.apply(merged)
+		merged.Version++
+		merged.Popularity += popularity
+		merged.RatingSum, merged.RatingCount, merged.Rating = ratingSum, ratingCount, averageRating(ratingSum, ratingCount)
+
+		revisions := []*Revision{NewRevision(merged, RevisionMerge, userID)}
+		for _, source := range sources {
+			tombstone := copyMovie(source)
+			tombstone.tombstone(survivor.ID, userID, now)
+			revisions = append(revisions, NewRevision(tombstone, RevisionMerge, userID))
+		}
+
+		return nil, insertRevisions(sc, m.Revisions, revisions)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, source := range sources {
+		survivor.Popularity += source.Popularity
+		source.tombstone(survivor.ID, userID, now)
+	}
+
+	update.apply(survivor)
+	survivor.Version++
+	survivor.RatingSum = ratingSum
+	survivor.RatingCount = ratingCount
+	survivor.Rating = averageRating(ratingSum, ratingCount)
+
+	return nil
+}
+
+// tombstone moves a source to the trash as a redirect to the survivor, its external IDs are released for the survivor to take over
+func (m MergeModel) tombstone(ctx context.Context, source *Movie, survivorID, userID string, at time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(source.ID)
+	if err != nil {
+		return ErrRecordNotFound
+	}
+
+	filter := bson.D{{Key: "_id", Value: oid}, {Key: "version", Value: source.Version}, notDeleted}
+	update := bson.M{
+		"$set":   bson.M{"deleted_at": at, "deleted_by": userID, "merged_into": survivorID},
+		"$unset": bson.M{"external_ids": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+
+	res, err := m.Movies.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+// tombstone applies the changes of MergeModel.tombstone to the movie
+func (movie *Movie) tombstone(survivorID, userID string, at time.Time) {
+	movie.DeletedAt = &at
+	movie.DeletedBy = userID
+	movie.MergedInto = survivorID
+	movie.ExternalIDs = nil
+	movie.Version++
+}
+
+// moveReviews moves the reviews of a source to the survivor, reviews of users who also reviewed the survivor are dropped
+func (m MergeModel) moveReviews(ctx context.Context, sourceID, survivorID string) error {
+	users, err := m.Reviews.Distinct(ctx, "user_id", bson.M{"movie_id": survivorID})
+	if err != nil {
+		return err
+	}
+
+	_, err = m.Reviews.DeleteMany(ctx, bson.M{"movie_id": sourceID, "user_id": bson.M{"$in": users}})
+	if err != nil {
+		return err
+	}
+
+	_, err = m.Reviews.UpdateMany(ctx, bson.M{"movie_id": sourceID}, bson.M{"$set": bson.M{"movie_id": survivorID}})
+	return err
+}
+
+// moveWatchlist moves the watchlist entries of a source to the survivor, entries of users who also listed the survivor are
+// removed & close their gap
+func (m MergeModel) moveWatchlist(ctx context.Context, sourceID, survivorID string) error {
+	users, err := m.Watchlist.Distinct(ctx, "user_id", bson.M{"movie_id": survivorID})
+	if err != nil {
+		return err
+	}
+
+	cursor, err := m.Watchlist.Find(ctx, bson.M{"movie_id": sourceID, "user_id": bson.M{"$in": users}})
+	if err != nil {
+		return err
+	}
+
+	var duplicates []WatchlistEntry
+	err = cursor.All(ctx, &duplicates)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range duplicates {
+		_, err = m.Watchlist.DeleteOne(ctx, bson.M{"user_id": entry.UserID, "movie_id": sourceID})
+		if err != nil {
+			return err
+		}
+
+		_, err = m.Watchlist.UpdateMany(ctx,
+			bson.M{"user_id": entry.UserID, "position": bson.M{"$gt": entry.Position}},
+			bson.M{"$inc": bson.M{"position": -1}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = m.Watchlist.UpdateMany(ctx, bson.M{"movie_id": sourceID}, bson.M{"$set": bson.M{"movie_id": survivorID}})
+	return err
+}
+
+// moveWatched moves the watched history of a source to the survivor, the dates of users who also watched the survivor are combined
+func (m MergeModel) moveWatched(ctx context.Context, sourceID, survivorID string) error {
+	users, err := m.Watched.Distinct(ctx, "user_id", bson.M{"movie_id": survivorID})
+	if err != nil {
+		return err
+	}
+
+	cursor, err := m.Watched.Find(ctx, bson.M{"movie_id": sourceID, "user_id": bson.M{"$in": users}})
+	if err != nil {
+		return err
+	}
+
+	var duplicates []WatchedMovie
+	err = cursor.All(ctx, &duplicates)
+	if err != nil {
+		return err
+	}
+
+	for _, watched := range duplicates {
+		_, err = m.Watched.UpdateOne(ctx, bson.M{"user_id": watched.UserID, "movie_id": survivorID}, bson.M{
+			"$push": bson.M{"dates": bson.M{"$each": watched.Dates, "$sort": 1}},
+			"$inc":  bson.M{"watch_count": watched.WatchCount},
+			"$max":  bson.M{"last_watched_at": watched.LastWatchedAt},
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = m.Watched.DeleteOne(ctx, bson.M{"user_id": watched.UserID, "movie_id": sourceID})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = m.Watched.UpdateMany(ctx, bson.M{"movie_id": sourceID}, bson.M{"$set": bson.M{"movie_id": survivorID}})
+	return err
+}
+
+// averageRating returns the average of count ratings adding up to sum, 0 without ratings
+func averageRating(sum, count int64) float64 {
+	if count == 0 {
+		return 0
+	}
+	return float64(sum) / float64(count)
+}
+
+// GetMergedInto method returns the ID of the movie a merged movie redirects to
+func (m MovieModel) GetMergedInto(id string) (string, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var result struct {
+		MergedInto string `bson:"merged_into"`
+	}
+
+	filter := bson.M{"_id": oid, "merged_into": bson.M{"$exists": true}}
+	opts := options.FindOne().SetProjection(bson.M{"merged_into": 1})
+
+	err = m.Collection.FindOne(ctx, filter, opts).Decode(&result)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return "", ErrRecordNotFound
+		default:
+			return "", err
+		}
+	}
+
+	return result.MergedInto, nil
+}
@@ -2,27 +2,177 @@ package data
 
 import (
 	"errors"
+	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// ErrEditConflict custom error
+// ErrRecordNotFound & ErrEditConflict custom errors
 var (
-	ErrEditConflict = errors.New("edit conflict")
+	ErrRecordNotFound = errors.New("record not found")
+	ErrEditConflict   = errors.New("edit conflict")
 )
 
-// Models struct wraps Models
+// MovieStore is implemented by every movie storage backend
+type MovieStore interface {
+	Insert(movie *Movie) (string, error)
+	InsertMany(movies []*Movie) error
+	Get(id string, fields []string) (*Movie, error)
+	GetByTitle(title string, year int32) (*Movie, error)
+	GetByExternalID(ids ExternalIDs) (*Movie, error)
+	GetMergedInto(id string) (string, error)
+	FindDuplicates(title string, year int32) ([]*Movie, error)
+	Patch(movie *Movie, update MovieUpdate, change Change) error
+	Delete(id, userID string) (*Movie, error)
+	Restore(id, userID string) (*Movie, error)
+	GetAll(query MovieQuery, filters Filters) ([]*Movie, Metadata, error)
+	GetTrash(filters Filters) ([]*Movie, Metadata, error)
+	Facets(query MovieQuery, names []string) (Facets, error)
+	Suggest(q string, limit int) ([]*Movie, error)
+	AddPopularity(id string, delta int64) error
+	SetPoster(id string, at time.Time) error
+	Export(query MovieQuery, sort string, fn func(movie *Movie) error) error
+}
+
+// UserStore is implemented by every user storage backend
+type UserStore interface {
+	Insert(user *User) (string, error)
+	GetByEmail(email string) (*User, error)
+	Update(user *User, id string) error
+	GetForToken(userID string) (*User, error)
+	GetPermissions(userID string) ([]string, error)
+}
+
+// TokenStore is implemented by every token storage backend
+type TokenStore interface {
+	New(userID string, ttl time.Duration, scope string) (*Token, error)
+	Insert(token *Token) error
+	Get(tokenScope, tokenPlaintext string) (string, error)
+	DeleteAllForUser(scope, userID string) error
+}
+
+// RevisionStore is implemented by every revision storage backend
+type RevisionStore interface {
+	Get(movieID string, version int32) (*Revision, error)
+	GetAll(movieID string) ([]*Revision, error)
+}
+
+// PersonStore is implemented by every person storage backend
+type PersonStore interface {
+	Insert(person *Person) (string, error)
+	Get(id string) (*Person, error)
+	Update(person *Person) error
+	Delete(id string) error
+	GetAll(name string, filters Filters) ([]*Person, Metadata, error)
+}
+
+// CreditStore is implemented by every credit storage backend
+type CreditStore interface {
+	Insert(credit *Credit) (string, error)
+	Delete(movieID, id string) error
+	DeleteAllForPerson(personID string) error
+	GetForMovie(movieID string) ([]*Credit, error)
+	GetForPerson(personID string) ([]*Credit, error)
+}
+
+// ReviewStore is implemented by every review storage backend
+type ReviewStore interface {
+	Insert(review *Review) error
+	GetForUser(movieID, userID string) (*Review, error)
+	Update(review *Review) error
+	Delete(review *Review) error
+	GetAll(movieID string, filters Filters) ([]*Review, Metadata, error)
+}
+
+// WatchlistStore is implemented by every watchlist storage backend
+type WatchlistStore interface {
+	Add(userID, movieID string) (*WatchlistEntry, error)
+	Remove(userID, movieID string) error
+	Reorder(userID string, movieIDs []string) error
+	GetAll(userID string) ([]*WatchlistEntry, error)
+}
+
+// WatchedStore is implemented by every watched history storage backend
+type WatchedStore interface {
+	Log(userID, movieID string, at time.Time) (*WatchedMovie, error)
+	Remove(userID, movieID string) error
+	GetAll(userID string, filters Filters) ([]*WatchedMovie, Metadata, error)
+	Summary(userID string) (*WatchSummary, error)
+}
+
+// PosterStore is implemented by every poster storage backend
+type PosterStore interface {
+	Put(movieID string, posters []*Poster) error
+	Get(movieID, size string) (*Poster, error)
+}
+
+// MergeStore is implemented by every movie merge backend
+type MergeStore interface {
+	Merge(survivor *Movie, update MovieUpdate, sources []*Movie, userID string) error
+}
+
+// PurgeStore is implemented by every trash purge backend
+type PurgeStore interface {
+	Purge(cutoff time.Time) (int64, error)
+}
+
+// Models struct wraps the storage backends used by the application
 type Models struct {
-	Movies MovieModel
-	User   UserModel
-	Token  TokenModel
+	Movies    MovieStore
+	User      UserStore
+	Token     TokenStore
+	Revisions RevisionStore
+	People    PersonStore
+	Credits   CreditStore
+	Reviews   ReviewStore
+	Watchlist WatchlistStore
+	Watched   WatchedStore
+	Posters   PosterStore
+	Merges    MergeStore
+	Purges    PurgeStore
 }
 
-// NewModels returns Models struct containing initialized Models
-func NewModels(data, user, token *mongo.Collection) Models {
+// NewModels returns Models struct containing MongoDB backed Models
+func NewModels(data, user, token, revision, people, credit, review, watchlist, watched *mongo.Collection, posters PosterModel) Models {
+	return Models{
+		Movies:    MovieModel{Collection: data, Revisions: revision},
+		User:      UserModel{Collection: user},
+		Token:     TokenModel{Collection: token},
+		Revisions: RevisionModel{Collection: revision},
+		People:    PersonModel{Collection: people},
+		Credits:   CreditModel{Collection: credit, People: people, Movies: data},
+		Reviews:   ReviewModel{Collection: review, Movies: data},
+		Watchlist: WatchlistModel{Collection: watchlist, Movies: data},
+		Watched:   WatchedModel{Collection: watched, Movies: data},
+		Posters:   posters,
+		Merges:    MergeModel{Movies: data, Credits: credit, Reviews: review, Watchlist: watchlist, Watched: watched, Revisions: revision},
+		Purges:    PurgeModel{Movies: data, Credits: credit, Reviews: review, Watchlist: watchlist, Watched: watched, Revisions: revision, Posters: posters},
+	}
+}
+
+// NewMemoryModels returns Models struct containing in-memory Models, no database required
+func NewMemoryModels() Models {
+	revisions := newMemoryRevisionModel()
+	movies := newMemoryMovieModel(revisions)
+	people := newMemoryPersonModel()
+	credits := newMemoryCreditModel(people, movies)
+	reviews := newMemoryReviewModel(movies)
+	watchlist := newMemoryWatchlistModel(movies)
+	watched := newMemoryWatchedModel(movies)
+	posters := newMemoryPosterModel()
+
 	return Models{
-		Movies: MovieModel{Collection: data},
-		User:   UserModel{Collection: user},
-		Token:  TokenModel{Collection: token},
+		Movies:    movies,
+		User:      newMemoryUserModel(),
+		Token:     newMemoryTokenModel(),
+		Revisions: revisions,
+		People:    people,
+		Credits:   credits,
+		Reviews:   reviews,
+		Watchlist: watchlist,
+		Watched:   watched,
+		Posters:   posters,
+		Merges:    &memoryMergeModel{movies: movies, credits: credits, reviews: reviews, watchlist: watchlist, watched: watched},
+		Purges:    &memoryPurgeModel{movies: movies, credits: credits, reviews: reviews, watchlist: watchlist, watched: watched, posters: posters},
 	}
 }
@@ -2,6 +2,8 @@ package data
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"strings"
 	"time"
 
@@ -14,19 +16,64 @@ import (
 
 // Movie struct
 type Movie struct {
-	OID       primitive.ObjectID `json:"-" bson:"_id,omitempty"`
-	ID        string             `json:"id,omitempty" bson:"id,omitempty"`
-	CreatedAt time.Time          `json:"-"`
-	Title     string             `json:"title" bson:"title,omitempty"`
-	Year      int32              `json:"year,omitempty" bson:"year,omitempty"`
-	Runtime   Runtime            `json:"runtime,omitempty" bson:"runtime,omitempty"`
-	Genres    []string           `json:"genres,omitempty" bson:"genres,omitempty"`
-	Version   int32              `json:"-"`
+	OID              primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	ID               string             `json:"id,omitempty" bson:"id,omitempty"`
+	CreatedAt        time.Time          `json:"-"`
+	Title            string             `json:"title,omitempty" bson:"title,omitempty"`
+	OriginalTitle    string             `json:"original_title,omitempty" bson:"-"`
+	Titles           map[string]string  `json:"titles,omitempty" bson:"titles,omitempty"`
+	OriginalLanguage string             `json:"original_language,omitempty" bson:"original_language,omitempty"`
+	TitleKey         string             `json:"-" bson:"title_key,omitempty"`
+	TitleWords       []string           `json:"-" bson:"title_words,omitempty"`
+	AltTitles        []string           `json:"-" bson:"alt_titles,omitempty"`
+	Year             int32              `json:"year,omitempty" bson:"year,omitempty"`
+	Runtime          Runtime            `json:"runtime,omitempty" bson:"runtime,omitempty"`
+	RuntimeFormat    string             `json:"-" bson:"-"`
+	Genres           []string           `json:"genres,omitempty" bson:"genres,omitempty"`
+	ExternalIDs      *ExternalIDs       `json:"external_ids,omitempty" bson:"external_ids,omitempty"`
+	CreatedBy        string             `json:"-" bson:"created_by,omitempty"`
+	DeletedAt        *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedBy        string             `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
+	MergedInto       string             `json:"merged_into,omitempty" bson:"merged_into,omitempty"`
+	Popularity       int64              `json:"-" bson:"popularity"`
+	Rating           float64            `json:"rating,omitempty" bson:"rating"`
+	RatingCount      int64              `json:"rating_count,omitempty" bson:"rating_count"`
+	RatingSum        int64              `json:"-" bson:"rating_sum"`
+	PosterAt         time.Time          `json:"-" bson:"poster_at,omitempty"`
+	Posters          map[string]string  `json:"posters,omitempty" bson:"-"`
+	Score            float64            `json:"score,omitempty" bson:"score,omitempty"`
+	Highlights       []TextSpan         `json:"highlights,omitempty" bson:"-"`
+	Version          int32              `json:"-"`
 }
 
-// MovieModel struct type wraps a MongoDB collection
+// MarshalJSON writes the runtime of movie in its RuntimeFormat, all other fields are written as usual
+func (movie Movie) MarshalJSON() ([]byte, error) {
+	// movieJSON has none of the methods of Movie, so marshalling it doesn't end up back here
+	type movieJSON Movie
+
+	if movie.RuntimeFormat == "" || movie.RuntimeFormat == RuntimeFormatMins {
+		return json.Marshal(movieJSON(movie))
+	}
+
+	var runtime json.RawMessage
+	if movie.Runtime != 0 {
+		runtime = movie.Runtime.MarshalFormat(movie.RuntimeFormat)
+	}
+
+	// The runtime field of the outer struct shadows the one of the embedded movie
+	return json.Marshal(struct {
+		movieJSON
+		Runtime json.RawMessage `json:"runtime,omitempty"`
+	}{movieJSON(movie), runtime})
+}
+
+// notDeleted matches movies which haven't been moved to the trash
+var notDeleted = bson.E{Key: "deleted_at", Value: bson.M{"$exists": false}}
+
+// MovieModel struct type wraps the movies collection & the revisions collection every change to a movie is recorded in
 type MovieModel struct {
 	Collection *mongo.Collection
+	Revisions  *mongo.Collection
 }
 
 // ValidateMovie check for valid JSON
@@ -45,155 +92,631 @@ func ValidateMovie(v *validator.Validator, movie *Movie) {
 	v.Check(len(movie.Genres) >= 1, "genres", "must contain at least 1 genre")
 	v.Check(len(movie.Genres) <= 5, "genres", "must not contain more than 5 genres")
 	v.Check(validator.Unique(movie.Genres), "genres", "must not contain duplicate values")
+	v.Check(!validator.In("", movie.Genres...), "genres", "must not contain empty values")
+
+	ValidateTitles(v, movie.Titles, movie.OriginalLanguage)
+	ValidateExternalIDs(v, movie.ExternalIDs)
 }
 
-// Insert method for creating a new record
+// Insert method for creating a new record along with its first revision
 func (m MovieModel) Insert(movie *Movie) (string, error) {
 	oid := primitive.NewObjectID()
 
 	args := Movie{
-		OID:       oid,
-		ID:        oid.Hex(),
-		CreatedAt: time.Now(),
-		Title:     movie.Title,
-		Year:      movie.Year,
-		Runtime:   movie.Runtime,
-		Genres:    movie.Genres,
+		OID:        oid,
+		ID:         oid.Hex(),
+		CreatedAt:  time.Now(),
+		Title:      movie.Title,
+		TitleKey:   NormalizeTitle(movie.Title),
+		TitleWords: TitleWords(movie.Title),
+		Year:       movie.Year,
+		Runtime:    movie.Runtime,
+		Genres:     movie.Genres,
+		CreatedBy:  movie.CreatedBy,
+		Version:    1,
+	}
+	if len(movie.Titles) != 0 {
+		args.Titles = movie.Titles
+		args.AltTitles = altTitles(movie.Titles)
+	}
+	args.OriginalLanguage = movie.OriginalLanguage
+	if !movie.ExternalIDs.Empty() {
+		args.ExternalIDs = movie.ExternalIDs
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := m.Collection.InsertOne(ctx, args)
+	err := m.withRevisions(ctx, func(sc mongo.SessionContext) ([]*Revision, error) {
+		_, err := m.Collection.InsertOne(sc, args)
+		if err != nil {
+			switch {
+			case mongo.IsDuplicateKeyError(err):
+				return nil, ErrDuplicateExternalID
+			default:
+				return nil, err
+			}
+		}
+
+		return []*Revision{NewRevision(&args, RevisionInsert, args.CreatedBy)}, nil
+	})
 	if err != nil {
 		return "", err
 	}
 
-	filter := bson.M{"_id": oid}
-	update := bson.M{"$inc": bson.M{"version": 1}}
-	_ = m.Collection.FindOneAndUpdate(ctx, filter, update)
+	movie.Version = 1
 	return oid.Hex(), nil
 }
 
+// InsertMany method for creating several records along with their first revisions in a single transaction, sets ID & Version of
+// every movie. Either all movies are created or none
+func (m MovieModel) InsertMany(movies []*Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(movies))
+	revisions := make([]*Revision, len(movies))
+	for i, movie := range movies {
+		movie.OID = primitive.NewObjectID()
+		movie.ID = movie.OID.Hex()
+		movie.CreatedAt = time.Now()
+		movie.TitleKey = NormalizeTitle(movie.Title)
+		movie.TitleWords = TitleWords(movie.Title)
+		movie.AltTitles = altTitles(movie.Titles)
+		movie.Version = 1
+		docs[i] = movie
+		revisions[i] = NewRevision(movie, RevisionInsert, movie.CreatedBy)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return m.withRevisions(ctx, func(sc mongo.SessionContext) ([]*Revision, error) {
+		_, err := m.Collection.InsertMany(sc, docs)
+		if err != nil {
+			switch {
+			case mongo.IsDuplicateKeyError(err):
+				return nil, ErrDuplicateExternalID
+			default:
+				return nil, err
+			}
+		}
+
+		return revisions, nil
+	})
+}
+
 // Get method for fetching a specific record
-func (m MovieModel) Get(id string) (*Movie, error) {
+func (m MovieModel) Get(id string, fields []string) (*Movie, error) {
 	var result *Movie
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, ErrRecordNotFound
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"_id": oid}
-	err = m.Collection.FindOne(ctx, filter).Decode(&result)
+	filter := bson.D{{Key: "_id", Value: oid}, notDeleted}
+	opts := options.FindOne()
+	if projection := movieProjection(fields); projection != nil {
+		opts.SetProjection(projection)
+	}
+
+	err = m.Collection.FindOne(ctx, filter, opts).Decode(&result)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
 	}
 
+	trimFields(result, fields)
+
 	return result, nil
 }
 
-// Update method for editing a specific record
-func (m MovieModel) Update(movie *Movie, id string) error {
-	oid, err := primitive.ObjectIDFromHex(id)
+// GetByTitle method for fetching a record by its exact title & release year
+func (m MovieModel) GetByTitle(title string, year int32) (*Movie, error) {
+	var result *Movie
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "title", Value: title}, {Key: "year", Value: year}, notDeleted}
+	err := m.Collection.FindOne(ctx, filter).Decode(&result)
 	if err != nil {
-		return err
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"title":   movie.Title,
-			"year":    movie.Year,
-			"runtime": movie.Runtime,
-			"genres":  movie.Genres},
-		"$inc": bson.M{"version": 1}}
+	return result, nil
+}
+
+// MovieUpdate holds targeted changes to a movie record, nil & empty fields are left untouched
+type MovieUpdate struct {
+	Title   *string
+	Year    *int32
+	Runtime *Runtime
+	// Titles replaces all localized titles, an empty non-nil map removes them. An empty OriginalLanguage removes it
+	Titles           map[string]string
+	OriginalLanguage *string
+	// ExternalIDs replaces all IDs, empty ones remove them
+	ExternalIDs *ExternalIDs
+	// Genres replaces the whole list, used if the change can't be expressed as a single insert or removal
+	Genres         []string
+	AddGenres      []string
+	AddGenresAt    int
+	RemoveGenres   []string
+	replacesGenres bool
+}
+
+// NewMovieUpdate returns the smallest MovieUpdate turning original into updated
+func NewMovieUpdate(original, updated *Movie) MovieUpdate {
+	var u MovieUpdate
+
+	if updated.Title != original.Title {
+		u.Title = &updated.Title
+	}
+	if updated.Year != original.Year {
+		u.Year = &updated.Year
+	}
+	if updated.Runtime != original.Runtime {
+		u.Runtime = &updated.Runtime
+	}
+	if !equalTitles(original.Titles, updated.Titles) {
+		u.Titles = make(map[string]string, len(updated.Titles))
+		for tag, title := range updated.Titles {
+			u.Titles[tag] = title
+		}
+	}
+	if updated.OriginalLanguage != original.OriginalLanguage {
+		u.OriginalLanguage = &updated.OriginalLanguage
+	}
+	if !equalExternalIDs(original.ExternalIDs, updated.ExternalIDs) {
+		u.ExternalIDs = &ExternalIDs{}
+		if updated.ExternalIDs != nil {
+			*u.ExternalIDs = *updated.ExternalIDs
+		}
+	}
+
+	before, after := original.Genres, updated.Genres
+	if equalStrings(before, after) {
+		return u
+	}
+
+	// Common prefix & suffix tell apart a single contiguous insert or removal
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix && before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	switch {
+	case prefix+suffix == len(before):
+		u.AddGenres = after[prefix : len(after)-suffix]
+		u.AddGenresAt = prefix
+		if validator.Unique(after) {
+			return u
+		}
+	case prefix+suffix == len(after):
+		// $pull removes by value, only safe if the removed genres don't remain in the list
+		u.RemoveGenres = before[prefix : len(before)-suffix]
+		if !containsAny(after, u.RemoveGenres) && validator.Unique(before) {
+			return u
+		}
+	}
+
+	u.AddGenres, u.AddGenresAt, u.RemoveGenres = nil, 0, nil
+	u.Genres = after
+	u.replacesGenres = true
+	return u
+}
+
+// Empty reports if the update doesn't change anything
+func (u MovieUpdate) Empty() bool {
+	return u.Title == nil && u.Titles == nil && u.OriginalLanguage == nil && u.Year == nil && u.Runtime == nil && u.ExternalIDs == nil && !u.replacesGenres && len(u.AddGenres) == 0 && len(u.RemoveGenres) == 0
+}
+
+// apply performs the update on movie
+func (u MovieUpdate) apply(movie *Movie) {
+	if u.Title != nil {
+		movie.Title = *u.Title
+		movie.TitleKey = NormalizeTitle(*u.Title)
+		movie.TitleWords = TitleWords(*u.Title)
+	}
+	if u.Titles != nil {
+		movie.Titles = nil
+		if len(u.Titles) != 0 {
+			movie.Titles = make(map[string]string, len(u.Titles))
+			for tag, title := range u.Titles {
+				movie.Titles[tag] = title
+			}
+		}
+		movie.AltTitles = altTitles(u.Titles)
+	}
+	if u.OriginalLanguage != nil {
+		movie.OriginalLanguage = *u.OriginalLanguage
+	}
+	if u.Year != nil {
+		movie.Year = *u.Year
+	}
+	if u.Runtime != nil {
+		movie.Runtime = *u.Runtime
+	}
+	if u.ExternalIDs != nil {
+		movie.ExternalIDs = nil
+		if !u.ExternalIDs.Empty() {
+			ids := *u.ExternalIDs
+			movie.ExternalIDs = &ids
+		}
+	}
+
+	switch {
+	case u.replacesGenres:
+		movie.Genres = append([]string{}, u.Genres...)
+	case len(u.AddGenres) != 0:
+		genres := append([]string{}, movie.Genres[:u.AddGenresAt]...)
+		genres = append(genres, u.AddGenres...)
+		movie.Genres = append(genres, movie.Genres[u.AddGenresAt:]...)
+	case len(u.RemoveGenres) != 0:
+		var genres []string
+		for _, genre := range movie.Genres {
+			if !containsAny([]string{genre}, u.RemoveGenres) {
+				genres = append(genres, genre)
+			}
+		}
+		movie.Genres = genres
+	}
+}
+
+// document returns the MongoDB update document, MongoDB doesn't allow $push & $pull on the same field in one update
+func (u MovieUpdate) document() bson.M {
+	set := bson.M{}
+	if u.Title != nil {
+		set["title"] = *u.Title
+		set["title_key"] = NormalizeTitle(*u.Title)
+		set["title_words"] = TitleWords(*u.Title)
+	}
+	if len(u.Titles) != 0 {
+		set["titles"] = u.Titles
+		set["alt_titles"] = altTitles(u.Titles)
+	}
+	if u.OriginalLanguage != nil && *u.OriginalLanguage != "" {
+		set["original_language"] = *u.OriginalLanguage
+	}
+	if u.Year != nil {
+		set["year"] = *u.Year
+	}
+	if u.Runtime != nil {
+		set["runtime"] = *u.Runtime
+	}
+	if u.ExternalIDs != nil && !u.ExternalIDs.Empty() {
+		set["external_ids"] = *u.ExternalIDs
+	}
+	if u.replacesGenres {
+		set["genres"] = u.Genres
+	}
+
+	update := bson.M{"$inc": bson.M{"version": 1}}
+	if len(set) != 0 {
+		update["$set"] = set
+	}
+	if len(u.AddGenres) != 0 {
+		update["$push"] = bson.M{"genres": bson.M{"$each": u.AddGenres, "$position": u.AddGenresAt}}
+	}
+	if len(u.RemoveGenres) != 0 {
+		update["$pull"] = bson.M{"genres": bson.M{"$in": u.RemoveGenres}}
+	}
+	unset := bson.M{}
+	if u.Titles != nil && len(u.Titles) == 0 {
+		unset["titles"] = ""
+		unset["alt_titles"] = ""
+	}
+	if u.OriginalLanguage != nil && *u.OriginalLanguage == "" {
+		unset["original_language"] = ""
+	}
+	if u.ExternalIDs != nil && u.ExternalIDs.Empty() {
+		unset["external_ids"] = ""
+	}
+	if len(unset) != 0 {
+		update["$unset"] = unset
+	}
+
+	return update
+}
+
+// Patch method applies targeted changes to a specific record & records change as a revision in the same transaction, movie must hold
+// the state the update was computed from & is updated in place. Fails with ErrEditConflict if movie.Version is no longer current
+func (m MovieModel) Patch(movie *Movie, update MovieUpdate, change Change) error {
+	oid, err := primitive.ObjectIDFromHex(movie.ID)
+	if err != nil {
+		return ErrRecordNotFound
+	}
+
+	filter := bson.D{{Key: "_id", Value: oid}, {Key: "version", Value: movie.Version}, notDeleted}
+
+	// The revision holds the state the update leaves the record in
+	updated := copyMovie(movie)
+	update.apply(updated)
+	updated.Version++
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err = m.Collection.UpdateByID(ctx, oid, update)
+	err = m.withRevisions(ctx, func(sc mongo.SessionContext) ([]*Revision, error) {
+		res, err := m.Collection.UpdateOne(sc, filter, update.document())
+		if err != nil {
+			switch {
+			case mongo.IsDuplicateKeyError(err):
+				return nil, ErrDuplicateExternalID
+			default:
+				return nil, err
+			}
+		}
+
+		if res.MatchedCount == 0 {
+			return nil, ErrEditConflict
+		}
+
+		return []*Revision{change.revision(updated)}, nil
+	})
 	if err != nil {
 		return err
 	}
 
+	update.apply(movie)
+	movie.Version++
 	return nil
 }
 
-// Delete method for removing a specific record
-func (m MovieModel) Delete(id string) error {
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(values, candidates []string) bool {
+	for _, value := range values {
+		if validator.In(value, candidates...) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete method moves a specific record to the trash along with a revision, it's hidden from Get & GetAll until restored or purged
+func (m MovieModel) Delete(id, userID string) (*Movie, error) {
+	var result *Movie
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return err
+		return nil, ErrRecordNotFound
 	}
 
-	delete := bson.M{"_id": oid}
+	filter := bson.D{{Key: "_id", Value: oid}, notDeleted}
+	update := bson.M{
+		"$set": bson.M{"deleted_at": time.Now(), "deleted_by": userID},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	res, _ := m.Collection.DeleteOne(ctx, delete)
-	if res.DeletedCount == 0 {
-		return mongo.ErrNoDocuments
+	err = m.withRevisions(ctx, func(sc mongo.SessionContext) ([]*Revision, error) {
+		err := m.Collection.FindOneAndUpdate(sc, filter, update, opts).Decode(&result)
+		if err != nil {
+			switch {
+			case errors.Is(err, mongo.ErrNoDocuments):
+				return nil, ErrRecordNotFound
+			default:
+				return nil, err
+			}
+		}
+
+		return []*Revision{NewRevision(result, RevisionDelete, userID)}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return result, nil
+}
+
+// Restore method moves a specific record out of the trash along with a revision, merged records can't be restored
+func (m MovieModel) Restore(id, userID string) (*Movie, error) {
+	var result *Movie
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrRecordNotFound
+	}
+
+	filter := bson.D{{Key: "_id", Value: oid}, {Key: "deleted_at", Value: bson.M{"$exists": true}}, notMerged}
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": "", "deleted_by": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = m.withRevisions(ctx, func(sc mongo.SessionContext) ([]*Revision, error) {
+		err := m.Collection.FindOneAndUpdate(sc, filter, update, opts).Decode(&result)
+		if err != nil {
+			switch {
+			case errors.Is(err, mongo.ErrNoDocuments):
+				return nil, ErrRecordNotFound
+			default:
+				return nil, err
+			}
+		}
+
+		return []*Revision{NewRevision(result, RevisionRestore, userID)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetTrash method to list records which were moved to the trash, merged records are left out
+func (m MovieModel) GetTrash(filters Filters) ([]*Movie, Metadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "deleted_at", Value: bson.M{"$exists": true}}, notMerged}
+	return m.find(ctx, filter, filters)
 }
 
 // GetAll method to list of all records
-func (m MovieModel) GetAll(title string, genres []string, filters Filters) ([]*Movie, Metadata, error) {
+func (m MovieModel) GetAll(query MovieQuery, filters Filters) ([]*Movie, Metadata, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var sort bson.D
-	switch filters.Sort {
-	case "title":
-		sort = bson.D{{Key: "title", Value: 1}}
-	case "-title":
-		sort = bson.D{{Key: "title", Value: -1}}
-	case "runtime":
-		sort = bson.D{{Key: "runtime", Value: 1}}
-	case "-runtime":
-		sort = bson.D{{Key: "runtime", Value: -1}}
-	case "year":
-		sort = bson.D{{Key: "year", Value: 1}}
-	case "-year":
-		sort = bson.D{{Key: "year", Value: -1}}
-	case "-id":
-		sort = bson.D{{Key: "id", Value: -1}}
-	default:
-		sort = bson.D{{Key: "id", Value: 1}}
+	filter := append(query.filter(), notDeleted)
+
+	movies, metadata, err := m.find(ctx, filter, filters)
+	if err != nil {
+		return nil, Metadata{}, err
 	}
 
-	findOpts := options.Find().SetSort(sort).SetLimit(int64(filters.limit())).SetSkip(int64(filters.offset()))
+	query.Highlight(movies...)
+	for _, movie := range movies {
+		trimFields(movie, filters.Fields)
+	}
 
-	var filter bson.D
-	if title != "" && len(genres) != 0 {
-		searchSlice := append([]string{title}, genres...)
-		searchString := strings.Join(searchSlice, ", ")
-		filter = bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: searchString}}}}
-	} else if title != "" && len(genres) == 0 {
-		filter = bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: title}}}}
-	} else if title == "" && len(genres) != 0 {
-		searchString := strings.Join(genres, ", ")
-		filter = bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: searchString}}}}
-	} else {
-		filter = bson.D{}
+	return movies, metadata, nil
+}
+
+// Export method calls fn for every record matching query in sort order, records are decoded one at a time
+func (m MovieModel) Export(query MovieQuery, sort string, fn func(movie *Movie) error) error {
+	// Exports are streamed to slow clients, allow much more time than for a single page
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	filter := append(query.filter(), notDeleted)
+
+	field, direction := movieSortField(sort)
+	sortDoc := bson.D{{Key: "_id", Value: direction}}
+	if field != "_id" {
+		sortDoc = bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+	}
+	opts := options.Find().SetSort(sortDoc)
+
+	cursor, err := m.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
 	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var movie Movie
+		err = cursor.Decode(&movie)
+		if err != nil {
+			return err
+		}
+
+		err = fn(&movie)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
 
-	countOpts := options.Count()
-	if filters.limit() != 0 {
-		countOpts = options.Count().SetLimit(int64(filters.limit())).SetSkip(int64(filters.offset()))
+// find returns one page of the records matching filter along with the pagination metadata
+func (m MovieModel) find(ctx context.Context, filter bson.D, filters Filters) ([]*Movie, Metadata, error) {
+	// _id breaks ties so every record has a stable position for keyset pagination
+	field, direction := movieSortField(filters.Sort)
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if field != "_id" {
+		sort = bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
 	}
-	count, err := m.Collection.CountDocuments(ctx, filter, countOpts)
+
+	skip, limit := int64(filters.offset()), int64(filters.limit())
+
+	// The sort field is fetched even if it's not selected as the next cursor is built from it
+	projection := movieProjection(filters.Fields, field)
+
+	// Count all matching records, not just the ones on the requested page
+	count, err := m.Collection.CountDocuments(ctx, filter)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
 
 	metadata := calculateMetadata(int(count), filters.Page, filters.PageSize)
 
-	cursor, err := m.Collection.Find(ctx, filter, findOpts)
+	var keyset bson.D
+	if filters.keyset() {
+		// Continue after the cursor & fetch one extra record to find out if there is a next page
+		if after := filters.after(); after != nil {
+			keyset, err = keysetFilter(field, direction, after)
+			if err != nil {
+				return nil, Metadata{}, err
+			}
+		}
+
+		if limit != 0 {
+			limit++
+		}
+	}
+
+	var cursor *mongo.Cursor
+	if field == "score" {
+		// The text score only exists as a field after the first stage, so paging by it needs an aggregation
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: filter}},
+			{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "textScore"}}}},
+		}
+		if projection != nil {
+			projection["score"] = 1
+			pipeline = append(pipeline, bson.D{{Key: "$project", Value: projection}})
+		}
+		if len(keyset) != 0 {
+			pipeline = append(pipeline, bson.D{{Key: "$match", Value: keyset}})
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+		if skip != 0 {
+			pipeline = append(pipeline, bson.D{{Key: "$skip", Value: skip}})
+		}
+		if limit != 0 {
+			pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+		}
+
+		cursor, err = m.Collection.Aggregate(ctx, pipeline)
+	} else {
+		findOpts := options.Find().SetSort(sort).SetLimit(limit).SetSkip(skip)
+		if textSearch(filter) {
+			if projection == nil {
+				projection = bson.M{}
+			}
+			projection["score"] = bson.M{"$meta": "textScore"}
+		}
+		if projection != nil {
+			findOpts.SetProjection(projection)
+		}
+
+		cursor, err = m.Collection.Find(ctx, append(filter, keyset...), findOpts)
+	}
 	if err != nil {
 		return nil, Metadata{}, err
 	}
@@ -203,5 +726,66 @@ func (m MovieModel) GetAll(title string, genres []string, filters Filters) ([]*M
 		return nil, Metadata{}, err
 	}
 
+	metadata.NextCursor = filters.nextCursor(results)
+	if metadata.NextCursor != "" {
+		results = results[:filters.limit()]
+		metadata.HasNext = true
+	}
+
 	return results, metadata, nil
 }
+
+// textSearch reports if filter contains a $text search, only then records have a text score
+func textSearch(filter bson.D) bool {
+	for _, e := range filter {
+		if e.Key == "$text" {
+			return true
+		}
+	}
+	return false
+}
+
+// movieSortField maps a SortSafelist value to the document field & sort direction
+func movieSortField(sort string) (string, int) {
+	direction := 1
+	if strings.HasPrefix(sort, "-") {
+		direction = -1
+	}
+
+	switch strings.TrimPrefix(sort, "-") {
+	case "title":
+		return "title", direction
+	case "runtime":
+		return "runtime", direction
+	case "year":
+		return "year", direction
+	case "rating":
+		return "rating", direction
+	case SortRelevance:
+		return "score", -1
+	default:
+		return "_id", direction
+	}
+}
+
+// keysetFilter matches all records positioned after the cursor in the given sort order
+func keysetFilter(field string, direction int, after *cursor) (bson.D, error) {
+	oid, err := primitive.ObjectIDFromHex(after.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	op := "$gt"
+	if direction < 0 {
+		op = "$lt"
+	}
+
+	if field == "_id" {
+		return bson.D{{Key: "_id", Value: bson.D{{Key: op, Value: oid}}}}, nil
+	}
+
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: field, Value: bson.D{{Key: op, Value: after.value()}}}},
+		bson.D{{Key: field, Value: after.value()}, {Key: "_id", Value: bson.D{{Key: op, Value: oid}}}},
+	}}}, nil
+}
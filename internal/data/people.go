@@ -0,0 +1,189 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Person struct holds a director, writer, cast member or other film crew
+type Person struct {
+	OID       primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	ID        string             `json:"id" bson:"id"`
+	CreatedAt time.Time          `json:"-" bson:"created_at"`
+	Name      string             `json:"name" bson:"name"`
+	NameKey   string             `json:"-" bson:"name_key"`
+	BirthYear int32              `json:"birth_year,omitempty" bson:"birth_year,omitempty"`
+	Bio       string             `json:"bio,omitempty" bson:"bio,omitempty"`
+	Version   int32              `json:"-" bson:"version"`
+}
+
+// ValidatePerson checks the fields of a person
+func ValidatePerson(v *validator.Validator, person *Person) {
+	v.Check(person.Name != "", "name", "must be provided")
+	v.Check(len(person.Name) <= 500, "name", "must not be more than 500 bytes long")
+
+	v.Check(person.BirthYear == 0 || person.BirthYear >= 1800, "birth_year", "must be greater than 1800")
+	v.Check(person.BirthYear <= int32(time.Now().Year()), "birth_year", "must not be in the future")
+
+	v.Check(len(person.Bio) <= 10_000, "bio", "must not be more than 10000 bytes long")
+}
+
+// PersonModel struct type wraps a MongoDB collection
+type PersonModel struct {
+	Collection *mongo.Collection
+}
+
+// Insert method for creating a new record
+func (m PersonModel) Insert(person *Person) (string, error) {
+	oid := primitive.NewObjectID()
+
+	person.OID = oid
+	person.ID = oid.Hex()
+	person.CreatedAt = time.Now()
+	person.NameKey = NormalizeTitle(person.Name)
+	person.Version = 1
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.Collection.InsertOne(ctx, person)
+	if err != nil {
+		return "", err
+	}
+
+	return person.ID, nil
+}
+
+// Get method for fetching a specific record
+func (m PersonModel) Get(id string) (*Person, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var person Person
+	err = m.Collection.FindOne(ctx, bson.M{"_id": oid}).Decode(&person)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &person, nil
+}
+
+// Update method for updating a specific record, fails with ErrEditConflict if it was changed since it was read
+func (m PersonModel) Update(person *Person) error {
+	filter := bson.M{"_id": person.OID, "version": person.Version}
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":       person.Name,
+			"name_key":   NormalizeTitle(person.Name),
+			"birth_year": person.BirthYear,
+			"bio":        person.Bio},
+		"$inc": bson.M{"version": 1}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := m.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrEditConflict
+	}
+
+	person.Version++
+	return nil
+}
+
+// Delete method for deleting a specific record, its credits are removed by the caller
+func (m PersonModel) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := m.Collection.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+
+	if res.DeletedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll method to list people whose name starts with name, ordered by name or id
+func (m PersonModel) GetAll(name string, filters Filters) ([]*Person, Metadata, error) {
+	filter := bson.M{}
+	if name != "" {
+		filter["name_key"] = bson.M{"$regex": "^" + regexp.QuoteMeta(NormalizeTitle(name))}
+	}
+
+	field, direction := personSortField(filters.Sort)
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if field != "_id" {
+		sort = bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := m.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	opts := options.Find().SetSort(sort).SetSkip(int64(filters.offset())).SetLimit(int64(filters.limit()))
+
+	cursor, err := m.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer cursor.Close(ctx)
+
+	people := []*Person{}
+	err = cursor.All(ctx, &people)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return people, calculateMetadata(int(count), filters.Page, filters.PageSize), nil
+}
+
+// personSortField maps a people SortSafelist value to the document field & sort direction
+func personSortField(sort string) (string, int) {
+	switch sort {
+	case "name":
+		return "name_key", 1
+	case "-name":
+		return "name_key", -1
+	case "-id":
+		return "_id", -1
+	default:
+		return "_id", 1
+	}
+}
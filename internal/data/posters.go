@@ -0,0 +1,217 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Poster struct holds one size of a movie poster, everything but Data is stored as the GridFS file metadata
+type Poster struct {
+	MovieID     string    `bson:"movie_id"`
+	Size        string    `bson:"size"`
+	ContentType string    `bson:"content_type"`
+	Width       int32     `bson:"width"`
+	Height      int32     `bson:"height"`
+	ETag        string    `bson:"etag"`
+	UploadedAt  time.Time `bson:"uploaded_at"`
+	Data        []byte    `bson:"-"`
+}
+
+// stamp sets the fields every stored size of an upload shares & the ETag derived from the image data
+func (p *Poster) stamp(movieID string, at time.Time) {
+	sum := sha256.Sum256(p.Data)
+
+	p.MovieID = movieID
+	p.UploadedAt = at
+	p.ETag = `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// PosterModel struct type wraps the GridFS bucket posters are stored in, a gridfs.Bucket isn't safe for concurrent use so one is opened per call
+type PosterModel struct {
+	Database *mongo.Database
+	Bucket   string
+}
+
+func (m PosterModel) bucket(timeout time.Duration) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(m.Database, options.GridFSBucket().SetName(m.Bucket))
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	bucket.SetReadDeadline(deadline)
+	bucket.SetWriteDeadline(deadline)
+
+	return bucket, nil
+}
+
+// Put method stores all sizes of a new poster & then removes the files of the previous one
+func (m PosterModel) Put(movieID string, posters []*Poster) error {
+	bucket, err := m.bucket(30 * time.Second)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	ids := bson.A{}
+
+	for _, poster := range posters {
+		poster.stamp(movieID, now)
+
+		stream, err := bucket.OpenUploadStream(movieID+"/"+poster.Size, options.GridFSUpload().SetMetadata(poster))
+		if err != nil {
+			return err
+		}
+
+		_, err = stream.Write(poster.Data)
+		if err != nil {
+			stream.Abort()
+			return err
+		}
+
+		err = stream.Close()
+		if err != nil {
+			return err
+		}
+
+		ids = append(ids, stream.FileID)
+	}
+
+	cursor, err := bucket.Find(bson.M{"metadata.movie_id": movieID, "_id": bson.M{"$nin": ids}})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	defer cursor.Close(ctx)
+
+	var previous []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err = cursor.All(ctx, &previous)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range previous {
+		err = bucket.Delete(file.ID)
+		if err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get method fetches one size of the current poster of a movie
+func (m PosterModel) Get(movieID, size string) (*Poster, error) {
+	bucket, err := m.bucket(10 * time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var file struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Metadata Poster             `bson:"metadata"`
+	}
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "uploadDate", Value: -1}})
+	err = bucket.GetFilesCollection().FindOne(ctx, bson.M{"metadata.movie_id": movieID, "metadata.size": size}, opts).Decode(&file)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	stream, err := bucket.OpenDownloadStream(file.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gridfs.ErrFileNotFound):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	defer stream.Close()
+
+	poster := file.Metadata
+	poster.Data, err = io.ReadAll(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	return &poster, nil
+}
+
+// deleteAll removes the files of every poster of the movies
+func (m PosterModel) deleteAll(movieIDs []string) error {
+	bucket, err := m.bucket(time.Minute)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := bucket.Find(bson.M{"metadata.movie_id": bson.M{"$in": movieIDs}})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	defer cursor.Close(ctx)
+
+	var files []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err = cursor.All(ctx, &files)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		err = bucket.Delete(file.ID)
+		if err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetPoster method records when the current poster of a movie was uploaded, it isn't an edit so the version stays the same
+func (m MovieModel) SetPoster(id string, at time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := m.Collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: oid}, notDeleted}, bson.M{"$set": bson.M{"poster_at": at}})
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
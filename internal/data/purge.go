@@ -0,0 +1,181 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// purgeBatchSize is the number of trashed movies removed per transaction
+const purgeBatchSize = 500
+
+// PurgeModel struct type wraps every collection holding data of a movie & the bucket of its posters, a purge removes the movie from all of them
+type PurgeModel struct {
+	Movies    *mongo.Collection
+	Credits   *mongo.Collection
+	Reviews   *mongo.Collection
+	Watchlist *mongo.Collection
+	Watched   *mongo.Collection
+	Revisions *mongo.Collection
+	Posters   PosterModel
+}
+
+// Purge method permanently removes movies which were moved to the trash before the cutoff & returns how many. Their credits, reviews,
+// watchlist entries, watched history & revisions are removed in the same transaction, as are the redirects of movies merged into them.
+// Merged movies are kept as redirects until then. GridFS can't take part in a transaction, so posters are removed once it committed
+func (m PurgeModel) Purge(cutoff time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var purged int64
+	for {
+		ids, count, err := m.purgeBatch(ctx, cutoff)
+		if err != nil {
+			return purged, err
+		}
+
+		if len(ids) == 0 {
+			return purged, nil
+		}
+
+		err = m.Posters.deleteAll(ids)
+		if err != nil {
+			return purged, err
+		}
+
+		purged += count
+	}
+}
+
+// purgeBatch removes up to purgeBatchSize trashed movies & everything referring to them in a single transaction. It returns the IDs of all
+// removed movies including redirects & the number of trashed ones
+func (m PurgeModel) purgeBatch(ctx context.Context, cutoff time.Time) ([]string, int64, error) {
+	session, err := m.Movies.Database().Client().StartSession()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer session.EndSession(ctx)
+
+	var ids []string
+	var count int64
+
+	// WithTransaction retries the whole callback on transient errors, so the results are only set once it succeeded
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		filter := bson.D{{Key: "deleted_at", Value: bson.M{"$lt": cutoff}}, notMerged}
+
+		trashed, err := m.movieIDs(sc, filter, options.Find().SetLimit(purgeBatchSize))
+		if err != nil || len(trashed) == 0 {
+			return nil, err
+		}
+
+		// Redirects to the purged movies would lead nowhere
+		redirects, err := m.movieIDs(sc, bson.M{"merged_into": bson.M{"$in": trashed}}, options.Find())
+		if err != nil {
+			return nil, err
+		}
+
+		removed := append(append(bson.A{}, trashed...), redirects...)
+
+		oids := make(bson.A, len(removed))
+		for i, id := range removed {
+			oid, err := primitive.ObjectIDFromHex(id.(string))
+			if err != nil {
+				return nil, err
+			}
+			oids[i] = oid
+		}
+
+		// A movie restored meanwhile makes this a write conflict, which WithTransaction retries
+		_, err = m.Movies.DeleteMany(sc, bson.M{"_id": bson.M{"$in": oids}})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, collection := range []*mongo.Collection{m.Credits, m.Reviews, m.Watched, m.Revisions} {
+			_, err = collection.DeleteMany(sc, bson.M{"movie_id": bson.M{"$in": removed}})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = m.removeFromWatchlists(sc, trashed)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = make([]string, len(removed))
+		for i, id := range removed {
+			ids[i] = id.(string)
+		}
+		count = int64(len(trashed))
+
+		return nil, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ids, count, nil
+}
+
+// movieIDs returns the IDs of the movies matching filter as hex strings, the form other collections refer to movies by
+func (m PurgeModel) movieIDs(ctx context.Context, filter interface{}, opts *options.FindOptions) (bson.A, error) {
+	cursor, err := m.Movies.Find(ctx, filter, opts.SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err = cursor.All(ctx, &docs)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(bson.A, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID.Hex()
+	}
+
+	return ids, nil
+}
+
+// removeFromWatchlists removes the movies from every watchlist & closes the gaps they leave. Entries are removed from the highest position
+// down, so the positions of the ones still to remove aren't shifted
+func (m PurgeModel) removeFromWatchlists(ctx context.Context, movieIDs bson.A) error {
+	opts := options.Find().SetSort(bson.D{{Key: "position", Value: -1}})
+
+	cursor, err := m.Watchlist.Find(ctx, bson.M{"movie_id": bson.M{"$in": movieIDs}}, opts)
+	if err != nil {
+		return err
+	}
+
+	var entries []WatchlistEntry
+	err = cursor.All(ctx, &entries)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		_, err = m.Watchlist.DeleteOne(ctx, bson.M{"user_id": entry.UserID, "movie_id": entry.MovieID})
+		if err != nil {
+			return err
+		}
+
+		_, err = m.Watchlist.UpdateMany(ctx,
+			bson.M{"user_id": entry.UserID, "position": bson.M{"$gt": entry.Position}},
+			bson.M{"$inc": bson.M{"position": -1}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
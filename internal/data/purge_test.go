@@ -0,0 +1,134 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryPurge(t *testing.T) {
+	models := NewMemoryModels()
+
+	insert := func(title string) *Movie {
+		t.Helper()
+
+		movie := &Movie{Title: title, Year: 1995, Runtime: 100, Genres: []string{"crime"}}
+
+		id, err := models.Movies.Insert(movie)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		movie.ID = id
+		return movie
+	}
+
+	purged, kept, source := insert("Heat"), insert("Ronin"), insert("Heat (1995)")
+
+	// The source is merged into the movie which gets purged, its redirect has to go too
+	if err := models.Merges.Merge(purged, MovieUpdate{}, []*Movie{source}, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	personID, err := models.People.Insert(&Person{Name: "Michael Mann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, movie := range []*Movie{purged, kept} {
+		if _, err := models.Credits.Insert(&Credit{MovieID: movie.ID, PersonID: personID, Role: "director"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := models.Reviews.Insert(&Review{MovieID: movie.ID, UserID: "user", Score: 8}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := models.Watched.Log("user", movie.ID, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if err := models.Posters.Put(movie.ID, []*Poster{{Size: "original", Data: []byte(movie.Title)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The purged movie sits between two others on the watchlist
+	for _, id := range []string{kept.ID, purged.ID} {
+		if _, err := models.Watchlist.Add("user", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	last := insert("Collateral")
+	if _, err := models.Watchlist.Add("user", last.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := models.Movies.Delete(purged.ID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Movies deleted after the cutoff stay in the trash
+	count, err := models.Purges.Purge(time.Now().Add(-time.Hour))
+	if err != nil || count != 0 {
+		t.Fatalf("Purge() before the cutoff = %d, %v, want 0", count, err)
+	}
+
+	count, err = models.Purges.Purge(time.Now().Add(time.Second))
+	if err != nil || count != 1 {
+		t.Fatalf("Purge() = %d, %v, want 1", count, err)
+	}
+
+	for _, id := range []string{purged.ID, source.ID} {
+		if _, err := models.Movies.Get(id, nil); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Get(%s) error = %v, want %v", id, err, ErrRecordNotFound)
+		}
+		if _, err := models.Movies.GetMergedInto(id); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("GetMergedInto(%s) error = %v, want %v", id, err, ErrRecordNotFound)
+		}
+		if revisions, err := models.Revisions.GetAll(id); err != nil || len(revisions) != 0 {
+			t.Errorf("revisions of %s = %d, %v, want none", id, len(revisions), err)
+		}
+	}
+
+	// Everything of the purged movie is gone, everything of the others is left
+	tests := []struct {
+		movie *Movie
+		want  int
+	}{
+		{purged, 0},
+		{kept, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.movie.Title, func(t *testing.T) {
+			credits, err := models.Credits.GetForMovie(tt.movie.ID)
+			if err != nil || len(credits) != tt.want {
+				t.Errorf("credits = %d, %v, want %d", len(credits), err, tt.want)
+			}
+
+			reviews, _, err := models.Reviews.GetAll(tt.movie.ID, Filters{Page: 1, PageSize: 20, Sort: "-created_at", SortSafelist: []string{"-created_at"}})
+			if err != nil || len(reviews) != tt.want {
+				t.Errorf("reviews = %d, %v, want %d", len(reviews), err, tt.want)
+			}
+
+			_, err = models.Posters.Get(tt.movie.ID, "original")
+			if (err == nil) != (tt.want == 1) {
+				t.Errorf("poster error = %v, want one only if kept", err)
+			}
+		})
+	}
+
+	watched, _, err := models.Watched.GetAll("user", Filters{Page: 1, PageSize: 20, Sort: "-last_watched_at", SortSafelist: []string{"-last_watched_at"}})
+	if err != nil || len(watched) != 1 || watched[0].MovieID != kept.ID {
+		t.Errorf("watched = %v, %v, want only %s", watched, err, kept.ID)
+	}
+
+	// The watchlist closes the gap of the purged movie
+	watchlist, err := models.Watchlist.GetAll("user")
+	if err != nil || len(watchlist) != 2 {
+		t.Fatalf("watchlist = %v, %v, want 2 entries", watchlist, err)
+	}
+	for i, want := range []string{kept.ID, last.ID} {
+		if watchlist[i].MovieID != want || watchlist[i].Position != int32(i+1) {
+			t.Errorf("watchlist[%d] = %s at %d, want %s at %d", i, watchlist[i].MovieID, watchlist[i].Position, want, i+1)
+		}
+	}
+}
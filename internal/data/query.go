@@ -0,0 +1,130 @@
+package data
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Constants for the ways a title can be matched
+const (
+	TitleMatchText   = "text"
+	TitleMatchPrefix = "prefix"
+)
+
+// MovieQuery holds the search & filter criteria of the movie list endpoints, zero values are ignored
+type MovieQuery struct {
+	Title      string
+	TitleMatch string
+	// Genres are full text search terms like the title, GenresAll & GenresAny match genres exactly
+	Genres     []string
+	GenresAll  []string
+	GenresAny  []string
+	YearMin    int32
+	YearMax    int32
+	RuntimeMin int32
+	RuntimeMax int32
+}
+
+// ValidateMovieQuery checks the filter values & ranges
+func ValidateMovieQuery(v *validator.Validator, q MovieQuery) {
+	v.Check(validator.In(q.TitleMatch, TitleMatchText, TitleMatchPrefix), "title_match", "must be text or prefix")
+
+	currentYear := int32(time.Now().Year())
+	v.Check(q.YearMin == 0 || (q.YearMin >= 1888 && q.YearMin <= currentYear), "year_min", "must be between 1888 and the current year")
+	v.Check(q.YearMax == 0 || (q.YearMax >= 1888 && q.YearMax <= currentYear), "year_max", "must be between 1888 and the current year")
+	v.Check(q.YearMin == 0 || q.YearMax == 0 || q.YearMin <= q.YearMax, "year_max", "must not be less than year_min")
+
+	v.Check(q.RuntimeMin >= 0, "runtime_min", "must not be negative")
+	v.Check(q.RuntimeMax >= 0, "runtime_max", "must not be negative")
+	v.Check(q.RuntimeMin == 0 || q.RuntimeMax == 0 || q.RuntimeMin <= q.RuntimeMax, "runtime_max", "must not be less than runtime_min")
+
+	v.Check(len(q.GenresAll) <= 5, "genres_all", "must not contain more than 5 genres")
+	v.Check(!validator.In("", q.GenresAll...), "genres_all", "must not contain empty values")
+	v.Check(len(q.GenresAny) <= 20, "genres_any", "must not contain more than 20 genres")
+	v.Check(!validator.In("", q.GenresAny...), "genres_any", "must not contain empty values")
+}
+
+// searchTerms returns the full text search terms of the query
+func (q MovieQuery) searchTerms() []string {
+	terms := q.Genres
+	if q.Title != "" && q.TitleMatch != TitleMatchPrefix {
+		terms = append([]string{q.Title}, q.Genres...)
+	}
+	return terms
+}
+
+// filter returns the MongoDB filter document, backed by the indexes of migration 7
+func (q MovieQuery) filter() bson.D {
+	filter := bson.D{}
+
+	if terms := q.searchTerms(); len(terms) != 0 {
+		filter = append(filter, bson.E{Key: "$text", Value: bson.D{{Key: "$search", Value: strings.Join(terms, ", ")}}})
+	}
+
+	// An anchored case-sensitive regex on the normalized title key only scans the matching index range
+	if q.Title != "" && q.TitleMatch == TitleMatchPrefix {
+		filter = append(filter, bson.E{Key: "title_key", Value: bson.M{"$regex": "^" + regexp.QuoteMeta(NormalizeTitle(q.Title))}})
+	}
+
+	if len(q.GenresAll) != 0 {
+		filter = append(filter, bson.E{Key: "genres", Value: bson.M{"$all": q.GenresAll}})
+	}
+	if len(q.GenresAny) != 0 {
+		filter = append(filter, bson.E{Key: "genres", Value: bson.M{"$in": q.GenresAny}})
+	}
+
+	if year := rangeFilter(q.YearMin, q.YearMax); year != nil {
+		filter = append(filter, bson.E{Key: "year", Value: year})
+	}
+	if runtime := rangeFilter(q.RuntimeMin, q.RuntimeMax); runtime != nil {
+		filter = append(filter, bson.E{Key: "runtime", Value: runtime})
+	}
+
+	return filter
+}
+
+// rangeFilter returns an inclusive range condition, nil if neither bound is set
+func rangeFilter(min, max int32) bson.M {
+	cond := bson.M{}
+	if min != 0 {
+		cond["$gte"] = min
+	}
+	if max != 0 {
+		cond["$lte"] = max
+	}
+
+	if len(cond) == 0 {
+		return nil
+	}
+	return cond
+}
+
+// match reports if movie matches the query, mirrors filter for the in-memory store
+func (q MovieQuery) match(movie *Movie) bool {
+	if terms := q.searchTerms(); len(terms) != 0 && !textMatch(strings.Join(terms, " "), append(append([]string{movie.Title}, altTitles(movie.Titles)...), movie.Genres...)...) {
+		return false
+	}
+
+	if q.Title != "" && q.TitleMatch == TitleMatchPrefix && !strings.HasPrefix(NormalizeTitle(movie.Title), NormalizeTitle(q.Title)) {
+		return false
+	}
+
+	for _, genre := range q.GenresAll {
+		if !validator.In(genre, movie.Genres...) {
+			return false
+		}
+	}
+	if len(q.GenresAny) != 0 && !containsAny(movie.Genres, q.GenresAny) {
+		return false
+	}
+
+	return inRange(movie.Year, q.YearMin, q.YearMax) && inRange(int32(movie.Runtime), q.RuntimeMin, q.RuntimeMax)
+}
+
+func inRange(value, min, max int32) bool {
+	return (min == 0 || value >= min) && (max == 0 || value <= max)
+}
@@ -0,0 +1,255 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicateReview error if a user reviews the same movie twice
+var ErrDuplicateReview = errors.New("duplicate review")
+
+// Review struct holds a user's rating of a movie & the optional review text
+type Review struct {
+	OID       primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	ID        string             `json:"id" bson:"id"`
+	MovieID   string             `json:"movie_id" bson:"movie_id"`
+	UserID    string             `json:"user_id" bson:"user_id"`
+	Score     int32              `json:"score" bson:"score"`
+	Text      string             `json:"text,omitempty" bson:"text,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	Version   int32              `json:"-" bson:"version"`
+}
+
+// ValidateReview checks the score & text of a review
+func ValidateReview(v *validator.Validator, review *Review) {
+	v.Check(review.Score != 0, "score", "must be provided")
+	v.Check(review.Score >= 1 && review.Score <= 10, "score", "must be between 1 and 10")
+
+	v.Check(len(review.Text) <= 10_000, "text", "must not be more than 10000 bytes long")
+}
+
+// ReviewModel struct type wraps the reviews collection & the movies collection holding the ratings computed from the reviews
+type ReviewModel struct {
+	Collection *mongo.Collection
+	Movies     *mongo.Collection
+}
+
+// Insert method for creating a new record, a unique index allows a single review per user & movie. The review & the new rating of the
+// movie are written in a single transaction
+func (m ReviewModel) Insert(review *Review) error {
+	oid := primitive.NewObjectID()
+
+	review.OID = oid
+	review.ID = oid.Hex()
+	review.CreatedAt = time.Now()
+	review.UpdatedAt = review.CreatedAt
+	review.Version = 1
+
+	return m.withRating(review.MovieID, func(sc mongo.SessionContext) error {
+		_, err := m.Collection.InsertOne(sc, review)
+		if err != nil {
+			switch {
+			case mongo.IsDuplicateKeyError(err):
+				return ErrDuplicateReview
+			default:
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetForUser method fetches the review a user wrote for a movie
+func (m ReviewModel) GetForUser(movieID, userID string) (*Review, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var review Review
+	err := m.Collection.FindOne(ctx, bson.M{"movie_id": movieID, "user_id": userID}).Decode(&review)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &review, nil
+}
+
+// Update method for updating a specific record together with the rating of its movie, fails with ErrEditConflict if it was changed
+// since it was read
+func (m ReviewModel) Update(review *Review) error {
+	filter := bson.M{"_id": review.OID, "version": review.Version}
+
+	updatedAt := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"score":      review.Score,
+			"text":       review.Text,
+			"updated_at": updatedAt},
+		"$inc": bson.M{"version": 1}}
+
+	err := m.withRating(review.MovieID, func(sc mongo.SessionContext) error {
+		res, err := m.Collection.UpdateOne(sc, filter, update)
+		if err != nil {
+			return err
+		}
+
+		if res.MatchedCount == 0 {
+			return ErrEditConflict
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	review.UpdatedAt = updatedAt
+	review.Version++
+	return nil
+}
+
+// Delete method for deleting a specific record together with its share of the rating of its movie, fails with ErrEditConflict if it
+// was changed since it was read
+func (m ReviewModel) Delete(review *Review) error {
+	return m.withRating(review.MovieID, func(sc mongo.SessionContext) error {
+		res, err := m.Collection.DeleteOne(sc, bson.M{"_id": review.OID, "version": review.Version})
+		if err != nil {
+			return err
+		}
+
+		if res.DeletedCount == 0 {
+			return ErrEditConflict
+		}
+
+		return nil
+	})
+}
+
+// withRating runs write & recomputes the rating of the movie from its reviews in a single transaction, so the rating never disagrees
+// with the reviews. WithTransaction retries on transient errors, so write must not change anything but the database
+func (m ReviewModel) withRating(movieID string, write func(sc mongo.SessionContext) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, err := m.Collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		err := write(sc)
+		if err != nil {
+			return nil, err
+		}
+
+		_, _, err = updateRating(sc, m.Collection, m.Movies, movieID)
+		return nil, err
+	})
+
+	return err
+}
+
+// GetAll method to list the reviews of a movie, newest first by default
+func (m ReviewModel) GetAll(movieID string, filters Filters) ([]*Review, Metadata, error) {
+	filter := bson.M{"movie_id": movieID}
+
+	field, direction := reviewSortField(filters.Sort)
+	sort := bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := m.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	opts := options.Find().SetSort(sort).SetSkip(int64(filters.offset())).SetLimit(int64(filters.limit()))
+
+	cursor, err := m.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer cursor.Close(ctx)
+
+	reviews := []*Review{}
+	err = cursor.All(ctx, &reviews)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return reviews, calculateMetadata(int(count), filters.Page, filters.PageSize), nil
+}
+
+// reviewSortField maps a reviews SortSafelist value to the document field & sort direction
+func reviewSortField(sort string) (string, int) {
+	switch sort {
+	case "created_at":
+		return "created_at", 1
+	case "score":
+		return "score", 1
+	case "-score":
+		return "score", -1
+	default:
+		return "created_at", -1
+	}
+}
+
+// updateRating sets the rating of a movie to the average of its reviews & returns their sum & count, fails with ErrRecordNotFound if
+// the movie doesn't exist
+func updateRating(ctx context.Context, reviews, movies *mongo.Collection, movieID string) (int64, int64, error) {
+	oid, err := primitive.ObjectIDFromHex(movieID)
+	if err != nil {
+		return 0, 0, ErrRecordNotFound
+	}
+
+	var rating struct {
+		Sum   int64 `bson:"sum"`
+		Count int64 `bson:"count"`
+	}
+
+	cursor, err := reviews.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"movie_id": movieID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "sum": bson.M{"$sum": "$score"}, "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	if cursor.Next(ctx) {
+		err = cursor.Decode(&rating)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	res, err := movies.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{
+		"rating_sum":   rating.Sum,
+		"rating_count": rating.Count,
+		"rating":       averageRating(rating.Sum, rating.Count),
+	}})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if res.MatchedCount == 0 {
+		return 0, 0, ErrRecordNotFound
+	}
+
+	return rating.Sum, rating.Count, nil
+}
@@ -0,0 +1,203 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Constants for the change recorded by a revision
+const (
+	RevisionInsert  = "insert"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionRevert  = "revert"
+	RevisionMerge   = "merge"
+)
+
+// Revision struct holds an immutable snapshot of a movie record after a change
+type Revision struct {
+	OID          primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	MovieID      string             `json:"movie_id" bson:"movie_id"`
+	Version      int32              `json:"version" bson:"version"`
+	Action       string             `json:"action" bson:"action"`
+	RevertedFrom int32              `json:"reverted_from,omitempty" bson:"reverted_from,omitempty"`
+	UserID       string             `json:"user_id" bson:"user_id"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	Movie        Movie              `json:"movie" bson:"movie"`
+}
+
+// Change struct describes who changed a movie & how, writes record it as a revision in the same transaction as the change
+type Change struct {
+	Action       string
+	UserID       string
+	RevertedFrom int32
+}
+
+// revision returns the revision recording the change which left movie in its current state
+func (c Change) revision(movie *Movie) *Revision {
+	revision := NewRevision(movie, c.Action, c.UserID)
+	revision.RevertedFrom = c.RevertedFrom
+	return revision
+}
+
+// FieldChange struct holds the values of a single movie field in two revisions
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// RevisionModel struct type wraps a MongoDB collection
+type RevisionModel struct {
+	Collection *mongo.Collection
+}
+
+// NewRevision returns a revision holding a copy of movie's current state
+func NewRevision(movie *Movie, action, userID string) *Revision {
+	snapshot := copyMovie(movie)
+	snapshot.OID = primitive.NilObjectID
+
+	return &Revision{
+		MovieID:   movie.ID,
+		Version:   movie.Version,
+		Action:    action,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+		Movie:     *snapshot,
+	}
+}
+
+// DiffMovies lists the fields which differ between two snapshots, compared by their JSON representation
+func DiffMovies(from, to *Movie) ([]FieldChange, error) {
+	a, err := movieFields(from)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := movieFields(to)
+	if err != nil {
+		return nil, err
+	}
+
+	for field := range b {
+		if _, ok := a[field]; !ok {
+			a[field] = nil
+		}
+	}
+
+	changes := []FieldChange{}
+	for field, value := range a {
+		// Ratings change with every review & aren't part of the edit history
+		if field == "id" || field == "rating" || field == "rating_count" || reflect.DeepEqual(value, b[field]) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, From: value, To: b[field]})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes, nil
+}
+
+func movieFields(movie *Movie) (map[string]interface{}, error) {
+	js, err := json.Marshal(movie)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	err = json.Unmarshal(js, &fields)
+	return fields, err
+}
+
+// insertRevisions adds revisions to collection in one round trip
+func insertRevisions(ctx context.Context, collection *mongo.Collection, revisions []*Revision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(revisions))
+	for i, revision := range revisions {
+		revision.OID = primitive.NewObjectID()
+		docs[i] = revision
+	}
+
+	_, err := collection.InsertMany(ctx, docs)
+	return err
+}
+
+// withRevisions runs write & inserts the revisions it returns in a single transaction, so no change to a movie is ever missing from
+// its history. WithTransaction retries on transient errors, so write must not change anything but the database. Transactions need a
+// replica set or sharded cluster, the API checks for one on startup
+func (m MovieModel) withRevisions(ctx context.Context, write func(sc mongo.SessionContext) ([]*Revision, error)) error {
+	session, err := m.Collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		revisions, err := write(sc)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, insertRevisions(sc, m.Revisions, revisions)
+	})
+
+	return err
+}
+
+// Get method for fetching a specific version of a movie
+func (m RevisionModel) Get(movieID string, version int32) (*Revision, error) {
+	var result *Revision
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := m.Collection.FindOne(ctx, bson.M{"movie_id": movieID, "version": version}).Decode(&result)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// GetAll method to list all revisions of a movie, oldest first
+func (m RevisionModel) GetAll(movieID string) ([]*Revision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+
+	cursor, err := m.Collection.Find(ctx, bson.M{"movie_id": movieID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []*Revision{}
+	err = cursor.All(ctx, &revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
@@ -3,6 +3,8 @@ package data
 import (
 	"errors"
 	"fmt"
+	"math"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -10,38 +12,154 @@ import (
 // ErrInvalidRuntimeFormat error if unable to parse or convert JSON string
 var ErrInvalidRuntimeFormat = errors.New("invalid runtime format")
 
+// Constants for the formats a runtime can be written in, RuntimeFormatMins is the default
+const (
+	RuntimeFormatMins    = "mins"
+	RuntimeFormatMinutes = "minutes"
+	RuntimeFormatISO8601 = "iso8601"
+	RuntimeFormatHuman   = "human"
+)
+
+// RuntimeFormats holds all supported output formats
+var RuntimeFormats = []string{RuntimeFormatMins, RuntimeFormatMinutes, RuntimeFormatISO8601, RuntimeFormatHuman}
+
+// Accepted runtime input, "112", "112 mins", "1h 52m" & ISO 8601 durations like "PT1H52M"
+var (
+	minutesRX = regexp.MustCompile(`^(\d+)(?: ?mins?)?$`)
+	humanRX   = regexp.MustCompile(`^(?:(\d+) ?h)? ?(?:(\d+) ?m)?$`)
+	iso8601RX = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?$`)
+)
+
+// runtimeExpected lists the accepted input formats for error messages
+const runtimeExpected = "expected minutes like 112 or 112 mins, hours and minutes like 1h 52m or an ISO 8601 duration like PT1H52M"
+
 // Runtime type for movie runtime
 type Runtime int32
 
-// MarshalJSON converts movie runtime to string
-func (r Runtime) MarshalJSON() ([]byte, error) {
-	jsonValue := fmt.Sprintf("%d mins", r)
+// runtimeError wraps ErrInvalidRuntimeFormat with what was expected instead
+func runtimeError(expected string) error {
+	return fmt.Errorf("%w, %s", ErrInvalidRuntimeFormat, expected)
+}
 
-	quotedJSONValue := strconv.Quote(jsonValue)
+// Format returns the runtime in one of the RuntimeFormats, unknown formats fall back to RuntimeFormatMins
+func (r Runtime) Format(format string) string {
+	hours, minutes := r/60, r%60
 
-	return []byte(quotedJSONValue), nil
+	switch format {
+	case RuntimeFormatMinutes:
+		return strconv.Itoa(int(r))
+	case RuntimeFormatISO8601:
+		switch {
+		case hours == 0:
+			return fmt.Sprintf("PT%dM", minutes)
+		case minutes == 0:
+			return fmt.Sprintf("PT%dH", hours)
+		default:
+			return fmt.Sprintf("PT%dH%dM", hours, minutes)
+		}
+	case RuntimeFormatHuman:
+		switch {
+		case hours == 0:
+			return fmt.Sprintf("%dm", minutes)
+		case minutes == 0:
+			return fmt.Sprintf("%dh", hours)
+		default:
+			return fmt.Sprintf("%dh %dm", hours, minutes)
+		}
+	default:
+		return fmt.Sprintf("%d mins", r)
+	}
+}
+
+// MarshalFormat returns the JSON value of the runtime in one of the RuntimeFormats, minutes are a number & all other formats a string
+func (r Runtime) MarshalFormat(format string) []byte {
+	if format == RuntimeFormatMinutes {
+		return []byte(r.Format(format))
+	}
+	return []byte(strconv.Quote(r.Format(format)))
+}
+
+// MarshalJSON converts movie runtime to string
+func (r Runtime) MarshalJSON() ([]byte, error) {
+	return r.MarshalFormat(RuntimeFormatMins), nil
 }
 
-// UnmarshalJSON converts string runtime to int
+// UnmarshalJSON converts a runtime string or a number of minutes to int, null is a no-op like for the built-in types
 func (r *Runtime) UnmarshalJSON(jsonValue []byte) error {
-	unquotedJSONValue, err := strconv.Unquote(string(jsonValue))
+	value := string(jsonValue)
+
+	switch {
+	case value == "null":
+		return nil
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return runtimeError(runtimeExpected)
+		}
+		value = unquoted
+	case !minutesRX.MatchString(value):
+		// Any other JSON value has to be a plain number of minutes
+		return runtimeError("expected a whole number of minutes or a string like 1h 52m")
+	}
+
+	// Assign parsed runtime to receiver
+	runtime, err := ParseRuntime(value)
 	if err != nil {
-		return ErrInvalidRuntimeFormat
+		return err
 	}
 
-	parts := strings.Split(unquotedJSONValue, " ")
+	*r = runtime
+	return nil
+}
+
+// ParseRuntime converts a runtime string to Runtime, used for JSON, CSV & query string input.
+// Accepts minutes like "112" or "112 mins", hours & minutes like "1h 52m" & ISO 8601 durations like "PT1H52M", all case-insensitive
+func ParseRuntime(s string) (Runtime, error) {
+	s = strings.TrimSpace(s)
+
+	var minutes int64
+	var err error
+
+	switch lower, upper := strings.ToLower(s), strings.ToUpper(s); {
+	case minutesRX.MatchString(lower):
+		minutes, err = sumParts(minutesRX.FindStringSubmatch(lower)[1:], 1)
 
-	if len(parts) != 2 || parts[1] != "mins" {
-		return ErrInvalidRuntimeFormat
+	case s != "" && humanRX.MatchString(lower):
+		minutes, err = sumParts(humanRX.FindStringSubmatch(lower)[1:], 60, 1)
+
+	case upper != "PT" && iso8601RX.MatchString(upper):
+		var seconds int64
+		seconds, err = sumParts(iso8601RX.FindStringSubmatch(upper)[1:], 3600, 60, 1)
+		if err == nil && seconds%60 != 0 {
+			return 0, runtimeError("ISO 8601 durations must be whole minutes")
+		}
+		minutes = seconds / 60
+
+	default:
+		return 0, runtimeError(runtimeExpected)
 	}
 
-	i, err := strconv.ParseInt(parts[0], 10, 32)
-	if err != nil {
-		return ErrInvalidRuntimeFormat
+	if err != nil || minutes > math.MaxInt32 {
+		return 0, runtimeError(fmt.Sprintf("must not be more than %d minutes", math.MaxInt32))
 	}
 
-	// Convert int32 to Runtime type & assign to receiver
-	*r = Runtime(i)
+	return Runtime(minutes), nil
+}
+
+// sumParts adds up the matched numbers multiplied by their unit, unmatched parts count as 0
+func sumParts(parts []string, units ...int64) (int64, error) {
+	var total int64
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
 
-	return nil
+		n, err := strconv.ParseInt(part, 10, 32)
+		if err != nil {
+			return 0, err
+		}
+		total += n * units[i]
+	}
+
+	return total, nil
 }
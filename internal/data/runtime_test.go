@@ -0,0 +1,188 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseRuntime(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Runtime
+		wantErr bool
+	}{
+		{"112", 112, false},
+		{"112 mins", 112, false},
+		{"112mins", 112, false},
+		{"1 min", 1, false},
+		{"112 MINS", 112, false},
+		{"  112 mins  ", 112, false},
+		{"0", 0, false},
+		{"1h 52m", 112, false},
+		{"1h52m", 112, false},
+		{"1 h 52 m", 112, false},
+		{"2h", 120, false},
+		{"52m", 52, false},
+		{"1H 52M", 112, false},
+		{"PT1H52M", 112, false},
+		{"PT2H", 120, false},
+		{"PT52M", 52, false},
+		{"PT6720S", 112, false},
+		{"PT1H51M60S", 112, false},
+		{"pt1h52m", 112, false},
+		{"2147483647", 2147483647, false},
+
+		{"", 0, true},
+		{"mins", 0, true},
+		{"-5", 0, true},
+		{"1.5", 0, true},
+		{"112 minutes", 0, true},
+		{"1h 52", 0, true},
+		{"52m 1h", 0, true},
+		{"PT", 0, true},
+		{"PT90S", 0, true},
+		{"P1D", 0, true},
+		{"PT1H52", 0, true},
+		{"2147483648", 0, true},
+		{"35791395h", 0, true},
+		{"99999999999", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRuntime(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRuntimeFormat) {
+					t.Errorf("ParseRuntime() = %d, %v, want %v", got, err, ErrInvalidRuntimeFormat)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseRuntime() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRuntime() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeFormat(t *testing.T) {
+	tests := []struct {
+		runtime Runtime
+		format  string
+		want    string
+	}{
+		{112, RuntimeFormatMins, "112 mins"},
+		{112, RuntimeFormatMinutes, "112"},
+		{112, RuntimeFormatISO8601, "PT1H52M"},
+		{112, RuntimeFormatHuman, "1h 52m"},
+		{120, RuntimeFormatISO8601, "PT2H"},
+		{120, RuntimeFormatHuman, "2h"},
+		{45, RuntimeFormatISO8601, "PT45M"},
+		{45, RuntimeFormatHuman, "45m"},
+		{0, RuntimeFormatISO8601, "PT0M"},
+		{0, RuntimeFormatHuman, "0m"},
+		{112, "", "112 mins"},
+		{112, "seconds", "112 mins"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+"/"+tt.format, func(t *testing.T) {
+			if got := tt.runtime.Format(tt.format); got != tt.want {
+				t.Errorf("Format(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+
+			// Every format is read back by ParseRuntime
+			parsed, err := ParseRuntime(tt.runtime.Format(tt.format))
+			if err != nil || parsed != tt.runtime {
+				t.Errorf("ParseRuntime(Format(%q)) = %d, %v, want %d", tt.format, parsed, err, tt.runtime)
+			}
+		})
+	}
+}
+
+func TestRuntimeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Runtime
+		wantErr bool
+	}{
+		{`112`, 112, false},
+		{`"112 mins"`, 112, false},
+		{`"1h 52m"`, 112, false},
+		{`"PT1H52M"`, 112, false},
+		{`null`, 7, false},
+		{`112.5`, 0, true},
+		{`-112`, 0, true},
+		{`true`, 0, true},
+		{`"1 day"`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			// null leaves the runtime as it was
+			got := Runtime(7)
+
+			err := got.UnmarshalJSON([]byte(tt.input))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRuntimeFormat) {
+					t.Errorf("UnmarshalJSON() error = %v, want %v", err, ErrInvalidRuntimeFormat)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("UnmarshalJSON() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMovieMarshalJSONRuntimeFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"", `"112 mins"`},
+		{RuntimeFormatMins, `"112 mins"`},
+		{RuntimeFormatMinutes, `112`},
+		{RuntimeFormatISO8601, `"PT1H52M"`},
+		{RuntimeFormatHuman, `"1h 52m"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			js, err := json.Marshal(&Movie{ID: "a", Title: "Heat", Runtime: 112, RuntimeFormat: tt.format})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(js, &fields); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			if got := string(fields["runtime"]); got != tt.want {
+				t.Errorf("runtime = %s, want %s", got, tt.want)
+			}
+			if got := string(fields["title"]); got != `"Heat"` {
+				t.Errorf("title = %s, want \"Heat\"", got)
+			}
+		})
+	}
+
+	// Movies without a runtime leave it out in every format
+	js, err := json.Marshal(Movie{ID: "a", RuntimeFormat: RuntimeFormatHuman})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(js) != `{"id":"a"}` {
+		t.Errorf("Marshal() = %s, want {\"id\":\"a\"}", js)
+	}
+}
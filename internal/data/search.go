@@ -0,0 +1,125 @@
+package data
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Weights of the fields in the movies text index, a title match in any language counts ten times as much as a genre match
+const (
+	TitleTextWeight     = 10
+	AltTitlesTextWeight = 10
+	GenresTextWeight    = 1
+)
+
+// SortRelevance is the sort value ordering search results by text score, best matches first
+const SortRelevance = "relevance"
+
+// TextSpan marks a matched part of a text, Start & End are character offsets & End is exclusive
+type TextSpan struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// HasSearchTerms reports if the query contains full text search terms, only then results have a score
+func (q MovieQuery) HasSearchTerms() bool {
+	return len(q.searchTerms()) != 0
+}
+
+// Highlight sets the matched title spans of search results, called again once their title was localized
+func (q MovieQuery) Highlight(movies ...*Movie) {
+	terms := q.searchTerms()
+	if len(terms) == 0 {
+		return
+	}
+
+	stems := make(map[string]bool)
+	for _, term := range terms {
+		for _, word := range splitWords(term) {
+			stems[stem(word)] = true
+		}
+	}
+
+	for _, movie := range movies {
+		movie.Highlights = matchSpans(movie.Title, stems)
+	}
+}
+
+// matchSpans returns the spans of the words in text whose stem is one of stems
+func matchSpans(text string, stems map[string]bool) []TextSpan {
+	spans := []TextSpan{}
+	runes := []rune(text)
+
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsNumber(runes[i])) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 {
+			word := string(runes[start:i])
+			if stems[stem(strings.ToLower(word))] {
+				spans = append(spans, TextSpan{Start: start, End: i, Text: word})
+			}
+			start = -1
+		}
+	}
+
+	return spans
+}
+
+// textScore approximates the MongoDB text score of movie for the query, used by the in-memory store
+func (q MovieQuery) textScore(movie *Movie) float64 {
+	terms := q.searchTerms()
+	if len(terms) == 0 {
+		return 0
+	}
+
+	var search []string
+	for _, term := range terms {
+		search = append(search, splitWords(term)...)
+	}
+
+	var genres []string
+	for _, genre := range movie.Genres {
+		genres = append(genres, splitWords(genre)...)
+	}
+
+	var alt []string
+	for _, title := range altTitles(movie.Titles) {
+		alt = append(alt, splitWords(title)...)
+	}
+
+	return fieldScore(search, splitWords(movie.Title), TitleTextWeight) + fieldScore(search, alt, AltTitlesTextWeight) +
+		fieldScore(search, genres, GenresTextWeight)
+}
+
+// fieldScore scores the words of a single field like MongoDB does, matches in short fields count more
+func fieldScore(search, words []string, weight float64) float64 {
+	if len(words) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int)
+	for _, word := range words {
+		counts[stem(word)]++
+	}
+
+	var score float64
+	seen := make(map[string]bool)
+	for _, term := range search {
+		s := stem(term)
+		if seen[s] || counts[s] == 0 {
+			continue
+		}
+		seen[s] = true
+
+		score += weight * (0.5*float64(counts[s])/float64(len(words)) + 0.5)
+	}
+
+	return score
+}
@@ -0,0 +1,159 @@
+package data
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeTitle folds case, strips diacritics & collapses whitespace, the result is stored as title_key for prefix matches
+func NormalizeTitle(title string) string {
+	var b strings.Builder
+
+	// Decomposed accented letters are a base letter followed by combining marks, dropping the marks leaves the base letter
+	for _, r := range norm.NFD.String(title) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// TitleWords returns the distinct normalized words of a title, stored as title_words for word prefix matches
+func TitleWords(title string) []string {
+	words := strings.FieldsFunc(NormalizeTitle(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	var unique []string
+	for _, word := range words {
+		if !containsAny(unique, []string{word}) {
+			unique = append(unique, word)
+		}
+	}
+
+	return unique
+}
+
+// suggestRank returns 0 if the title starts with the normalized prefix & 1 if one of its later words does
+func suggestRank(key, prefix string) int {
+	if strings.HasPrefix(key, prefix) {
+		return 0
+	}
+	return 1
+}
+
+// Suggest method returns up to limit titles starting with q or containing a word starting with q.
+// Title prefix matches are ranked before word prefix matches, ties are broken by popularity
+func (m MovieModel) Suggest(q string, limit int) ([]*Movie, error) {
+	prefix := NormalizeTitle(q)
+	if prefix == "" {
+		return []*Movie{}, nil
+	}
+
+	// Both branches are anchored regexes on indexed fields, the first word narrows multi-word prefixes
+	words := TitleWords(prefix)
+	match := bson.A{bson.M{"title_key": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}}}
+	if len(words) != 0 {
+		match = append(match, bson.M{"title_words": bson.M{"$regex": "^" + regexp.QuoteMeta(words[0])}})
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "$or", Value: match}, notDeleted}}},
+		{{Key: "$match", Value: bson.M{"title_key": bson.M{"$regex": `(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(prefix)}}}},
+		{{Key: "$addFields", Value: bson.M{"rank": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{bson.M{"$indexOfCP": bson.A{"$title_key", prefix}}, 0}}, 0, 1,
+		}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "rank", Value: 1}, {Key: "popularity", Value: -1}, {Key: "title_key", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: bson.M{"id": 1, "title": 1, "year": 1}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	cursor, err := m.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	movies := []*Movie{}
+	err = cursor.All(ctx, &movies)
+	if err != nil {
+		return nil, err
+	}
+
+	return movies, nil
+}
+
+// AddPopularity method adds delta to the popularity of a specific record, it's not an edit & leaves the version alone
+func (m MovieModel) AddPopularity(id string, delta int64) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := m.Collection.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$inc": bson.M{"popularity": delta}})
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// suggestMovies ranks & limits title suggestions the same way the Suggest aggregation does, used by the in-memory store
+func suggestMovies(movies []*Movie, q string, limit int) []*Movie {
+	prefix := NormalizeTitle(q)
+	if prefix == "" {
+		return []*Movie{}
+	}
+
+	wordPrefix := regexp.MustCompile(`(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(prefix))
+
+	matches := []*Movie{}
+	for _, movie := range movies {
+		if wordPrefix.MatchString(NormalizeTitle(movie.Title)) {
+			matches = append(matches, movie)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		keyA, keyB := NormalizeTitle(a.Title), NormalizeTitle(b.Title)
+
+		if rankA, rankB := suggestRank(keyA, prefix), suggestRank(keyB, prefix); rankA != rankB {
+			return rankA < rankB
+		}
+		if a.Popularity != b.Popularity {
+			return a.Popularity > b.Popularity
+		}
+		if keyA != keyB {
+			return keyA < keyB
+		}
+		return a.ID < b.ID
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base32"
+	"errors"
 	"time"
 
 	"github.com/BunnyTheLifeguard/greenlight/internal/validator"
@@ -121,7 +122,12 @@ func (m TokenModel) Get(tokenScope, tokenPlaintext string) (string, error) {
 
 	err := m.Collection.FindOne(ctx, filter).Decode(&result)
 	if err != nil {
-		return "", err
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return "", ErrRecordNotFound
+		default:
+			return "", err
+		}
 	}
 
 	return result.UserID.Hex(), nil
@@ -131,7 +137,7 @@ func (m TokenModel) Get(tokenScope, tokenPlaintext string) (string, error) {
 func (m TokenModel) DeleteAllForUser(scope, userID string) error {
 	uoid, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return err
+		return ErrRecordNotFound
 	}
 
 	delete := bson.M{"user_id": uoid, "scope": scope}
@@ -139,9 +145,13 @@ func (m TokenModel) DeleteAllForUser(scope, userID string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	res, _ := m.Collection.DeleteOne(ctx, delete)
+	res, err := m.Collection.DeleteOne(ctx, delete)
+	if err != nil {
+		return err
+	}
+
 	if res.DeletedCount == 0 {
-		return mongo.ErrNoDocuments
+		return ErrRecordNotFound
 	}
 
 	return nil
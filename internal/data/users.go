@@ -152,7 +152,12 @@ func (m UserModel) GetByEmail(email string) (*User, error) {
 	filter := bson.M{"email": email}
 	err := m.Collection.FindOne(ctx, filter).Decode(&result)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
 	}
 
 	return result, nil
@@ -162,7 +167,7 @@ func (m UserModel) GetByEmail(email string) (*User, error) {
 func (m UserModel) Update(user *User, id string) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return err
+		return ErrRecordNotFound
 	}
 
 	update := bson.M{
@@ -198,7 +203,7 @@ func (m UserModel) GetForToken(userID string) (*User, error) {
 	var result *User
 	oid, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, err
+		return nil, ErrRecordNotFound
 	}
 
 	filter := bson.M{"_id": oid}
@@ -208,7 +213,12 @@ func (m UserModel) GetForToken(userID string) (*User, error) {
 
 	err = m.Collection.FindOne(ctx, filter).Decode(&result)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
 	}
 
 	return result, nil
@@ -219,7 +229,7 @@ func (m UserModel) GetPermissions(userID string) ([]string, error) {
 	var result *User
 	oid, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, err
+		return nil, ErrRecordNotFound
 	}
 
 	filter := bson.M{"_id": oid}
@@ -229,7 +239,12 @@ func (m UserModel) GetPermissions(userID string) ([]string, error) {
 
 	err = m.Collection.FindOne(ctx, filter).Decode(&result)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
 	}
 
 	return result.Permissions, nil
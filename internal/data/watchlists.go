@@ -0,0 +1,357 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Watchlist errors
+var (
+	ErrAlreadyOnWatchlist = errors.New("movie already on watchlist")
+	ErrWatchlistMismatch  = errors.New("movies don't match the watchlist")
+)
+
+// WatchlistEntry struct holds a movie a user plans to watch, Position starts at 1
+type WatchlistEntry struct {
+	UserID   string    `json:"-" bson:"user_id"`
+	MovieID  string    `json:"movie_id" bson:"movie_id"`
+	Position int32     `json:"position" bson:"position"`
+	AddedAt  time.Time `json:"added_at" bson:"added_at"`
+	Movie    *Movie    `json:"movie,omitempty" bson:"movie,omitempty"`
+}
+
+// WatchedMovie struct holds the dates a user watched a movie on, every date after the first one is a rewatch
+type WatchedMovie struct {
+	UserID        string      `json:"-" bson:"user_id"`
+	MovieID       string      `json:"movie_id" bson:"movie_id"`
+	Dates         []time.Time `json:"watched_dates" bson:"dates"`
+	WatchCount    int32       `json:"watch_count" bson:"watch_count"`
+	RewatchCount  int32       `json:"rewatch_count" bson:"-"`
+	LastWatchedAt time.Time   `json:"last_watched_at" bson:"last_watched_at"`
+	Movie         *Movie      `json:"movie,omitempty" bson:"movie,omitempty"`
+}
+
+// WatchTotal struct holds the number of views & minutes watched of a genre or year
+type WatchTotal struct {
+	Value   interface{} `json:"value" bson:"_id"`
+	Views   int64       `json:"views" bson:"views"`
+	Minutes int64       `json:"minutes" bson:"minutes"`
+}
+
+// WatchSummary struct holds the totals of a user's watched history, ByYear is by the year a movie was watched in
+type WatchSummary struct {
+	Movies  int64        `json:"movies"`
+	Views   int64        `json:"views"`
+	Minutes int64        `json:"minutes"`
+	ByGenre []WatchTotal `json:"by_genre"`
+	ByYear  []WatchTotal `json:"by_year"`
+}
+
+// WatchlistModel struct type wraps the watchlist collection & the movies collection entries are joined with
+type WatchlistModel struct {
+	Collection *mongo.Collection
+	Movies     *mongo.Collection
+}
+
+// Add method appends a movie to the end of a user's watchlist
+func (m WatchlistModel) Add(userID, movieID string) (*WatchlistEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var last WatchlistEntry
+	opts := options.FindOne().SetSort(bson.D{{Key: "position", Value: -1}})
+	err := m.Collection.FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	entry := &WatchlistEntry{
+		UserID:   userID,
+		MovieID:  movieID,
+		Position: last.Position + 1,
+		AddedAt:  time.Now(),
+	}
+
+	_, err = m.Collection.InsertOne(ctx, entry)
+	if err != nil {
+		switch {
+		case mongo.IsDuplicateKeyError(err):
+			return nil, ErrAlreadyOnWatchlist
+		default:
+			return nil, err
+		}
+	}
+
+	return entry, nil
+}
+
+// Remove method removes a movie from a user's watchlist & closes the gap it leaves
+func (m WatchlistModel) Remove(userID, movieID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var entry WatchlistEntry
+	err := m.Collection.FindOneAndDelete(ctx, bson.M{"user_id": userID, "movie_id": movieID}).Decode(&entry)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	_, err = m.Collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "position": bson.M{"$gt": entry.Position}},
+		bson.M{"$inc": bson.M{"position": -1}},
+	)
+	return err
+}
+
+// Reorder method sets the order of a user's watchlist, movieIDs must hold every movie on it exactly once
+func (m WatchlistModel) Reorder(userID string, movieIDs []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := m.Collection.CountDocuments(ctx, bson.M{"user_id": userID, "movie_id": bson.M{"$in": movieIDs}})
+	if err != nil {
+		return err
+	}
+
+	total, err := m.Collection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return err
+	}
+
+	if count != total || int(count) != len(movieIDs) {
+		return ErrWatchlistMismatch
+	}
+
+	writes := make([]mongo.WriteModel, len(movieIDs))
+	for i, movieID := range movieIDs {
+		writes[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"user_id": userID, "movie_id": movieID}).
+			SetUpdate(bson.M{"$set": bson.M{"position": i + 1}})
+	}
+
+	_, err = m.Collection.BulkWrite(ctx, writes)
+	return err
+}
+
+// GetAll method lists a user's watchlist in order with the movies
+func (m WatchlistModel) GetAll(userID string) ([]*WatchlistEntry, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$sort", Value: bson.M{"position": 1}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         m.Movies.Name(),
+			"localField":   "movie_id",
+			"foreignField": "id",
+			"as":           "movie",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$movie", "preserveNullAndEmptyArrays": true}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*WatchlistEntry{}
+	err = cursor.All(ctx, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// WatchedModel struct type wraps the watched collection & the movies collection entries are joined with
+type WatchedModel struct {
+	Collection *mongo.Collection
+	Movies     *mongo.Collection
+}
+
+// Log method records that a user watched a movie at the given time, every further time counts as a rewatch
+func (m WatchedModel) Log(userID, movieID string, at time.Time) (*WatchedMovie, error) {
+	filter := bson.M{"user_id": userID, "movie_id": movieID}
+	update := bson.M{
+		"$push": bson.M{"dates": bson.M{"$each": bson.A{at}, "$sort": 1}},
+		"$inc":  bson.M{"watch_count": 1},
+		"$max":  bson.M{"last_watched_at": at},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var watched WatchedMovie
+	err := m.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&watched)
+	if err != nil {
+		return nil, err
+	}
+
+	watched.RewatchCount = watched.WatchCount - 1
+	return &watched, nil
+}
+
+// Remove method deletes a movie & all its dates from a user's watched history
+func (m WatchedModel) Remove(userID, movieID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := m.Collection.DeleteOne(ctx, bson.M{"user_id": userID, "movie_id": movieID})
+	if err != nil {
+		return err
+	}
+
+	if res.DeletedCount == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll method lists a user's watched movies with the movies, most recently watched first by default
+func (m WatchedModel) GetAll(userID string, filters Filters) ([]*WatchedMovie, Metadata, error) {
+	filter := bson.M{"user_id": userID}
+
+	field, direction := watchedSortField(filters.Sort)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := m.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: field, Value: direction}, {Key: "movie_id", Value: direction}}}},
+		{{Key: "$skip", Value: filters.offset()}},
+	}
+	if filters.limit() != 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: filters.limit()}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         m.Movies.Name(),
+			"localField":   "movie_id",
+			"foreignField": "id",
+			"as":           "movie",
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.M{"path": "$movie", "preserveNullAndEmptyArrays": true}}},
+	)
+
+	cursor, err := m.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer cursor.Close(ctx)
+
+	watched := []*WatchedMovie{}
+	err = cursor.All(ctx, &watched)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	for _, w := range watched {
+		w.RewatchCount = w.WatchCount - 1
+	}
+
+	return watched, calculateMetadata(int(count), filters.Page, filters.PageSize), nil
+}
+
+// Summary method totals a user's watched history, every view counts with the runtime of its movie
+func (m WatchedModel) Summary(userID string) (*WatchSummary, error) {
+	views := bson.M{"$sum": 1}
+	minutes := bson.M{"$sum": "$movie.runtime"}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         m.Movies.Name(),
+			"localField":   "movie_id",
+			"foreignField": "id",
+			"as":           "movie",
+		}}},
+		{{Key: "$unwind", Value: "$movie"}},
+		{{Key: "$unwind", Value: "$dates"}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": mongo.Pipeline{
+				{{Key: "$group", Value: bson.M{"_id": "$movie_id", "views": views, "minutes": minutes}}},
+				{{Key: "$group", Value: bson.M{
+					"_id":     nil,
+					"movies":  bson.M{"$sum": 1},
+					"views":   bson.M{"$sum": "$views"},
+					"minutes": bson.M{"$sum": "$minutes"},
+				}}},
+			},
+			"by_genre": mongo.Pipeline{
+				{{Key: "$unwind", Value: "$movie.genres"}},
+				{{Key: "$group", Value: bson.M{"_id": "$movie.genres", "views": views, "minutes": minutes}}},
+				{{Key: "$sort", Value: bson.D{{Key: "minutes", Value: -1}, {Key: "_id", Value: 1}}}},
+			},
+			"by_year": mongo.Pipeline{
+				{{Key: "$group", Value: bson.M{"_id": bson.M{"$year": "$dates"}, "views": views, "minutes": minutes}}},
+				{{Key: "$sort", Value: bson.M{"_id": 1}}},
+			},
+		}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Totals  []WatchSummary `bson:"totals"`
+		ByGenre []WatchTotal   `bson:"by_genre"`
+		ByYear  []WatchTotal   `bson:"by_year"`
+	}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &WatchSummary{ByGenre: []WatchTotal{}, ByYear: []WatchTotal{}}
+	if len(results) != 0 {
+		if len(results[0].Totals) != 0 {
+			summary.Movies = results[0].Totals[0].Movies
+			summary.Views = results[0].Totals[0].Views
+			summary.Minutes = results[0].Totals[0].Minutes
+		}
+		summary.ByGenre = append(summary.ByGenre, results[0].ByGenre...)
+		summary.ByYear = append(summary.ByYear, results[0].ByYear...)
+	}
+
+	return summary, nil
+}
+
+// watchedSortField maps a watched SortSafelist value to the document field & sort direction
+func watchedSortField(sort string) (string, int) {
+	switch sort {
+	case "last_watched_at":
+		return "last_watched_at", 1
+	case "watch_count":
+		return "watch_count", 1
+	case "-watch_count":
+		return "watch_count", -1
+	default:
+		return "last_watched_at", -1
+	}
+}
@@ -0,0 +1,172 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// decode returns the decoded JSON document js, failing the test if it isn't valid
+func decode(t *testing.T, js string) interface{} {
+	t.Helper()
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(js), &value); err != nil {
+		t.Fatalf("invalid test JSON %s: %v", js, err)
+	}
+	return value
+}
+
+// errAny stands for any error in the test tables
+var errAny = errors.New("any error")
+
+func TestApply(t *testing.T) {
+	const doc = `{"title":"Heat","genres":["crime","drama"],"ids":{"a/b":1,"m~n":2}}`
+
+	tests := []struct {
+		name    string
+		doc     string
+		ops     string
+		want    string
+		wantErr error
+	}{
+		{"add member", doc, `[{"op":"add","path":"/year","value":1995}]`, `{"title":"Heat","year":1995,"genres":["crime","drama"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"add replaces member", doc, `[{"op":"add","path":"/title","value":"Ronin"}]`, `{"title":"Ronin","genres":["crime","drama"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"add array element", doc, `[{"op":"add","path":"/genres/1","value":"action"}]`, `{"title":"Heat","genres":["crime","action","drama"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"add end of array", doc, `[{"op":"add","path":"/genres/-","value":"action"}]`, `{"title":"Heat","genres":["crime","drama","action"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"add after end of array", doc, `[{"op":"add","path":"/genres/2","value":"action"}]`, `{"title":"Heat","genres":["crime","drama","action"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"add root", doc, `[{"op":"add","path":"","value":{"title":"Ronin"}}]`, `{"title":"Ronin"}`, nil},
+		{"add null", doc, `[{"op":"add","path":"/year","value":null}]`, `{"title":"Heat","year":null,"genres":["crime","drama"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"add escaped slash", doc, `[{"op":"add","path":"/ids/a~1b","value":3}]`, `{"title":"Heat","genres":["crime","drama"],"ids":{"a/b":3,"m~n":2}}`, nil},
+		{"add escaped tilde", doc, `[{"op":"add","path":"/ids/m~0n","value":3}]`, `{"title":"Heat","genres":["crime","drama"],"ids":{"a/b":1,"m~n":3}}`, nil},
+		{"add tilde escape order", `{}`, `[{"op":"add","path":"/~01","value":1}]`, `{"~1":1}`, nil},
+		{"add missing parent", doc, `[{"op":"add","path":"/cast/0","value":"x"}]`, "", errAny},
+		{"add index out of bounds", doc, `[{"op":"add","path":"/genres/3","value":"x"}]`, "", errAny},
+		{"add leading zero index", doc, `[{"op":"add","path":"/genres/01","value":"x"}]`, "", errAny},
+		{"add without value", doc, `[{"op":"add","path":"/year"}]`, "", errAny},
+
+		{"remove member", doc, `[{"op":"remove","path":"/title"}]`, `{"genres":["crime","drama"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"remove array element", doc, `[{"op":"remove","path":"/genres/0"}]`, `{"title":"Heat","genres":["drama"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"remove escaped member", doc, `[{"op":"remove","path":"/ids/a~1b"}]`, `{"title":"Heat","genres":["crime","drama"],"ids":{"m~n":2}}`, nil},
+		{"remove end of array", doc, `[{"op":"remove","path":"/genres/-"}]`, "", errAny},
+		{"remove missing member", doc, `[{"op":"remove","path":"/year"}]`, "", errAny},
+		{"remove root", doc, `[{"op":"remove","path":""}]`, "", errAny},
+
+		{"replace member", doc, `[{"op":"replace","path":"/title","value":"Ronin"}]`, `{"title":"Ronin","genres":["crime","drama"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"replace array element", doc, `[{"op":"replace","path":"/genres/1","value":"thriller"}]`, `{"title":"Heat","genres":["crime","thriller"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"replace missing member", doc, `[{"op":"replace","path":"/year","value":1995}]`, "", errAny},
+
+		{"move member", doc, `[{"op":"move","from":"/title","path":"/name"}]`, `{"name":"Heat","genres":["crime","drama"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"move array element", doc, `[{"op":"move","from":"/genres/0","path":"/genres/-"}]`, `{"title":"Heat","genres":["drama","crime"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"move escaped member", doc, `[{"op":"move","from":"/ids/m~0n","path":"/ids/a~1b"}]`, `{"title":"Heat","genres":["crime","drama"],"ids":{"a/b":2}}`, nil},
+		{"move into own child", doc, `[{"op":"move","from":"/ids","path":"/ids/nested"}]`, "", errAny},
+		{"move missing member", doc, `[{"op":"move","from":"/year","path":"/released"}]`, "", errAny},
+
+		{"copy member", doc, `[{"op":"copy","from":"/genres","path":"/tags"}]`, `{"title":"Heat","genres":["crime","drama"],"tags":["crime","drama"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"copy is independent", doc, `[{"op":"copy","from":"/genres","path":"/tags"},{"op":"add","path":"/tags/-","value":"action"}]`, `{"title":"Heat","genres":["crime","drama"],"tags":["crime","drama","action"],"ids":{"a/b":1,"m~n":2}}`, nil},
+
+		{"test passes", doc, `[{"op":"test","path":"/genres","value":["crime","drama"]},{"op":"remove","path":"/title"}]`, `{"genres":["crime","drama"],"ids":{"a/b":1,"m~n":2}}`, nil},
+		{"test escaped member", doc, `[{"op":"test","path":"/ids/a~1b","value":1}]`, doc, nil},
+		{"test fails", doc, `[{"op":"test","path":"/title","value":"Ronin"}]`, "", ErrTestFailed},
+		{"test number type", doc, `[{"op":"test","path":"/ids/a~1b","value":"1"}]`, "", ErrTestFailed},
+		{"test end of array", doc, `[{"op":"test","path":"/genres/-","value":"drama"}]`, "", errAny},
+		{"failed test discards earlier operations", doc, `[{"op":"remove","path":"/title"},{"op":"test","path":"/genres/0","value":"drama"}]`, "", ErrTestFailed},
+
+		{"invalid pointer", doc, `[{"op":"remove","path":"title"}]`, "", errAny},
+		{"unsupported operation", doc, `[{"op":"merge","path":"/title","value":"Ronin"}]`, "", errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []Operation
+			if err := json.Unmarshal([]byte(tt.ops), &ops); err != nil {
+				t.Fatalf("invalid test operations %s: %v", tt.ops, err)
+			}
+
+			original := decode(t, tt.doc)
+			input := decode(t, tt.doc)
+
+			got, err := Apply(input, ops)
+
+			switch {
+			case tt.wantErr == errAny:
+				if err == nil {
+					t.Fatalf("Apply() = %v, want an error", got)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("Apply() error = %v", err)
+			default:
+				if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+					t.Errorf("Apply() = %v, want %v", got, want)
+				}
+			}
+
+			// The document passed in is never changed, even if an operation fails
+			if !reflect.DeepEqual(input, original) {
+				t.Errorf("Apply() changed its input to %v", input)
+			}
+		})
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+		wantErr bool
+	}{
+		{"", []string{}, false},
+		{"/", []string{""}, false},
+		{"/title", []string{"title"}, false},
+		{"/genres/0", []string{"genres", "0"}, false},
+		{"/a~1b/m~0n", []string{"a/b", "m~n"}, false},
+		{"/~01", []string{"~1"}, false},
+		{"/~10", []string{"/0"}, false},
+		{"title", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			got, err := parsePointer(tt.pointer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePointer() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePointer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		patch  string
+		want   string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove member", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"replace array", `{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{"nested object", `{"a":{"b":"c","d":"e"}}`, `{"a":{"d":null,"f":"g"}}`, `{"a":{"b":"c","f":"g"}}`},
+		{"object replaces scalar", `{"a":"b"}`, `{"a":{"c":"d"}}`, `{"a":{"c":"d"}}`},
+		{"non-object patch", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"non-object target", `["a"]`, `{"b":"c"}`, `{"b":"c"}`},
+		{"null removes missing member", `{"a":"b"}`, `{"c":null}`, `{"a":"b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergePatch(decode(t, tt.target), decode(t, tt.patch))
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("MergePatch() = %v, want %v", got, want)
+			}
+		})
+	}
+}
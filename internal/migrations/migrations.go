@@ -0,0 +1,226 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RecordCollection is the collection holding one document per applied migration
+const RecordCollection = "migrations"
+
+// ErrSchemaBehind is returned by Check if there are migrations which have not been applied yet
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+// Collections holds the names of the collections the migrations operate on, Posters names a GridFS bucket
+type Collections struct {
+	Movies    string
+	Users     string
+	Tokens    string
+	Revisions string
+	People    string
+	Credits   string
+	Reviews   string
+	Watchlist string
+	Watched   string
+	Posters   string
+}
+
+// Migration is a single versioned schema or data change written in Go
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database, c Collections) error
+	Down        func(ctx context.Context, db *mongo.Database, c Collections) error
+}
+
+// Record is the document stored in the migrations collection for an applied migration
+type Record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Status of a single migration
+type Status struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+}
+
+// Migrator applies & reverts migrations against a database
+type Migrator struct {
+	db          *mongo.Database
+	collections Collections
+	records     *mongo.Collection
+	migrations  []Migration
+}
+
+// New returns a Migrator for all registered migrations
+func New(db *mongo.Database, c Collections) *Migrator {
+	ms := append([]Migration{}, all...)
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+
+	return &Migrator{
+		db:          db,
+		collections: c,
+		records:     db.Collection(RecordCollection),
+		migrations:  ms,
+	}
+}
+
+// Latest returns the version of the newest registered migration
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// applied returns the applied records keyed by version
+func (m *Migrator) applied(ctx context.Context) (map[int]Record, error) {
+	cursor, err := m.records.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]Record, len(records))
+	for _, record := range records {
+		result[record.Version] = record
+	}
+
+	return result, nil
+}
+
+// Version returns the highest applied migration version, 0 if none have been applied
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	var record Record
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})
+	err := m.records.FindOne(ctx, bson.D{}, opts).Decode(&record)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return 0, nil
+		default:
+			return 0, err
+		}
+	}
+
+	return record.Version, nil
+}
+
+// Check returns ErrSchemaBehind if any registered migration has not been applied
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			return fmt.Errorf("%w: migration %d (%s) is pending, run the migrate up command", ErrSchemaBehind, migration.Version, migration.Description)
+		}
+	}
+
+	return nil
+}
+
+// Status lists every registered migration & whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		record, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Applied:     ok,
+			AppliedAt:   record.AppliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Up applies up to n pending migrations in ascending order, all of them if n is 0
+func (m *Migrator) Up(ctx context.Context, n int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if n > 0 && len(done) == n {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err = migration.Up(ctx, m.db, m.collections)
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s) up: %w", migration.Version, migration.Description, err)
+		}
+
+		_, err = m.records.InsertOne(ctx, Record{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		})
+		if err != nil {
+			return done, err
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the n most recently applied migrations in descending order
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < n; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err = migration.Down(ctx, m.db, m.collections)
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s) down: %w", migration.Version, migration.Description, err)
+		}
+
+		_, err = m.records.DeleteOne(ctx, bson.M{"_id": migration.Version})
+		if err != nil {
+			return done, err
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
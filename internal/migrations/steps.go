@@ -0,0 +1,496 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Text index weights as created by migrations 9 & 15. Like everything else a migration depends on they're copied here rather than
+// taken from the data package, so changing the weights there needs a new migration instead of silently altering applied ones
+const (
+	titleTextWeight     = 10
+	altTitlesTextWeight = 10
+	genresTextWeight    = 1
+)
+
+// all registered migrations, append new ones with the next version number & never edit applied ones. Version 4 backfilled an
+// empty created_by & was dropped, a missing created_by already means the creator is unknown. Its number is never reused
+var all = []Migration{
+	{
+		Version:     1,
+		Description: "create movies text index on title & genres",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			_, err := db.Collection(c.Movies).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "title", Value: "text"}, {Key: "genres", Value: "text"}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			return dropIndex(ctx, db.Collection(c.Movies), "title_text_genres_text")
+		},
+	},
+	{
+		Version:     2,
+		Description: "create unique case-insensitive users name & email indexes",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			collation := &options.Collation{Locale: "en", Strength: 2}
+			_, err := db.Collection(c.Users).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.M{"name": 1},
+					Options: options.Index().SetUnique(true).SetCollation(collation),
+				},
+				{
+					Keys:    bson.M{"email": 1},
+					Options: options.Index().SetUnique(true).SetCollation(collation),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			err := dropIndex(ctx, db.Collection(c.Users), "name_1")
+			if err != nil {
+				return err
+			}
+			return dropIndex(ctx, db.Collection(c.Users), "email_1")
+		},
+	},
+	{
+		Version:     3,
+		Description: "create tokens TTL index on expiry",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			_, err := db.Collection(c.Tokens).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "expiry", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(1),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			return dropIndex(ctx, db.Collection(c.Tokens), "expiry_1")
+		},
+	},
+	{
+		Version:     5,
+		Description: "create movies deleted_at index for the trash listing & purge",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			_, err := db.Collection(c.Movies).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+				Options: options.Index().SetSparse(true),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			return dropIndex(ctx, db.Collection(c.Movies), "deleted_at_1")
+		},
+	},
+	{
+		Version:     6,
+		Description: "create unique revisions index on movie_id & version",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			_, err := db.Collection(c.Revisions).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "movie_id", Value: 1}, {Key: "version", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			return dropIndex(ctx, db.Collection(c.Revisions), "movie_id_1_version_1")
+		},
+	},
+	{
+		Version:     7,
+		Description: "backfill movies title_key & create indexes for the structured list filters",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			// title_key holds the lower case title for case-insensitive prefix matches
+			_, err := db.Collection(c.Movies).UpdateMany(ctx,
+				bson.M{"title_key": bson.M{"$exists": false}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{"title_key": bson.M{"$toLower": "$title"}}}}},
+			)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Movies).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "title_key", Value: 1}, {Key: "year", Value: 1}}},
+				{Keys: bson.D{{Key: "genres", Value: 1}, {Key: "year", Value: 1}}},
+				{Keys: bson.D{{Key: "year", Value: 1}, {Key: "runtime", Value: 1}}},
+				{Keys: bson.D{{Key: "runtime", Value: 1}}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			for _, name := range []string{"title_key_1_year_1", "genres_1_year_1", "year_1_runtime_1", "runtime_1"} {
+				err := dropIndex(ctx, db.Collection(c.Movies), name)
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err := db.Collection(c.Movies).UpdateMany(ctx,
+				bson.M{},
+				bson.M{"$unset": bson.M{"title_key": ""}},
+			)
+			return err
+		},
+	},
+	{
+		Version:     8,
+		Description: "backfill movies normalized title_key & title_words and create the title suggest index",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			// Diacritics can't be stripped in an update pipeline, so every title is normalized in Go
+			cursor, err := db.Collection(c.Movies).Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"title": 1}))
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+
+			for cursor.Next(ctx) {
+				var movie struct {
+					ID    primitive.ObjectID `bson:"_id"`
+					Title string             `bson:"title"`
+				}
+				if err := cursor.Decode(&movie); err != nil {
+					return err
+				}
+
+				_, err = db.Collection(c.Movies).UpdateByID(ctx, movie.ID, bson.M{"$set": bson.M{
+					"title_key":   normalizeTitle(movie.Title),
+					"title_words": titleWords(movie.Title),
+				}})
+				if err != nil {
+					return err
+				}
+			}
+			if err := cursor.Err(); err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Movies).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "title_words", Value: 1}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			err := dropIndex(ctx, db.Collection(c.Movies), "title_words_1")
+			if err != nil {
+				return err
+			}
+
+			// title_key stays normalized, it's still a valid lower case key for migration 7
+			_, err = db.Collection(c.Movies).UpdateMany(ctx,
+				bson.M{},
+				bson.M{"$unset": bson.M{"title_words": ""}},
+			)
+			return err
+		},
+	},
+	{
+		Version:     9,
+		Description: "recreate movies text index weighting title over genres",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			// Index options can't be changed in place, the weighted index replaces the one of migration 1 under the same name
+			err := dropIndex(ctx, db.Collection(c.Movies), "title_text_genres_text")
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Movies).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "title", Value: "text"}, {Key: "genres", Value: "text"}},
+				Options: options.Index().SetName("title_text_genres_text").SetWeights(bson.M{
+					"title":  titleTextWeight,
+					"genres": genresTextWeight,
+				}),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			err := dropIndex(ctx, db.Collection(c.Movies), "title_text_genres_text")
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Movies).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "title", Value: "text"}, {Key: "genres", Value: "text"}},
+			})
+			return err
+		},
+	},
+	{
+		Version:     10,
+		Description: "create people & credits indexes used by the credits & filmography lookups",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			_, err := db.Collection(c.People).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{Keys: bson.D{{Key: "name_key", Value: 1}}},
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Credits).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "movie_id", Value: 1}, {Key: "billing", Value: 1}}},
+				{Keys: bson.D{{Key: "person_id", Value: 1}}},
+			})
+			if err != nil {
+				return err
+			}
+
+			// Credits reference movies by their id string, the filmography lookup joins on it
+			_, err = db.Collection(c.Movies).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "id", Value: 1}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			indexes := []struct {
+				collection string
+				name       string
+			}{
+				{c.People, "id_1"},
+				{c.People, "name_key_1"},
+				{c.Credits, "movie_id_1_billing_1"},
+				{c.Credits, "person_id_1"},
+				{c.Movies, "id_1"},
+			}
+
+			for _, index := range indexes {
+				err := dropIndex(ctx, db.Collection(index.collection), index.name)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		Version:     11,
+		Description: "create reviews indexes & backfill movies rating for the rating sort",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			// The unique index enforces a single review per user & movie
+			_, err := db.Collection(c.Reviews).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "movie_id", Value: 1}, {Key: "user_id", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{Keys: bson.D{{Key: "movie_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Movies).UpdateMany(ctx,
+				bson.M{"rating": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"rating": 0, "rating_count": 0, "rating_sum": 0}},
+			)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Movies).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "rating", Value: 1}, {Key: "_id", Value: 1}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			for _, name := range []string{"movie_id_1_user_id_1", "movie_id_1_created_at_-1"} {
+				err := dropIndex(ctx, db.Collection(c.Reviews), name)
+				if err != nil {
+					return err
+				}
+			}
+
+			err := dropIndex(ctx, db.Collection(c.Movies), "rating_1__id_1")
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Movies).UpdateMany(ctx,
+				bson.M{},
+				bson.M{"$unset": bson.M{"rating": "", "rating_count": "", "rating_sum": ""}},
+			)
+			return err
+		},
+	},
+	{
+		Version:     12,
+		Description: "create watchlist & watched history indexes",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			// The unique indexes keep a movie on a user's watchlist & in their history at most once
+			_, err := db.Collection(c.Watchlist).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "movie_id", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "position", Value: 1}}},
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Watched).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "movie_id", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_watched_at", Value: -1}}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			for _, name := range []string{"user_id_1_movie_id_1", "user_id_1_position_1"} {
+				err := dropIndex(ctx, db.Collection(c.Watchlist), name)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, name := range []string{"user_id_1_movie_id_1", "user_id_1_last_watched_at_-1"} {
+				err := dropIndex(ctx, db.Collection(c.Watched), name)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		Version:     13,
+		Description: "create posters files index on movie & size",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			// GridFS creates its own indexes on the first upload, this one finds the current poster of a movie in a size
+			_, err := db.Collection(c.Posters+".files").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "metadata.movie_id", Value: 1}, {Key: "metadata.size", Value: 1}, {Key: "uploadDate", Value: -1}},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			return dropIndex(ctx, db.Collection(c.Posters+".files"), "metadata.movie_id_1_metadata.size_1_uploadDate_-1")
+		},
+	},
+	{
+		Version:     14,
+		Description: "create unique sparse movies external ID indexes",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			// Sparse indexes skip movies without the ID, so any number of movies can leave it out
+			unique := options.Index().SetUnique(true).SetSparse(true)
+			_, err := db.Collection(c.Movies).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.M{"external_ids.imdb": 1}, Options: unique},
+				{Keys: bson.M{"external_ids.tmdb": 1}, Options: unique},
+				{Keys: bson.M{"external_ids.wikidata": 1}, Options: unique},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			for _, name := range []string{"external_ids.imdb_1", "external_ids.tmdb_1", "external_ids.wikidata_1"} {
+				err := dropIndex(ctx, db.Collection(c.Movies), name)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		Version:     15,
+		Description: "backfill movies alt_titles & recreate the text index including localized titles",
+		Up: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			// The text index ignores the order of alt_titles, so the stored order of the titles is kept
+			_, err := db.Collection(c.Movies).UpdateMany(ctx,
+				bson.M{"titles": bson.M{"$exists": true}, "alt_titles": bson.M{"$exists": false}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{"alt_titles": bson.M{"$map": bson.M{
+					"input": bson.M{"$objectToArray": "$titles"},
+					"in":    "$$this.v",
+				}}}}}},
+			)
+			if err != nil {
+				return err
+			}
+
+			// A collection has at most one text index, so the one of migration 9 has to go first
+			err = dropIndex(ctx, db.Collection(c.Movies), "title_text_genres_text")
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Movies).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "title", Value: "text"}, {Key: "alt_titles", Value: "text"}, {Key: "genres", Value: "text"}},
+				Options: options.Index().SetName("title_text_alt_titles_text_genres_text").SetWeights(bson.M{
+					"title":      titleTextWeight,
+					"alt_titles": altTitlesTextWeight,
+					"genres":     genresTextWeight,
+				}),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database, c Collections) error {
+			err := dropIndex(ctx, db.Collection(c.Movies), "title_text_alt_titles_text_genres_text")
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection(c.Movies).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "title", Value: "text"}, {Key: "genres", Value: "text"}},
+				Options: options.Index().SetName("title_text_genres_text").SetWeights(bson.M{
+					"title":  titleTextWeight,
+					"genres": genresTextWeight,
+				}),
+			})
+			return err
+		},
+	},
+}
+
+// dropIndex removes an index by name, a missing index is not an error
+func dropIndex(ctx context.Context, coll *mongo.Collection, name string) error {
+	_, err := coll.Indexes().DropOne(ctx, name)
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound") {
+		return nil
+	}
+
+	return err
+}
+
+// normalizeTitle is the title_key normalization of migration 8, it folds case, strips diacritics & collapses whitespace
+func normalizeTitle(title string) string {
+	var b strings.Builder
+
+	// Decomposed accented letters are a base letter followed by combining marks, dropping the marks leaves the base letter
+	for _, r := range norm.NFD.String(title) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// titleWords returns the distinct normalized words of a title as stored in title_words by migration 8
+func titleWords(title string) []string {
+	words := strings.FieldsFunc(normalizeTitle(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	var unique []string
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			unique = append(unique, word)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,249 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/BunnyTheLifeguard/greenlight/internal/data"
+)
+
+// maxRecentViews is the number of recently viewed movies kept per user for the profile
+const maxRecentViews = 20
+
+// Weights holds the share each signal has in the similarity score of two movies
+type Weights struct {
+	Genre   float64
+	Year    float64
+	Runtime float64
+}
+
+// DefaultWeights favours genre overlap over year & runtime
+var DefaultWeights = Weights{Genre: 0.6, Year: 0.25, Runtime: 0.15}
+
+// Scales of the year & runtime proximity, a difference of this size halves the score of the signal
+const (
+	yearScale    = 10.0
+	runtimeScale = 30.0
+)
+
+// profile holds the features similarity is scored against, either a single movie or the movies of a user
+type profile struct {
+	genres  map[string]bool
+	year    float64
+	runtime float64
+	exclude map[string]bool
+}
+
+// Index holds a snapshot of all movies & the recently viewed movies of every user, the snapshot is replaced by Rebuild
+type Index struct {
+	weights Weights
+
+	mu     sync.RWMutex
+	movies []*data.Movie
+	byID   map[string]*data.Movie
+	recent map[string][]string
+}
+
+// New returns an empty Index scoring with weights
+func New(weights Weights) *Index {
+	return &Index{
+		weights: weights,
+		byID:    make(map[string]*data.Movie),
+		recent:  make(map[string][]string),
+	}
+}
+
+// Rebuild replaces the snapshot with the movies currently in store, movies in the trash are left out
+func (idx *Index) Rebuild(store data.MovieStore) error {
+	var movies []*data.Movie
+	byID := make(map[string]*data.Movie)
+
+	err := store.Export(data.MovieQuery{}, "id", func(movie *data.Movie) error {
+		snapshot := &data.Movie{
+			ID:        movie.ID,
+			Title:     movie.Title,
+			Year:      movie.Year,
+			Runtime:   movie.Runtime,
+			Genres:    movie.Genres,
+			CreatedBy: movie.CreatedBy,
+		}
+		movies = append(movies, snapshot)
+		byID[snapshot.ID] = snapshot
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	idx.movies = movies
+	idx.byID = byID
+	idx.mu.Unlock()
+
+	return nil
+}
+
+// Len returns the number of movies in the snapshot
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.movies)
+}
+
+// Viewed records that a user viewed a movie, only the most recent views are kept
+func (idx *Index) Viewed(userID, movieID string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	recent := []string{movieID}
+	for _, id := range idx.recent[userID] {
+		if id != movieID && len(recent) < maxRecentViews {
+			recent = append(recent, id)
+		}
+	}
+	idx.recent[userID] = recent
+}
+
+// Similar returns the movies most similar to movie, the movie itself isn't part of the result
+func (idx *Index) Similar(movie *data.Movie, limit int) []*data.Movie {
+	p := profile{
+		genres:  make(map[string]bool),
+		year:    float64(movie.Year),
+		runtime: float64(movie.Runtime),
+		exclude: map[string]bool{movie.ID: true},
+	}
+	for _, genre := range movie.Genres {
+		p.genres[genre] = true
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return idx.rank(p, limit)
+}
+
+// ForUser returns recommendations for a user built from the genres of the movies they created or recently viewed.
+// The movies the profile is built from aren't recommended, a user without any has no recommendations
+func (idx *Index) ForUser(userID string, limit int) []*data.Movie {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var movies []*data.Movie
+	for _, movie := range idx.movies {
+		if movie.CreatedBy == userID {
+			movies = append(movies, movie)
+		}
+	}
+	for _, id := range idx.recent[userID] {
+		if movie, ok := idx.byID[id]; ok && movie.CreatedBy != userID {
+			movies = append(movies, movie)
+		}
+	}
+
+	if len(movies) == 0 {
+		return []*data.Movie{}
+	}
+
+	p := profile{genres: make(map[string]bool), exclude: make(map[string]bool)}
+	for _, movie := range movies {
+		for _, genre := range movie.Genres {
+			p.genres[genre] = true
+		}
+		p.year += float64(movie.Year)
+		p.runtime += float64(movie.Runtime)
+		p.exclude[movie.ID] = true
+	}
+	p.year /= float64(len(movies))
+	p.runtime /= float64(len(movies))
+
+	return idx.rank(p, limit)
+}
+
+// rank scores every movie in the snapshot against p & returns copies of the best ones with their score, highest first
+func (idx *Index) rank(p profile, limit int) []*data.Movie {
+	var ranked []*data.Movie
+	for _, movie := range idx.movies {
+		if p.exclude[movie.ID] {
+			continue
+		}
+
+		score := idx.score(p, movie)
+		if score == 0 {
+			continue
+		}
+
+		c := *movie
+		c.CreatedBy = ""
+		c.Score = score
+		ranked = append(ranked, &c)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	if ranked == nil {
+		ranked = []*data.Movie{}
+	}
+
+	return ranked
+}
+
+// score combines the genre overlap, year proximity & runtime similarity of a movie to p into a value between 0 & 1.
+// Movies sharing no genre with p score 0, year & runtime alone don't make a movie similar
+func (idx *Index) score(p profile, movie *data.Movie) float64 {
+	genre := jaccard(p.genres, movie.Genres)
+	if genre == 0 {
+		return 0
+	}
+
+	year := proximity(p.year, float64(movie.Year), yearScale)
+	runtime := proximity(p.runtime, float64(movie.Runtime), runtimeScale)
+
+	w := idx.weights
+	total := w.Genre + w.Year + w.Runtime
+	if total == 0 {
+		return genre
+	}
+
+	score := (w.Genre*genre + w.Year*year + w.Runtime*runtime) / total
+	return math.Round(score*1000) / 1000
+}
+
+// jaccard returns the size of the intersection of both genre sets divided by the size of their union
+func jaccard(set map[string]bool, genres []string) float64 {
+	union := len(set)
+	intersection := 0
+	seen := make(map[string]bool)
+	for _, genre := range genres {
+		if seen[genre] {
+			continue
+		}
+		seen[genre] = true
+
+		if set[genre] {
+			intersection++
+		} else {
+			union++
+		}
+	}
+
+	if union == 0 {
+		return 0
+	}
+
+	return float64(intersection) / float64(union)
+}
+
+// proximity returns 1 for equal values, falling towards 0 as they drift apart, a difference of scale gives 0.5
+func proximity(a, b, scale float64) float64 {
+	return 1 / (1 + math.Abs(a-b)/scale)
+}
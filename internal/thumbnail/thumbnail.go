@@ -0,0 +1,184 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Errors returned by Generate for images which can't be used
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrInvalidDimensions = errors.New("invalid image dimensions")
+)
+
+// jpegQuality is the quality JPEG thumbnails are encoded with
+const jpegQuality = 85
+
+// Limits holds the smallest & largest accepted image dimensions, the maximum guards against decompression bombs
+type Limits struct {
+	MinWidth  int
+	MinHeight int
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Size names a thumbnail width, a Width of 0 keeps the image as uploaded
+type Size struct {
+	Name  string
+	Width int
+}
+
+// Image struct holds an encoded image of a single size
+type Image struct {
+	Size        string
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Sniff returns the content type of data detected from its leading bytes & whether it's a supported format
+func Sniff(data []byte) (string, bool) {
+	contentType := http.DetectContentType(data)
+	return contentType, contentType == "image/jpeg" || contentType == "image/png"
+}
+
+// Generate checks data is a JPEG or PNG image within limits & returns it in every size, thumbnails keep the format of the upload.
+// Images are never scaled up, a size wider than the upload gets an image of the original width
+func Generate(data []byte, limits Limits, sizes []Size) ([]*Image, error) {
+	contentType, ok := Sniff(data)
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	// The header alone gives the dimensions, so oversized images are rejected before they are decoded
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	if config.Width < limits.MinWidth || config.Height < limits.MinHeight {
+		return nil, fmt.Errorf("%w: must be at least %dx%d pixels", ErrInvalidDimensions, limits.MinWidth, limits.MinHeight)
+	}
+	if config.Width > limits.MaxWidth || config.Height > limits.MaxHeight {
+		return nil, fmt.Errorf("%w: must be at most %dx%d pixels", ErrInvalidDimensions, limits.MaxWidth, limits.MaxHeight)
+	}
+
+	var src image.Image
+	switch contentType {
+	case "image/png":
+		src, err = png.Decode(bytes.NewReader(data))
+	default:
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	rgba := toRGBA(src)
+
+	images := make([]*Image, 0, len(sizes))
+	for _, size := range sizes {
+		if size.Width == 0 {
+			images = append(images, &Image{
+				Size:        size.Name,
+				ContentType: contentType,
+				Width:       config.Width,
+				Height:      config.Height,
+				Data:        data,
+			})
+			continue
+		}
+
+		scaled := rgba
+		if size.Width < config.Width {
+			scaled = resize(rgba, size.Width)
+		}
+
+		var buf bytes.Buffer
+		switch contentType {
+		case "image/png":
+			err = png.Encode(&buf, scaled)
+		default:
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: jpegQuality})
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, &Image{
+			Size:        size.Name,
+			ContentType: contentType,
+			Width:       scaled.Bounds().Dx(),
+			Height:      scaled.Bounds().Dy(),
+			Data:        buf.Bytes(),
+		})
+	}
+
+	return images, nil
+}
+
+// toRGBA converts img to an RGBA image with its origin at 0,0
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// resize scales src down to width keeping its aspect ratio, every pixel is the average of the source pixels it covers
+func resize(src *image.RGBA, width int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+
+	height := (sh*width + sw/2) / sw
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, sh)
+
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, sw)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// span returns the range of source pixels covered by destination pixel i of n, spanning at least one pixel
+func span(i, n, size int) (int, int) {
+	start := i * size / n
+	end := (i + 1) * size / n
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
@@ -60,3 +60,14 @@ func Unique(values []string) bool {
 
 	return len(values) == len(uniqueValues)
 }
+
+// AllIn returns true if every value in slice is in list of strings
+func AllIn(values []string, list ...string) bool {
+	for _, value := range values {
+		if !In(value, list...) {
+			return false
+		}
+	}
+
+	return true
+}